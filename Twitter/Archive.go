@@ -0,0 +1,414 @@
+package TwitterAPI
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Archive reads the data export users download from their Twitter settings, either the zip file
+// itself or the directory it was extracted to.
+//
+// The data files are JavaScript assignments like `window.YTD.tweet.part0 = [...]`. They are
+// decoded one element at a time and handed to a callback, so even archives with hundreds of
+// thousands of tweets are read in constant memory. Returning an error from the callback stops the
+// walk and is passed back to the caller.
+type Archive struct {
+	files fs.FS
+	data  map[string][]string
+	close func() error
+}
+
+// ArchiveAccount is the owner of an archive, from account.js
+type ArchiveAccount struct {
+	AccountID   string `json:"accountId"`
+	Username    string `json:"username"`
+	DisplayName string `json:"accountDisplayName"`
+	Email       string `json:"email"`
+	CreatedVia  string `json:"createdVia"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// User returns the account as a User
+func (A *ArchiveAccount) User() User {
+
+	ID, _ := strconv.ParseInt(A.AccountID, 10, 64)
+
+	return User{ID: ID, IDStr: A.AccountID, ScreenName: A.Username, Name: A.DisplayName, CreatedAt: A.CreatedAt}
+}
+
+// ErrNotArchive is returned when a zip or directory has no data/*.js files
+var ErrNotArchive = errors.New("Not a Twitter archive, no data files found")
+
+// dataFile matches the data files, large archives split them into tweet-part1.js, tweet-part2.js...
+var dataFile = regexp.MustCompile(`^(?:.*/)?data/([a-z-]+?)(?:-part(\d+))?\.js$`)
+
+// OpenArchive opens an archive zip file or extracted directory
+func OpenArchive(Path string) (*Archive, error) {
+
+	info, err := os.Stat(Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return newArchive(os.DirFS(Path), func() error { return nil })
+	}
+
+	z, err := zip.OpenReader(Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	A, err := newArchive(z, z.Close)
+
+	if err != nil {
+		z.Close()
+	}
+
+	return A, err
+}
+
+// NewArchive reads an archive zip from memory or any other io.ReaderAt, like an uploaded file
+func NewArchive(r io.ReaderAt, Size int64) (*Archive, error) {
+
+	z, err := zip.NewReader(r, Size)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newArchive(z, func() error { return nil })
+}
+
+func newArchive(Files fs.FS, Close func() error) (*Archive, error) {
+
+	A := &Archive{files: Files, data: make(map[string][]string), close: Close}
+
+	err := fs.WalkDir(Files, ".", func(Name string, d fs.DirEntry, err error) error {
+
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		if m := dataFile.FindStringSubmatch(Name); m != nil {
+			A.data[m[1]] = append(A.data[m[1]], Name)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(A.data) == 0 {
+		return nil, ErrNotArchive
+	}
+
+	for _, Names := range A.data {
+		sort.Slice(Names, func(i, j int) bool { return partNumber(Names[i]) < partNumber(Names[j]) })
+	}
+
+	return A, nil
+}
+
+func partNumber(Name string) int {
+	n, _ := strconv.Atoi(dataFile.FindStringSubmatch(Name)[2])
+	return n
+}
+
+// Close releases the zip file
+func (A *Archive) Close() error {
+	return A.close()
+}
+
+// Files lists the data files found, keyed by kind like "tweet" or "direct-messages"
+func (A *Archive) Files() map[string][]string {
+	return A.data
+}
+
+// Account reads account.js
+func (A *Archive) Account() (*ArchiveAccount, error) {
+
+	var Account *ArchiveAccount
+
+	err := A.each("account", func(raw json.RawMessage) error {
+
+		var e struct {
+			Account ArchiveAccount `json:"account"`
+		}
+
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		Account = &e.Account
+
+		return nil
+	}, "account")
+
+	if err == nil && Account == nil {
+		err = errors.New("The archive's account.js is empty")
+	}
+
+	return Account, err
+}
+
+// Tweets walks tweet.js, the user's own tweets and retweets
+func (A *Archive) Tweets(fn func(Tweet) error) error {
+	return A.each("tweet", func(raw json.RawMessage) error {
+
+		var e struct {
+			Tweet json.RawMessage `json:"tweet"`
+		}
+
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		//older archives have the tweet object itself as the element
+		if e.Tweet == nil {
+			e.Tweet = raw
+		}
+
+		var T Tweet
+
+		if err := unmarshalArchive(e.Tweet, &T); err != nil {
+			return err
+		}
+
+		return fn(T)
+	}, "tweet", "tweets")
+}
+
+// Likes walks like.js. Archives only keep the ID, text and link of liked tweets.
+func (A *Archive) Likes(fn func(Tweet) error) error {
+	return A.each("like", func(raw json.RawMessage) error {
+
+		var e struct {
+			Like struct {
+				TweetID     string `json:"tweetId"`
+				FullText    string `json:"fullText"`
+				ExpandedURL string `json:"expandedUrl"`
+			} `json:"like"`
+		}
+
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		T := Tweet{IDStr: e.Like.TweetID, FullText: e.Like.FullText, Favorited: true}
+		T.ID, _ = strconv.ParseInt(T.IDStr, 10, 64)
+
+		if e.Like.ExpandedURL != "" {
+			T.Entities.URLs = []URLEntity{{ExpandedURL: e.Like.ExpandedURL}}
+		}
+
+		return fn(T)
+	}, "like")
+}
+
+// Followers walks follower.js, archives only keep the user IDs
+func (A *Archive) Followers(fn func(User) error) error {
+	return A.relations("follower", fn)
+}
+
+// Following walks following.js, archives only keep the user IDs
+func (A *Archive) Following(fn func(User) error) error {
+	return A.relations("following", fn)
+}
+
+func (A *Archive) relations(Kind string, fn func(User) error) error {
+	return A.each(Kind, func(raw json.RawMessage) error {
+
+		var e map[string]struct {
+			AccountID string `json:"accountId"`
+		}
+
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		U := User{IDStr: e[Kind].AccountID}
+		U.ID, _ = strconv.ParseInt(U.IDStr, 10, 64)
+
+		return fn(U)
+	}, Kind)
+}
+
+// DirectMessages walks direct-messages.js, every message of every one to one conversation
+func (A *Archive) DirectMessages(fn func(DirectMessage) error) error {
+	return A.each("direct-messages", func(raw json.RawMessage) error {
+
+		var e struct {
+			Conversation struct {
+				ID       string `json:"conversationId"`
+				Messages []struct {
+					Create *struct {
+						ID          string `json:"id"`
+						Text        string `json:"text"`
+						SenderID    string `json:"senderId"`
+						RecipientID string `json:"recipientId"`
+						CreatedAt   string `json:"createdAt"`
+					} `json:"messageCreate"`
+				} `json:"messages"`
+			} `json:"dmConversation"`
+		}
+
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		for _, m := range e.Conversation.Messages {
+
+			//conversation events like joins have no messageCreate
+			if m.Create == nil {
+				continue
+			}
+
+			D := DirectMessage{
+				IDStr:          m.Create.ID,
+				Text:           m.Create.Text,
+				SenderIDStr:    m.Create.SenderID,
+				RecipientIDStr: m.Create.RecipientID,
+				CreatedAt:      m.Create.CreatedAt,
+				ConversationID: e.Conversation.ID,
+			}
+			D.ID, _ = strconv.ParseInt(D.IDStr, 10, 64)
+
+			if err := fn(D); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, "direct-messages")
+}
+
+// each decodes the elements of every part of the first data file kind present, one at a time
+func (A *Archive) each(Name string, fn func(json.RawMessage) error, Kinds ...string) error {
+
+	for _, Kind := range Kinds {
+
+		Files, ok := A.data[Kind]
+
+		if !ok {
+			continue
+		}
+
+		for _, File := range Files {
+			if err := A.decode(File, fn); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("The archive has no %s.js", Name)
+}
+
+func (A *Archive) decode(File string, fn func(json.RawMessage) error) error {
+
+	f, err := A.files.Open(File)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	//skip `window.YTD.tweet.part0 = ` in front of the array
+	if _, err := r.ReadString('='); err != nil {
+		return fmt.Errorf("%s: no window.YTD assignment found", File)
+	}
+
+	dec := json.NewDecoder(r)
+
+	if t, err := dec.Token(); err != nil || t != json.Delim('[') {
+		return fmt.Errorf("%s: expected an array of records", File)
+	}
+
+	for dec.More() {
+
+		var raw json.RawMessage
+
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("%s: %v", path.Base(File), err)
+		}
+
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unmarshalArchive decodes like json.Unmarshal, except that archives quote every number
+// ("favorite_count" : "3", "indices" : ["0", "10"]) so quoted integers are accepted for numeric fields
+func unmarshalArchive(raw json.RawMessage, v interface{}) error {
+
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+
+	var generic interface{}
+
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(unquoteNumbers(generic, ""))
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// quotedNumbers are the keys archives write as strings while the API sends numbers
+var quotedNumbers = map[string]bool{
+	"id": true, "favorite_count": true, "retweet_count": true, "indices": true,
+	"in_reply_to_status_id": true, "in_reply_to_user_id": true, "quoted_status_id": true,
+}
+
+func unquoteNumbers(v interface{}, Key string) interface{} {
+
+	switch V := v.(type) {
+
+	case map[string]interface{}:
+		for k, e := range V {
+			V[k] = unquoteNumbers(e, k)
+		}
+
+	case []interface{}:
+		for i, e := range V {
+			V[i] = unquoteNumbers(e, Key)
+		}
+
+	case string:
+		if quotedNumbers[Key] {
+			if _, err := strconv.ParseInt(V, 10, 64); err == nil {
+				return json.Number(V)
+			}
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,155 @@
+package TwitterAPI
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var archiveFiles = map[string]string{
+	"data/account.js": `window.YTD.account.part0 = [{"account":{"accountId":"42","username":"gopher","accountDisplayName":"Gopher"}}]`,
+	"data/tweet.js": `window.YTD.tweet.part0 = [
+		{"tweet":{"id_str":"1","id":"1","full_text":"first","favorite_count":"3","entities":{"hashtags":[{"text":"go","indices":["0","3"]}]}}},
+		{"tweet":{"id_str":"2","id":"2","full_text":"second"}}
+	]`,
+	"data/tweet-part1.js":       `window.YTD.tweet.part1 = [{"tweet":{"id_str":"3","id":"3","full_text":"third"}}]`,
+	"data/like.js":              `window.YTD.like.part0 = [{"like":{"tweetId":"9","fullText":"liked","expandedUrl":"https://twitter.com/i/web/status/9"}}]`,
+	"data/follower.js":          `window.YTD.follower.part0 = [{"follower":{"accountId":"7"}},{"follower":{"accountId":"8"}}]`,
+	"data/direct-messages.js":   `window.YTD.direct_messages.part0 = [{"dmConversation":{"conversationId":"7-42","messages":[{"messageCreate":{"id":"100","text":"hi","senderId":"7","recipientId":"42"}},{"joinConversation":{}}]}}]`,
+	"assets/images/ignored.jpg": "not data",
+}
+
+func TestArchive(t *testing.T) {
+
+	Dir := t.TempDir()
+
+	var Zip bytes.Buffer
+	z := zip.NewWriter(&Zip)
+
+	for Name, Content := range archiveFiles {
+
+		os.MkdirAll(filepath.Join(Dir, filepath.Dir(Name)), 0755)
+		ioutil.WriteFile(filepath.Join(Dir, Name), []byte(Content), 0644)
+
+		w, _ := z.Create("twitter-2022/" + Name)
+		w.Write([]byte(Content))
+	}
+
+	z.Close()
+	ioutil.WriteFile(filepath.Join(Dir, "archive.zip"), Zip.Bytes(), 0644)
+
+	sources := []struct {
+		name string
+		open func() (*Archive, error)
+	}{
+		{"directory", func() (*Archive, error) { return OpenArchive(Dir) }},
+		{"zip file", func() (*Archive, error) { return OpenArchive(filepath.Join(Dir, "archive.zip")) }},
+		{"zip in memory", func() (*Archive, error) { return NewArchive(bytes.NewReader(Zip.Bytes()), int64(Zip.Len())) }},
+	}
+
+	for _, src := range sources {
+		t.Run(src.name, func(t *testing.T) {
+
+			A, err := src.open()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer A.Close()
+
+			if Account, err := A.Account(); err != nil || Account.Username != "gopher" || Account.User().ID != 42 {
+				t.Errorf("Account() = %+v, %v", Account, err)
+			}
+
+			var Texts []string
+
+			if err := A.Tweets(func(T Tweet) error { Texts = append(Texts, T.FullText); return nil }); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(Texts, []string{"first", "second", "third"}) {
+				t.Errorf("tweets %v, want every part in order", Texts)
+			}
+
+			var Likes, Followers, Messages []string
+
+			A.Likes(func(T Tweet) error { Likes = append(Likes, T.IDStr+" "+T.Entities.URLs[0].ExpandedURL); return nil })
+			A.Followers(func(U User) error { Followers = append(Followers, U.IDStr); return nil })
+			A.DirectMessages(func(D DirectMessage) error { Messages = append(Messages, D.SenderIDStr+" "+D.Text); return nil })
+
+			if !reflect.DeepEqual(Likes, []string{"9 https://twitter.com/i/web/status/9"}) || !reflect.DeepEqual(Followers, []string{"7", "8"}) || !reflect.DeepEqual(Messages, []string{"7 hi"}) {
+				t.Errorf("likes %v, followers %v, messages %v", Likes, Followers, Messages)
+			}
+
+			if err := A.Following(func(User) error { return nil }); err == nil {
+				t.Error("Following found a following.js that isn't there")
+			}
+		})
+	}
+}
+
+func TestArchiveNumbers(t *testing.T) {
+
+	A, err := OpenArchive(writeArchive(t, "data/tweet.js", archiveFiles["data/tweet.js"]))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Stop := errors.New("stop")
+	var First Tweet
+
+	err = A.Tweets(func(T Tweet) error {
+		First = T
+		return Stop
+	})
+
+	if err != Stop {
+		t.Errorf("got %v, want the callback's error", err)
+	}
+
+	if First.ID != 1 || First.FavoriteCount != 3 || len(First.Entities.Hashtags) != 1 || First.Entities.Hashtags[0].Indices[1] != 3 {
+		t.Errorf("got %+v, want the quoted numbers decoded", First)
+	}
+}
+
+func TestNotArchive(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		err     error
+	}{
+		{"no data files", "readme.txt", "hello", ErrNotArchive},
+		{"not a data file name", "data/Tweet.json", "[]", ErrNotArchive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := OpenArchive(writeArchive(t, tt.file, tt.content)); err != tt.err {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// writeArchive extracts a one file archive to a temporary directory
+func writeArchive(t *testing.T, Name, Content string) string {
+
+	Dir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(Dir, filepath.Dir(Name)), 0755)
+
+	if err := ioutil.WriteFile(filepath.Join(Dir, Name), []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return Dir
+}
@@ -0,0 +1,765 @@
+package TwitterAPI
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BotRequest is a mention handed to a BotHandler
+type BotRequest struct {
+	Tweet Tweet
+	// Text is the tweet without the @mentions in front of it
+	Text string
+	// Match holds the submatches of a Handle pattern, Args the words following a Command
+	Match []string
+	Args  []string
+}
+
+// BotHandler answers a mention, the text returned is tweeted as a reply in its thread. Returning
+// "" leaves the mention unanswered.
+type BotHandler func(R *BotRequest) (string, error)
+
+// ReplyLimit allows Replies replies every Per
+type ReplyLimit struct {
+	Replies int
+	Per     time.Duration
+}
+
+// ErrReplyLimited is reported for mentions left unanswered because of UserLimit, and returned by
+// Process when GlobalLimit is reached
+var ErrReplyLimited = errors.New("Reply rate limit reached")
+
+// Bot answers mentions of Account.
+//
+// Mentions are routed to the first handler whose pattern matches, in the order they were added.
+// A mention is marked handled in Store once it is answered, or once it turns out it won't be (no
+// route, a handler error, a permanent reply error or the sender's UserLimit). Mentions whose reply
+// failed for a transient reason, or that hit GlobalLimit, are left for the next poll. The webhook
+// has no poll, it queues them and tries again once GlobalLimit has room or PollInterval passed.
+//
+// Handlers run concurrently for mentions arriving through the webhook, a mention is only ever
+// handled by one of them at a time.
+//
+//	B := TwitterAPI.NewBot(&T, TwitterAPI.NewFileHandledStore("handled.json"))
+//	B.Command("ping", func(R *TwitterAPI.BotRequest) (string, error) { return "pong", nil })
+//	B.Handle(`(?i)weather in (\w+)`, weather)
+//	err := B.Run(ctx)
+type Bot struct {
+	Account *Account
+	Store   HandledStore
+
+	// PollInterval is how often Run reads the mentions timeline, a minute when zero
+	PollInterval time.Duration
+
+	// UserLimit caps replies to a single user, 5 an hour when zero. Mentions over it are dropped.
+	UserLimit ReplyLimit
+	// GlobalLimit caps all replies, 60 an hour when zero. Mentions over it wait.
+	GlobalLimit ReplyLimit
+
+	// SkipBacklog marks the mentions already there as handled the first time Run starts on an
+	// empty Store, instead of answering them
+	SkipBacklog bool
+
+	// NotFound answers mentions no route matches, they are left unanswered when nil
+	NotFound BotHandler
+
+	// OnError is told about every mention that couldn't be answered
+	OnError func(Tweet, error)
+
+	mu       sync.Mutex
+	routes   []botRoute
+	me       string
+	sent     []time.Time
+	perUser  map[string][]time.Time
+	inflight map[string]bool
+
+	// waiting are webhook mentions to try again when retry fires
+	waiting []Tweet
+	retry   *time.Timer
+}
+
+type botRoute struct {
+	pattern *regexp.Regexp
+	command string
+	handler BotHandler
+}
+
+// NewBot returns a bot answering mentions of A, with what was handled kept in Store
+func NewBot(A *Account, Store HandledStore) *Bot {
+	return &Bot{Account: A, Store: Store}
+}
+
+// Handle routes mentions matching the regular expression Pattern to H, it panics if Pattern doesn't compile
+func (B *Bot) Handle(Pattern string, H BotHandler) {
+
+	B.mu.Lock()
+	defer B.mu.Unlock()
+
+	B.routes = append(B.routes, botRoute{pattern: regexp.MustCompile(Pattern), handler: H})
+}
+
+// Command routes mentions whose first word is Name, with or without a leading / or !, to H.
+// The words after it are the request's Args.
+func (B *Bot) Command(Name string, H BotHandler) {
+
+	B.mu.Lock()
+	defer B.mu.Unlock()
+
+	B.routes = append(B.routes, botRoute{command: strings.ToLower(strings.TrimLeft(Name, "/!")), handler: H})
+}
+
+// route must be called with B.mu held
+func (B *Bot) route(T Tweet) (*BotRequest, BotHandler) {
+
+	R := &BotRequest{Tweet: T, Text: strings.TrimSpace(leadingMentions.ReplaceAllString(html.UnescapeString(T.Body()), ""))}
+
+	Words := strings.Fields(R.Text)
+
+	for _, r := range B.routes {
+
+		if r.pattern != nil {
+			if m := r.pattern.FindStringSubmatch(R.Text); m != nil {
+				R.Match = m
+				return R, r.handler
+			}
+			continue
+		}
+
+		if len(Words) > 0 && strings.ToLower(strings.TrimLeft(Words[0], "/!")) == r.command {
+			R.Args = Words[1:]
+			return R, r.handler
+		}
+	}
+
+	return R, B.NotFound
+}
+
+// Process handles a single mention, as Run and the webhook do for each mention they receive. It
+// returns ErrReplyLimited when GlobalLimit is reached, transient reply errors, and Store errors.
+// B.mu is only held to route the mention and count it against the limits, the handler and the
+// reply run without it.
+func (B *Bot) Process(T Tweet) error {
+
+	if T.RetweetedStatus != nil || T.User == nil {
+		return nil
+	}
+
+	B.mu.Lock()
+
+	if T.User.IDStr == B.me || B.inflight[T.IDStr] {
+		B.mu.Unlock()
+		return nil
+	}
+
+	if B.inflight == nil {
+		B.inflight = make(map[string]bool)
+	}
+
+	B.inflight[T.IDStr] = true
+	B.mu.Unlock()
+
+	defer func() {
+		B.mu.Lock()
+		delete(B.inflight, T.IDStr)
+		B.mu.Unlock()
+	}()
+
+	done, err := B.Store.IsHandled(T.IDStr)
+
+	if err != nil || done {
+		return err
+	}
+
+	R, H, Now, err := B.reserve(T)
+
+	if H == nil {
+
+		if err == ErrReplyLimited {
+			return err
+		}
+
+		if err != nil {
+			B.report(T, err)
+		}
+
+		return B.Store.MarkHandled(T.IDStr)
+	}
+
+	Reply, err := H(R)
+
+	if err != nil {
+		B.unreserve(T, Now)
+		B.report(T, err)
+		return B.Store.MarkHandled(T.IDStr)
+	}
+
+	if Reply == "" {
+		B.unreserve(T, Now)
+		return B.Store.MarkHandled(T.IDStr)
+	}
+
+	if _, err := B.Account.Reply(T.IDStr, Reply, ""); err != nil {
+
+		B.unreserve(T, Now)
+
+		if transient(err) {
+			return err
+		}
+
+		B.report(T, err)
+
+		return B.Store.MarkHandled(T.IDStr)
+	}
+
+	return B.Store.MarkHandled(T.IDStr)
+}
+
+// reserve routes T and counts a reply against the limits, before the handler runs so concurrent
+// mentions see it. The handler is nil when there is nothing to do: no route matched, or the error
+// says which limit was hit.
+func (B *Bot) reserve(T Tweet) (*BotRequest, BotHandler, time.Time, error) {
+
+	B.mu.Lock()
+	defer B.mu.Unlock()
+
+	R, H := B.route(T)
+	Now := time.Now()
+
+	if H == nil {
+		return R, nil, Now, nil
+	}
+
+	Global := limitOrDefault(B.GlobalLimit, 60)
+	User := limitOrDefault(B.UserLimit, 5)
+
+	B.sent = within(B.sent, Now, Global.Per)
+
+	if len(B.sent) >= Global.Replies {
+		return R, nil, Now, ErrReplyLimited
+	}
+
+	if B.perUser == nil {
+		B.perUser = make(map[string][]time.Time)
+	}
+
+	B.perUser[T.User.IDStr] = within(B.perUser[T.User.IDStr], Now, User.Per)
+
+	if len(B.perUser[T.User.IDStr]) >= User.Replies {
+		return R, nil, Now, fmt.Errorf("%w for @%s", ErrReplyLimited, T.User.ScreenName)
+	}
+
+	B.sent = append(B.sent, Now)
+	B.perUser[T.User.IDStr] = append(B.perUser[T.User.IDStr], Now)
+
+	return R, H, Now, nil
+}
+
+// unreserve gives back the reply reserved at At, when none was sent after all
+func (B *Bot) unreserve(T Tweet, At time.Time) {
+
+	B.mu.Lock()
+	defer B.mu.Unlock()
+
+	B.sent = without(B.sent, At)
+	B.perUser[T.User.IDStr] = without(B.perUser[T.User.IDStr], At)
+}
+
+// without removes one occurrence of At from Times
+func without(Times []time.Time, At time.Time) []time.Time {
+
+	for i := range Times {
+		if Times[i].Equal(At) {
+			return append(Times[:i:i], Times[i+1:]...)
+		}
+	}
+
+	return Times
+}
+
+func (B *Bot) report(T Tweet, err error) {
+	if B.OnError != nil {
+		B.OnError(T, err)
+	}
+}
+
+func limitOrDefault(L ReplyLimit, Replies int) ReplyLimit {
+
+	if L.Replies <= 0 {
+		L.Replies = Replies
+	}
+
+	if L.Per <= 0 {
+		L.Per = time.Hour
+	}
+
+	return L
+}
+
+// within drops the times older than Per
+func within(Times []time.Time, Now time.Time, Per time.Duration) []time.Time {
+
+	i := 0
+
+	for i < len(Times) && Now.Sub(Times[i]) >= Per {
+		i++
+	}
+
+	return Times[i:]
+}
+
+// identify looks up the bot's own user ID so its own tweets are never answered
+func (B *Bot) identify() error {
+
+	B.mu.Lock()
+	defer B.mu.Unlock()
+
+	if B.me != "" {
+		return nil
+	}
+
+	resp, err := B.Account.VerifyCredential()
+
+	if err != nil {
+		return err
+	}
+
+	var Me User
+
+	if err := Decode(resp, &Me); err != nil {
+		return err
+	}
+
+	B.me = Me.IDStr
+
+	return nil
+}
+
+// Run polls the mentions timeline and answers new mentions until ctx is canceled
+func (B *Bot) Run(ctx context.Context) error {
+
+	if err := B.identify(); err != nil {
+		return err
+	}
+
+	Since, err := B.Store.Newest()
+
+	if err != nil {
+		return err
+	}
+
+	Skip := Since == "" && B.SkipBacklog
+
+	Interval := B.PollInterval
+
+	if Interval <= 0 {
+		Interval = time.Minute
+	}
+
+	for {
+
+		Mentions, err := B.mentions(Since)
+
+		if Wait, ok := rateLimitWait(err); ok {
+
+			if err := sleepContext(ctx, Wait); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil && !transient(err) {
+			return err
+		}
+
+		for _, T := range Mentions {
+
+			if Skip {
+				err = B.Store.MarkHandled(T.IDStr)
+			} else {
+				err = B.Process(T)
+			}
+
+			if err == ErrReplyLimited || err != nil && transient(err) {
+				break
+			}
+
+			if err != nil {
+				return err
+			}
+
+			Since = T.IDStr
+		}
+
+		Skip = false
+
+		if err := sleepContext(ctx, Interval); err != nil {
+			return err
+		}
+	}
+}
+
+// mentionsPage is how many mentions are asked for per request, the most the API returns
+var mentionsPage = 200
+
+// mentions returns the mentions after Since, oldest first. It pages back with max_id until a
+// short page or Since, so a burst of mentions between two polls isn't cut to the newest page.
+func (B *Bot) mentions(Since string) ([]Tweet, error) {
+
+	var Params = url.Values{}
+
+	Params.Add("count", strconv.Itoa(mentionsPage))
+
+	if Since != "" {
+		Params.Add("since_id", Since)
+	}
+
+	SinceID, _ := strconv.ParseInt(Since, 10, 64)
+
+	var Mentions []Tweet
+
+	for {
+
+		resp, err := B.Account.DoRequest(ENDPOINT.MentionsTimeline, Params, "GET")
+
+		if err != nil {
+			return nil, err
+		}
+
+		var Page []Tweet
+
+		if err := Decode(resp, &Page); err != nil {
+			return nil, err
+		}
+
+		Mentions = append(Mentions, Page...)
+
+		if len(Page) < mentionsPage {
+			break
+		}
+
+		Oldest := Page[len(Page)-1].ID
+
+		if Oldest-1 <= SinceID {
+			break
+		}
+
+		Params.Set("max_id", strconv.FormatInt(Oldest-1, 10))
+	}
+
+	sort.SliceStable(Mentions, func(i, j int) bool { return Mentions[i].ID < Mentions[j].ID })
+
+	return Mentions, nil
+}
+
+// WebhookHandler serves an Account Activity API webhook for the bot. It answers the CRC challenge,
+// checks the signature of every event and answers the mentions among the tweet_create_events in
+// the background, so Twitter gets its response right away. Register it with the same consumer
+// key the Account uses.
+func (B *Bot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		Client, _ := B.Account.credentials()
+		Secret := Client.Credentials.Secret
+
+		switch r.Method {
+
+		case "GET":
+			CRC := r.URL.Query().Get("crc_token")
+
+			if CRC == "" {
+				http.Error(w, "crc_token missing", http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"response_token": "sha256=" + webhookSignature(Secret, []byte(CRC))})
+
+		case "POST":
+			Body, err := ioutil.ReadAll(io.LimitReader(r.Body, 10<<20))
+
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			Signature := strings.TrimPrefix(r.Header.Get("X-Twitter-Webhooks-Signature"), "sha256=")
+
+			if !hmac.Equal([]byte(Signature), []byte(webhookSignature(Secret, Body))) {
+				http.Error(w, "bad signature", http.StatusUnauthorized)
+				return
+			}
+
+			var Event struct {
+				ForUserID string  `json:"for_user_id"`
+				Tweets    []Tweet `json:"tweet_create_events"`
+			}
+
+			if err := json.Unmarshal(Body, &Event); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+
+			go B.webhookEvents(Event.ForUserID, Event.Tweets)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (B *Bot) webhookEvents(ForUserID string, Tweets []Tweet) {
+
+	B.mu.Lock()
+	if B.me == "" {
+		B.me = ForUserID
+	}
+	B.mu.Unlock()
+
+	var Mentions []Tweet
+
+	for _, T := range Tweets {
+		if mentionsUser(T, ForUserID) {
+			Mentions = append(Mentions, T)
+		}
+	}
+
+	B.processQueued(Mentions)
+}
+
+// processQueued handles webhook mentions, queueing the ones that have to wait
+func (B *Bot) processQueued(Mentions []Tweet) {
+
+	for _, T := range Mentions {
+
+		err := B.Process(T)
+
+		switch {
+		case err == ErrReplyLimited:
+			B.requeue(T)
+		case err != nil && transient(err):
+			B.report(T, err)
+			B.requeue(T)
+		case err != nil:
+			B.report(T, err)
+		}
+	}
+}
+
+// requeue keeps T for the next retry, which is set for when GlobalLimit has room again, or
+// PollInterval from now when that is later
+func (B *Bot) requeue(T Tweet) {
+
+	B.mu.Lock()
+	defer B.mu.Unlock()
+
+	B.waiting = append(B.waiting, T)
+
+	if B.retry != nil {
+		return
+	}
+
+	Wait := B.PollInterval
+
+	if Wait <= 0 {
+		Wait = time.Minute
+	}
+
+	Global := limitOrDefault(B.GlobalLimit, 60)
+
+	if len(B.sent) >= Global.Replies {
+		if Free := time.Until(B.sent[0].Add(Global.Per)); Free > Wait {
+			Wait = Free
+		}
+	}
+
+	B.retry = time.AfterFunc(Wait, func() {
+
+		B.mu.Lock()
+		Waiting := B.waiting
+		B.waiting, B.retry = nil, nil
+		B.mu.Unlock()
+
+		B.processQueued(Waiting)
+	})
+}
+
+// mentionsUser reports whether T mentions the user with UserID
+func mentionsUser(T Tweet, UserID string) bool {
+
+	for _, m := range T.Entities.UserMentions {
+		if m.IDStr == UserID || strconv.FormatInt(m.ID, 10) == UserID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func webhookSignature(Secret string, Data []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(Secret))
+	mac.Write(Data)
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HandledStore remembers which mentions a Bot has handled, implementations must be safe for concurrent use
+type HandledStore interface {
+	IsHandled(TweetID string) (bool, error)
+	MarkHandled(TweetID string) error
+	// Newest returns the newest mention handled, Run starts reading after it
+	Newest() (string, error)
+}
+
+// FileHandledStore is a HandledStore keeping the most recent handled mentions in a JSON file,
+// or only in memory when Path is empty
+type FileHandledStore struct {
+	Path string
+
+	mu      sync.Mutex
+	loaded  bool
+	newest  string
+	handled []string
+	set     map[string]bool
+}
+
+// handledKept bounds how many mention IDs a FileHandledStore keeps
+const handledKept = 10000
+
+// NewFileHandledStore returns a store at Path, the file is created on the first MarkHandled
+func NewFileHandledStore(Path string) *FileHandledStore {
+	return &FileHandledStore{Path: Path}
+}
+
+type handledFile struct {
+	Newest  string   `json:"newest"`
+	Handled []string `json:"handled"`
+}
+
+// load must be called with S.mu held
+func (S *FileHandledStore) load() error {
+
+	if S.loaded {
+		return nil
+	}
+
+	S.set = make(map[string]bool)
+
+	if S.Path != "" {
+
+		data, err := ioutil.ReadFile(S.Path)
+
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err == nil {
+
+			var F handledFile
+
+			if err := json.Unmarshal(data, &F); err != nil {
+				return fmt.Errorf("Handled mentions file %s is corrupt: %v", S.Path, err)
+			}
+
+			S.newest, S.handled = F.Newest, F.Handled
+
+			for _, ID := range S.handled {
+				S.set[ID] = true
+			}
+		}
+	}
+
+	S.loaded = true
+
+	return nil
+}
+
+func (S *FileHandledStore) IsHandled(TweetID string) (bool, error) {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	if err := S.load(); err != nil {
+		return false, err
+	}
+
+	return S.set[TweetID], nil
+}
+
+func (S *FileHandledStore) MarkHandled(TweetID string) error {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	if err := S.load(); err != nil {
+		return err
+	}
+
+	if !S.set[TweetID] {
+
+		S.set[TweetID] = true
+		S.handled = append(S.handled, TweetID)
+
+		if len(S.handled) > handledKept {
+			for _, Old := range S.handled[:len(S.handled)-handledKept] {
+				delete(S.set, Old)
+			}
+			S.handled = append([]string(nil), S.handled[len(S.handled)-handledKept:]...)
+		}
+	}
+
+	if newerID(TweetID, S.newest) {
+		S.newest = TweetID
+	}
+
+	if S.Path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(handledFile{Newest: S.newest, Handled: S.handled}, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(S.Path, data, 0600)
+}
+
+func (S *FileHandledStore) Newest() (string, error) {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	err := S.load()
+
+	return S.newest, err
+}
+
+// newerID compares two tweet IDs, anything is newer than ""
+func newerID(ID, Than string) bool {
+
+	a, _ := strconv.ParseInt(ID, 10, 64)
+	b, _ := strconv.ParseInt(Than, 10, 64)
+
+	return a > b
+}
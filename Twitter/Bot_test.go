@@ -0,0 +1,336 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestBotRouting(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	B := NewBot(login(t, S, "bot"), NewFileHandledStore(filepath.Join(t.TempDir(), "handled.json")))
+	Alice := login(t, S, "alice")
+
+	var Errors []error
+	B.OnError = func(_ Tweet, err error) { Errors = append(Errors, err) }
+
+	B.Command("/ping", func(R *BotRequest) (string, error) {
+		return strings.Join(append([]string{"pong"}, R.Args...), " "), nil
+	})
+	B.Handle(`(?i)weather in (\w+)`, func(R *BotRequest) (string, error) { return "sunny in " + R.Match[1], nil })
+	B.Command("fail", func(R *BotRequest) (string, error) { return "", errors.New("broken") })
+	B.Command("quiet", func(R *BotRequest) (string, error) { return "", nil })
+
+	if err := B.identify(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mention string
+		reply   string
+		errors  int
+	}{
+		{"@bot ping a b", "@alice pong a b", 0},
+		{"@bot !PING", "@alice pong", 0},
+		{"@bot what's the Weather in Lisbon?", "@alice sunny in Lisbon", 0},
+		{"@bot pingpong", "", 0},
+		{"@bot fail", "", 1},
+		{"@bot quiet", "", 1},
+		{"hi @bot ping", "", 1},
+	}
+
+	Since := ""
+
+	for _, tt := range tests {
+		t.Run(tt.mention, func(t *testing.T) {
+
+			resp, err := Alice.Tweet(tt.mention, "", "", false, false)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Mentions, err := B.mentions(Since)
+
+			if err != nil || len(Mentions) != 1 {
+				t.Fatalf("got %d mentions, %v", len(Mentions), err)
+			}
+
+			Since = Mentions[0].IDStr
+
+			if err := B.Process(Mentions[0]); err != nil {
+				t.Fatal(err)
+			}
+
+			var Reply string
+
+			if Newest := S.Tweets()[0]; Newest.InReplyToStatusIDStr != nil && *Newest.InReplyToStatusIDStr == idOf(t, resp) {
+				Reply = Newest.Text
+			}
+
+			if Reply != tt.reply || len(Errors) != tt.errors {
+				t.Errorf("got reply %q and errors %v, want %q and %d errors", Reply, Errors, tt.reply, tt.errors)
+			}
+
+			if Done, _ := B.Store.IsHandled(Since); !Done {
+				t.Error("mention not marked handled")
+			}
+
+			//answering the same mention twice would be a duplicate reply
+			Before := len(S.Tweets())
+
+			if err := B.Process(Mentions[0]); err != nil || len(S.Tweets()) != Before {
+				t.Errorf("processing again: %v, %d tweets, want %d", err, len(S.Tweets()), Before)
+			}
+		})
+	}
+}
+
+func TestBotLimits(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	B := NewBot(login(t, S, "bot"), NewFileHandledStore(filepath.Join(t.TempDir(), "handled.json")))
+	B.UserLimit = ReplyLimit{Replies: 1}
+	B.GlobalLimit = ReplyLimit{Replies: 2}
+	B.Command("ping", func(R *BotRequest) (string, error) { return "pong " + R.Args[0], nil })
+
+	var Reported []error
+	B.OnError = func(_ Tweet, err error) { Reported = append(Reported, err) }
+
+	tests := []struct {
+		from     string
+		arg      string
+		err      error
+		handled  bool
+		reported bool
+	}{
+		{"alice", "1", nil, true, false},
+		{"alice", "2", nil, true, true},
+		{"bob", "3", nil, true, false},
+		{"carol", "4", ErrReplyLimited, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" "+tt.arg, func(t *testing.T) {
+
+			if _, err := login(t, S, tt.from).Tweet("@bot ping "+tt.arg, "", "", false, false); err != nil {
+				t.Fatal(err)
+			}
+
+			Mentions, _ := B.mentions("")
+			T := Mentions[len(Mentions)-1]
+			Reported = nil
+
+			if err := B.Process(T); err != tt.err {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+
+			if Done, _ := B.Store.IsHandled(T.IDStr); Done != tt.handled {
+				t.Errorf("handled %v, want %v", Done, tt.handled)
+			}
+
+			if Reported := len(Reported) == 1 && errors.Is(Reported[0], ErrReplyLimited); Reported != tt.reported {
+				t.Errorf("reported %v, want %v", Reported, tt.reported)
+			}
+		})
+	}
+
+	if n := len(S.Tweets()); n != 6 {
+		t.Errorf("%d tweets, want 4 mentions and 2 replies", n)
+	}
+}
+
+func TestBotMentionsPaging(t *testing.T) {
+
+	defer func(Page int) { mentionsPage = Page }(mentionsPage)
+	mentionsPage = 2
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	B := NewBot(login(t, S, "bot"), NewFileHandledStore(filepath.Join(t.TempDir(), "handled.json")))
+	Alice := login(t, S, "alice")
+
+	var IDs []string
+
+	for i := 1; i <= 5; i++ {
+
+		resp, err := Alice.Tweet(fmt.Sprintf("@bot ping %d", i), "", "", false, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		IDs = append(IDs, idOf(t, resp))
+	}
+
+	//the mention IDs follow each other, so a full page ending right after Since is the last one
+	tests := []struct {
+		name     string
+		since    string
+		want     []string
+		requests int
+	}{
+		{"everything", "", IDs, 3},
+		{"stops at since", IDs[0], IDs[1:], 2},
+		{"one full page", IDs[2], IDs[3:], 1},
+		{"nothing new", IDs[4], nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Before := len(S.Requests())
+
+			Mentions, err := B.mentions(tt.since)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var Got []string
+
+			for _, M := range Mentions {
+				Got = append(Got, M.IDStr)
+			}
+
+			if !reflect.DeepEqual(Got, tt.want) || len(S.Requests())-Before != tt.requests {
+				t.Errorf("got %v in %d requests, want %v in %d", Got, len(S.Requests())-Before, tt.want, tt.requests)
+			}
+		})
+	}
+}
+
+func TestBotWebhook(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		global ReplyLimit
+		fault  *TwitterTest.Fault
+		errors int
+	}{
+		{"answered", ReplyLimit{}, nil, 0},
+		{"global limit requeues", ReplyLimit{Replies: 1, Per: 50 * time.Millisecond}, nil, 0},
+		{"server error requeues", ReplyLimit{}, &TwitterTest.Fault{Status: 503}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			S := TwitterTest.NewServer("ck", "cs")
+			defer S.Close()
+
+			B := NewBot(login(t, S, "bot"), NewFileHandledStore(filepath.Join(t.TempDir(), "handled.json")))
+			B.PollInterval = 10 * time.Millisecond
+			B.GlobalLimit = tt.global
+			B.Command("ping", func(R *BotRequest) (string, error) { return "pong", nil })
+
+			Errors := make(chan error, 10)
+			B.OnError = func(_ Tweet, err error) { Errors <- err }
+
+			for _, From := range []string{"alice", "bob"} {
+				login(t, S, From).Tweet("@bot ping", "", "", false, false)
+			}
+
+			Mentions, err := B.mentions("")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var Bot User
+
+			resp, _ := B.Account.VerifyCredential()
+			Decode(resp, &Bot)
+
+			if tt.fault != nil {
+				S.InjectFault("statuses/update.json", *tt.fault, 1)
+			}
+
+			Body, _ := json.Marshal(map[string]interface{}{"for_user_id": Bot.IDStr, "tweet_create_events": Mentions})
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(Body))
+			r.Header.Set("X-Twitter-Webhooks-Signature", "sha256="+webhookSignature("cs", Body))
+
+			B.WebhookHandler().ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("got %d, want 200", w.Code)
+			}
+
+			//the mentions are answered in the background, each is marked handled after its reply
+			for Deadline := time.Now().Add(2 * time.Second); time.Now().Before(Deadline); time.Sleep(5 * time.Millisecond) {
+
+				First, _ := B.Store.IsHandled(Mentions[0].IDStr)
+				Second, _ := B.Store.IsHandled(Mentions[1].IDStr)
+
+				if First && Second {
+					break
+				}
+			}
+
+			if n := len(S.Tweets()); n != 4 {
+				t.Errorf("%d tweets, want both mentions answered", n)
+			}
+
+			if n := len(Errors); n != tt.errors {
+				t.Errorf("%d errors reported, want %d", n, tt.errors)
+			}
+		})
+	}
+}
+
+func TestWebhookRequests(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	H := NewBot(login(t, S, "bot"), NewFileHandledStore(filepath.Join(t.TempDir(), "handled.json"))).WebhookHandler()
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		signature string
+		status    int
+		response  string
+	}{
+		{"crc", "GET", "/webhook?crc_token=abc", "", "", 200, `{"response_token":"sha256=` + webhookSignature("cs", []byte("abc")) + `"}`},
+		{"no crc token", "GET", "/webhook", "", "", 400, ""},
+		{"bad signature", "POST", "/webhook", `{}`, "sha256=" + webhookSignature("other", []byte(`{}`)), 401, ""},
+		{"bad json", "POST", "/webhook", `{`, "sha256=" + webhookSignature("cs", []byte(`{`)), 400, ""},
+		{"other method", "PUT", "/webhook", "", "", 405, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			r.Header.Set("X-Twitter-Webhooks-Signature", tt.signature)
+
+			H.ServeHTTP(w, r)
+
+			if w.Code != tt.status || tt.response != "" && strings.TrimSpace(w.Body.String()) != tt.response {
+				t.Errorf("got %d %s, want %d %s", w.Code, w.Body, tt.status, tt.response)
+			}
+		})
+	}
+}
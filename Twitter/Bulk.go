@@ -0,0 +1,505 @@
+package TwitterAPI
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TweetSource walks tweets, calling fn for each until it returns an error. Archive.Tweets and
+// Account.EachTimelineTweet are both sources.
+type TweetSource func(fn func(Tweet) error) error
+
+// Match is a three way filter on a yes/no property of a tweet
+type Match int
+
+const (
+	// MatchAny ignores the property
+	MatchAny Match = iota
+	// MatchOnly keeps tweets that have it
+	MatchOnly
+	// MatchNone keeps tweets that don't
+	MatchNone
+)
+
+func (M Match) allows(Has bool) bool {
+	return M == MatchAny || (M == MatchOnly) == Has
+}
+
+// TweetFilter picks the tweets a bulk operation acts on, a zero TweetFilter matches everything
+type TweetFilter struct {
+	// Since and Until bound the creation time, Until is exclusive. Zero values leave that side open.
+	Since time.Time
+	Until time.Time
+
+	// Keywords match when any of them is in the text, case insensitively. Empty matches everything.
+	Keywords []string
+
+	// ExcludeKeywords protect tweets containing any of them
+	ExcludeKeywords []string
+
+	Retweets Match
+	Replies  Match
+	Media    Match
+
+	// MinEngagement only matches tweets with at least this many likes plus retweets
+	MinEngagement int
+
+	// KeepPopular protects tweets with at least this many likes plus retweets, 0 disables it
+	KeepPopular int
+
+	// Func is an extra test for anything the fields above can't express
+	Func func(*Tweet) bool
+}
+
+// Matches reports whether T passes every condition of the filter
+func (F *TweetFilter) Matches(T *Tweet) bool {
+
+	if !F.Since.IsZero() || !F.Until.IsZero() {
+
+		Created, err := T.CreatedTime()
+
+		if err != nil || (!F.Since.IsZero() && Created.Before(F.Since)) || (!F.Until.IsZero() && !Created.Before(F.Until)) {
+			return false
+		}
+	}
+
+	Text := strings.ToLower(T.Body())
+
+	if len(F.Keywords) > 0 && !containsAny(Text, F.Keywords) {
+		return false
+	}
+
+	if containsAny(Text, F.ExcludeKeywords) {
+		return false
+	}
+
+	//archives mark retweets by their text only
+	IsRetweet := T.RetweetedStatus != nil || strings.HasPrefix(T.Body(), "RT @")
+
+	if !F.Retweets.allows(IsRetweet) || !F.Replies.allows(T.InReplyToStatusIDStr != "") {
+		return false
+	}
+
+	HasMedia := len(T.Entities.Media) > 0 || (T.ExtendedEntities != nil && len(T.ExtendedEntities.Media) > 0)
+
+	if !F.Media.allows(HasMedia) {
+		return false
+	}
+
+	Engagement := T.FavoriteCount + T.RetweetCount
+
+	if Engagement < F.MinEngagement || (F.KeepPopular > 0 && Engagement >= F.KeepPopular) {
+		return false
+	}
+
+	return F.Func == nil || F.Func(T)
+}
+
+func containsAny(Text string, Words []string) bool {
+
+	for _, w := range Words {
+		if w != "" && strings.Contains(Text, strings.ToLower(w)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EachTimelineTweet walks the account's own timeline from the newest tweet back, retweets included.
+// Twitter only serves the latest 3,200 tweets this way, older ones have to come from an Archive.
+func (P *Account) EachTimelineTweet(fn func(Tweet) error) error {
+
+	Params := url.Values{
+		"count":       {"200"},
+		"include_rts": {"true"},
+		"tweet_mode":  {"extended"},
+	}
+
+	if P.ScreenName != "" {
+		Params.Set("screen_name", P.ScreenName)
+	} else {
+
+		var Me User
+
+		resp, err := P.VerifyCredential()
+
+		if err == nil {
+			err = Decode(resp, &Me)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		Params.Set("user_id", Me.IDStr)
+	}
+
+	return eachPage(P, ENDPOINT.UserTimeline, Params, fn)
+}
+
+// EachFavorite walks the tweets the account has liked, newest like first
+func (P *Account) EachFavorite(fn func(Tweet) error) error {
+
+	Params := url.Values{
+		"count":      {"200"},
+		"tweet_mode": {"extended"},
+	}
+
+	if P.ScreenName != "" {
+		Params.Set("screen_name", P.ScreenName)
+	}
+
+	return eachPage(P, ENDPOINT.FavoriteList, Params, fn)
+}
+
+// eachPage follows max_id down a timeline style endpoint until a page comes back empty
+func eachPage(P *Account, Endpoint string, Params url.Values, fn func(Tweet) error) error {
+
+	for {
+
+		resp, err := P.DoRequest(Endpoint, Params, "GET")
+
+		if err != nil {
+			return err
+		}
+
+		var Page []Tweet
+
+		if err := Decode(resp, &Page); err != nil {
+			return err
+		}
+
+		var Oldest int64
+
+		for _, T := range Page {
+
+			if err := fn(T); err != nil {
+				return err
+			}
+
+			if ID, err := strconv.ParseInt(T.IDStr, 10, 64); err == nil && (Oldest == 0 || ID < Oldest) {
+				Oldest = ID
+			}
+		}
+
+		if Oldest == 0 {
+			return nil
+		}
+
+		Params.Set("max_id", strconv.FormatInt(Oldest-1, 10))
+	}
+}
+
+// BulkOperation applies an action to every tweet of a source that passes Filter.
+//
+// Every tweet handled is appended to the file at ProgressPath, a later run with the same file skips
+// them, so an interrupted cleanup picks up where it stopped. Rate limited calls wait for the
+// window to reset and are tried again.
+type BulkOperation struct {
+	Account *Account
+	Filter  TweetFilter
+
+	// DryRun reports what would be done without calling the API or writing progress
+	DryRun bool
+
+	// ProgressPath is the resumable log, one JSON line per handled tweet. Empty disables it.
+	ProgressPath string
+
+	// Pace is the minimum time between two calls
+	Pace time.Duration
+
+	// OnResult is called for every matching tweet, use it for dry-run output
+	OnResult func(BulkResult)
+}
+
+// BulkResult is what happened to one tweet
+type BulkResult struct {
+	TweetID string
+	Text    string
+	// Action is e.g. "delete", DryRun is set when it wasn't actually done
+	Action string
+	DryRun bool
+	// Gone means Twitter no longer knew the tweet, it counts as done
+	Gone bool
+	Err  error
+}
+
+// BulkReport counts what a bulk operation did
+type BulkReport struct {
+	Scanned int
+	Matched int
+	// Resumed tweets were already handled in an earlier run
+	Resumed int
+	Done    int
+	Failed  []BulkResult
+}
+
+// progressEntry is one line of the progress log
+type progressEntry struct {
+	Action  string    `json:"action"`
+	TweetID string    `json:"id"`
+	At      time.Time `json:"at"`
+}
+
+// Delete deletes every matching tweet from Source, which is usually the account's own
+// EachTimelineTweet or an archive's Tweets. Tweets that are already gone count as done.
+func (B *BulkOperation) Delete(ctx context.Context, Source TweetSource) (*BulkReport, error) {
+	return B.run(ctx, Source, "delete", func(T Tweet) error {
+		_, err := B.Account.DeleteTweet(T.IDStr)
+		return err
+	})
+}
+
+// Unfavorite removes the like from every matching tweet, Source is usually the account's
+// EachFavorite or an archive's Likes
+func (B *BulkOperation) Unfavorite(ctx context.Context, Source TweetSource) (*BulkReport, error) {
+	return B.run(ctx, Source, "unfavorite", func(T Tweet) error {
+		_, err := B.Account.UnFavorite(T.IDStr)
+		return err
+	})
+}
+
+// Unretweet undoes every matching retweet from Source, which is usually the account's
+// EachTimelineTweet. Tweets that aren't retweets are passed over without counting as matched.
+func (B *BulkOperation) Unretweet(ctx context.Context, Source TweetSource) (*BulkReport, error) {
+
+	Retweets := func(fn func(Tweet) error) error {
+		return Source(func(T Tweet) error {
+
+			if T.RetweetedStatus == nil && !strings.HasPrefix(T.Body(), "RT @") {
+				return nil
+			}
+
+			return fn(T)
+		})
+	}
+
+	return B.run(ctx, Retweets, "unretweet", func(T Tweet) error {
+
+		//archives only have the retweet's own ID, which unretweet accepts as well
+		ID := T.IDStr
+
+		if T.RetweetedStatus != nil {
+			ID = T.RetweetedStatus.IDStr
+		}
+
+		_, err := B.Account.Unretweet(ID)
+		return err
+	})
+}
+
+func (B *BulkOperation) run(ctx context.Context, Source TweetSource, Action string, Do func(Tweet) error) (*BulkReport, error) {
+
+	Done, err := B.loadProgress(Action)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var log *os.File
+
+	if B.ProgressPath != "" && !B.DryRun {
+
+		if err := os.MkdirAll(filepath.Dir(B.ProgressPath), 0700); err != nil {
+			return nil, err
+		}
+
+		if log, err = os.OpenFile(B.ProgressPath, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600); err != nil {
+			return nil, err
+		}
+
+		defer log.Close()
+
+		//start on a fresh line if the last run died halfway through writing one
+		if info, err := log.Stat(); err == nil && info.Size() > 0 {
+
+			last := make([]byte, 1)
+
+			if _, err := log.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+				log.Write([]byte("\n"))
+			}
+		}
+	}
+
+	Report := &BulkReport{}
+	var Last time.Time
+
+	err = Source(func(T Tweet) error {
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		Report.Scanned++
+
+		if !B.Filter.Matches(&T) {
+			return nil
+		}
+
+		Report.Matched++
+
+		if Done[T.IDStr] {
+			Report.Resumed++
+			return nil
+		}
+
+		Result := BulkResult{TweetID: T.IDStr, Text: T.Body(), Action: Action, DryRun: B.DryRun}
+
+		if !B.DryRun {
+
+			if wait := B.Pace - time.Since(Last); wait > 0 {
+				if err := sleepContext(ctx, wait); err != nil {
+					return err
+				}
+			}
+
+			if Result.Err = B.do(ctx, T, Do); ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			Last = time.Now()
+
+			//already deleted, unliked or unretweeted
+			if IsErrorCode(Result.Err, 144, 34) {
+				Result.Err, Result.Gone = nil, true
+			}
+		}
+
+		if Result.Err != nil {
+			Report.Failed = append(Report.Failed, Result)
+		} else {
+			Report.Done++
+		}
+
+		if Result.Err == nil && log != nil {
+
+			line, _ := json.Marshal(progressEntry{Action: Action, TweetID: T.IDStr, At: time.Now()})
+
+			if _, err := log.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+
+		if B.OnResult != nil {
+			B.OnResult(Result)
+		}
+
+		return nil
+	})
+
+	return Report, err
+}
+
+// do calls Do, waiting out rate limits until it goes through or fails for another reason
+func (B *BulkOperation) do(ctx context.Context, T Tweet, Do func(Tweet) error) error {
+
+	for {
+
+		err := Do(T)
+
+		Wait, Limited := rateLimitWait(err)
+
+		if !Limited {
+			return err
+		}
+
+		if err := sleepContext(ctx, Wait); err != nil {
+			return err
+		}
+	}
+}
+
+// rateLimitWait reports whether err is a rate limit and how long until the window resets
+func rateLimitWait(err error) (time.Duration, bool) {
+
+	var Reset time.Time
+	var Held *RateLimitError
+
+	switch E := err.(type) {
+	case *CanceledError:
+		if !errors.As(E, &Held) {
+			return 0, false
+		}
+		Reset = Held.Reset
+	case *APIError:
+		if E.StatusCode != 429 && E.Code != 88 {
+			return 0, false
+		}
+		Reset = E.Reset()
+	default:
+		return 0, false
+	}
+
+	//windows are 15 minutes long, wait a whole one when the response didn't say
+	if Reset.IsZero() {
+		return 15 * time.Minute, true
+	}
+
+	if Wait := time.Until(Reset) + time.Second; Wait > 0 {
+		return Wait, true
+	}
+
+	return time.Second, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// loadProgress returns the IDs the progress log already has for Action
+func (B *BulkOperation) loadProgress(Action string) (map[string]bool, error) {
+
+	Done := make(map[string]bool)
+
+	if B.ProgressPath == "" {
+		return Done, nil
+	}
+
+	f, err := os.Open(B.ProgressPath)
+
+	if os.IsNotExist(err) {
+		return Done, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+
+	for s.Scan() {
+
+		var e progressEntry
+
+		//a crash can leave a line cut short, that tweet is simply handled again
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			continue
+		}
+
+		if e.Action == Action {
+			Done[e.TweetID] = true
+		}
+	}
+
+	return Done, s.Err()
+}
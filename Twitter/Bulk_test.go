@@ -0,0 +1,289 @@
+package TwitterAPI
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestBulkDeleteResumes(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	for _, Status := range []string{"keep this", "old news 1", "old news 2", "old news 3", "old news but #pinned"} {
+		if _, err := A.Tweet(Status, "", "", false, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//the timeline shrinks as tweets are deleted, replay the same list on every run like an archive would
+	var Tweets []Tweet
+
+	if err := A.EachTimelineTweet(func(T Tweet) error { Tweets = append(Tweets, T); return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	Source := func(fn func(Tweet) error) error {
+
+		for _, T := range Tweets {
+			if err := fn(T); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	ID := map[string]string{}
+
+	for _, T := range Tweets {
+		ID[T.Body()] = T.IDStr
+	}
+
+	B := &BulkOperation{
+		Account:      A,
+		Filter:       TweetFilter{Keywords: []string{"OLD NEWS"}, ExcludeKeywords: []string{"#pinned"}},
+		ProgressPath: filepath.Join(t.TempDir(), "progress.jsonl"),
+	}
+
+	S.InjectError("statuses/destroy/"+ID["old news 2"]+".json", 403, 326, "Account locked", 1)
+
+	//deleted by hand before the cleanup, it counts as done
+	if _, err := A.DeleteTweet(ID["old news 3"]); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := []struct {
+		name   string
+		want   BulkReport
+		failed int
+		left   int
+	}{
+		{"first run", BulkReport{Scanned: 5, Matched: 3, Done: 2}, 1, 3},
+		{"second run", BulkReport{Scanned: 5, Matched: 3, Resumed: 2, Done: 1}, 0, 2},
+	}
+
+	for _, r := range runs {
+
+		Report, err := B.Delete(context.Background(), Source)
+
+		if err != nil {
+			t.Fatalf("%s: %v", r.name, err)
+		}
+
+		if Report.Scanned != r.want.Scanned || Report.Matched != r.want.Matched || Report.Resumed != r.want.Resumed || Report.Done != r.want.Done || len(Report.Failed) != r.failed {
+			t.Errorf("%s: got %+v, want %+v with %d failed", r.name, *Report, r.want, r.failed)
+		}
+
+		if n := len(S.Tweets()); n != r.left {
+			t.Errorf("%s: %d tweets left, want %d", r.name, n, r.left)
+		}
+	}
+
+	data, _ := ioutil.ReadFile(B.ProgressPath)
+
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("progress has %d lines, want 3:\n%s", n, data)
+	}
+}
+
+func TestBulkDryRun(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	for i := 0; i < 3; i++ {
+		A.Tweet("tweet "+strconv.Itoa(i), "", "", false, false)
+	}
+
+	var Results []BulkResult
+
+	B := &BulkOperation{Account: A, DryRun: true, ProgressPath: filepath.Join(t.TempDir(), "progress.jsonl"), OnResult: func(R BulkResult) { Results = append(Results, R) }}
+
+	Report, err := B.Delete(context.Background(), A.EachTimelineTweet)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Report.Done != 3 || len(Results) != 3 || !Results[0].DryRun || len(S.Tweets()) != 3 {
+		t.Errorf("got %+v and %d tweets left, want nothing deleted", *Report, len(S.Tweets()))
+	}
+
+	if _, err := ioutil.ReadFile(B.ProgressPath); err == nil {
+		t.Error("a dry run wrote progress")
+	}
+}
+
+func TestBulkCanceled(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	for i := 0; i < 3; i++ {
+		A.Tweet("tweet "+strconv.Itoa(i), "", "", false, false)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	B := &BulkOperation{Account: A, OnResult: func(BulkResult) { cancel() }}
+
+	if _, err := B.Delete(ctx, A.EachTimelineTweet); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+
+	if n := len(S.Tweets()); n != 2 {
+		t.Errorf("%d tweets left, want 2", n)
+	}
+}
+
+func TestTweetFilter(t *testing.T) {
+
+	Day := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter TweetFilter
+		tweet  Tweet
+		want   bool
+	}{
+		{"zero filter", TweetFilter{}, Tweet{Text: "anything"}, true},
+		{"before since", TweetFilter{Since: Day}, Tweet{CreatedAt: Day.Add(-time.Hour).Format(time.RubyDate)}, false},
+		{"until is exclusive", TweetFilter{Until: Day}, Tweet{CreatedAt: Day.Format(time.RubyDate)}, false},
+		{"keyword", TweetFilter{Keywords: []string{"Golang"}}, Tweet{FullText: "I like golang"}, true},
+		{"excluded keyword", TweetFilter{ExcludeKeywords: []string{"keep"}}, Tweet{Text: "please KEEP"}, false},
+		{"archive retweet", TweetFilter{Retweets: MatchNone}, Tweet{Text: "RT @a: hi"}, false},
+		{"only replies", TweetFilter{Replies: MatchOnly}, Tweet{Text: "hi"}, false},
+		{"only media", TweetFilter{Media: MatchOnly}, Tweet{Text: "pic", ExtendedEntities: &Entities{Media: []MediaEntity{{}}}}, true},
+		{"popular kept", TweetFilter{KeepPopular: 10}, Tweet{Text: "hit", FavoriteCount: 8, RetweetCount: 2}, false},
+		{"below engagement", TweetFilter{MinEngagement: 5}, Tweet{Text: "quiet", FavoriteCount: 4}, false},
+		{"func", TweetFilter{Func: func(T *Tweet) bool { return T.Lang == "en" }}, Tweet{Text: "hola", Lang: "es"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(&tt.tweet); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+
+	Reset := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		err      error
+		min, max time.Duration
+		limited  bool
+	}{
+		{"429 with reset", &APIError{StatusCode: 429, Code: 88, Header: http.Header{"X-Rate-Limit-Reset": {strconv.FormatInt(Reset.Unix(), 10)}}}, 59 * time.Second, 62 * time.Second, true},
+		{"429 without reset", &APIError{StatusCode: 429}, 15 * time.Minute, 15 * time.Minute, true},
+		{"held by a pool", &CanceledError{Err: &RateLimitError{Reset: Reset}}, 59 * time.Second, 62 * time.Second, true},
+		{"canceled otherwise", &CanceledError{Err: errors.New("no")}, 0, 0, false},
+		{"other api error", &APIError{StatusCode: 404, Code: 144}, 0, 0, false},
+		{"nil", nil, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Wait, Limited := rateLimitWait(tt.err)
+
+			if Limited != tt.limited || Wait < tt.min || Wait > tt.max {
+				t.Errorf("got %v, %v, want between %v and %v, %v", Wait, Limited, tt.min, tt.max, tt.limited)
+			}
+		})
+	}
+}
+
+func TestBulkUndo(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	Author := login(t, S, "author")
+	Fan := login(t, S, "fan")
+
+	var IDs []string
+
+	for _, Status := range []string{"one", "two", "three"} {
+
+		resp, err := Author.Tweet(Status, "", "", false, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		IDs = append(IDs, idOf(t, resp))
+	}
+
+	for _, ID := range IDs {
+		Fan.FavouriteTweet(ID)
+		Fan.Retweet(ID)
+	}
+
+	//the fan's own tweet is on the same timeline as the retweets and has to be left alone
+	Fan.Tweet("my own", "", "", false, false)
+
+	tests := []struct {
+		name   string
+		run    func(B *BulkOperation) (*BulkReport, error)
+		done   int
+		tweets int
+		likes  int
+	}{
+		{"unfavorite", func(B *BulkOperation) (*BulkReport, error) {
+			return B.Unfavorite(context.Background(), Fan.EachFavorite)
+		}, 2, 7, 1},
+		{"unretweet", func(B *BulkOperation) (*BulkReport, error) {
+			return B.Unretweet(context.Background(), Fan.EachTimelineTweet)
+		}, 2, 5, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			B := &BulkOperation{Account: Fan, Filter: TweetFilter{ExcludeKeywords: []string{"three"}}}
+
+			Report, err := tt.run(B)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if Report.Done != tt.done || len(Report.Failed) != 0 {
+				t.Errorf("got %+v, want %d done", *Report, tt.done)
+			}
+
+			if n := len(S.Tweets()); n != tt.tweets {
+				t.Errorf("%d tweets left, want %d", n, tt.tweets)
+			}
+
+			var Likes []Tweet
+
+			Fan.EachFavorite(func(T Tweet) error { Likes = append(Likes, T); return nil })
+
+			if len(Likes) != tt.likes {
+				t.Errorf("%d likes left, want %d", len(Likes), tt.likes)
+			}
+		})
+	}
+}
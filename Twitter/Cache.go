@@ -0,0 +1,341 @@
+package TwitterAPI
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores raw API responses, implementations must be safe for concurrent use
+type Cache interface {
+	Get(Key string) ([]byte, bool)
+	Set(Key string, Value []byte, TTL time.Duration)
+	// DeletePrefix drops every entry whose key starts with Prefix
+	DeletePrefix(Prefix string)
+}
+
+// CachePolicy puts a Cache in front of idempotent GET endpoints.
+//
+// Only endpoints listed in TTL are cached, keyed on the endpoint plus its sorted params and the
+// account's access token. A successful POST to an endpoint in Invalidates drops the cached entries
+// of the endpoints it maps to, so a profile update isn't followed by a stale UsersShow.
+type CachePolicy struct {
+	Store Cache
+
+	// TTL is keyed by ENDPOINT value, endpoints containing :id match any ID
+	TTL map[string]time.Duration
+
+	// Invalidates maps a mutating endpoint to the cached endpoints it makes stale
+	Invalidates map[string][]string
+}
+
+// NewCachePolicy caches the common read endpoints in Store for a few minutes (oEmbed markup for an hour)
+func NewCachePolicy(Store Cache) *CachePolicy {
+
+	profile := []string{ENDPOINT.UsersShow, ENDPOINT.UsersLookup, ENDPOINT.VerifyCredentials}
+	tweets := []string{ENDPOINT.ShowTweet, ENDPOINT.LookUp, ENDPOINT.Oembed}
+	relationships := append([]string{ENDPOINT.FriendshipShow}, profile...)
+
+	return &CachePolicy{
+		Store: Store,
+		TTL: map[string]time.Duration{
+			ENDPOINT.UsersShow:          5 * time.Minute,
+			ENDPOINT.UsersLookup:        5 * time.Minute,
+			ENDPOINT.VerifyCredentials:  5 * time.Minute,
+			ENDPOINT.ShowTweet:          5 * time.Minute,
+			ENDPOINT.LookUp:             5 * time.Minute,
+			ENDPOINT.GetAccountSettings: 5 * time.Minute,
+			ENDPOINT.GetUserBanner:      5 * time.Minute,
+			ENDPOINT.FriendshipShow:     5 * time.Minute,
+			ENDPOINT.Oembed:             time.Hour,
+		},
+		Invalidates: map[string][]string{
+			ENDPOINT.UpdateProfile:         profile,
+			ENDPOINT.UpdatePicture:         profile,
+			ENDPOINT.UpdateBackgroundPic:   profile,
+			ENDPOINT.UpdateBanner:          append([]string{ENDPOINT.GetUserBanner}, profile...),
+			ENDPOINT.RemoveBanner:          append([]string{ENDPOINT.GetUserBanner}, profile...),
+			ENDPOINT.ChangeAccountSettings: {ENDPOINT.GetAccountSettings},
+			ENDPOINT.DeleteTweet:           tweets,
+			ENDPOINT.Retweet:               tweets,
+			ENDPOINT.Unretweet:             tweets,
+			ENDPOINT.Favourite:             tweets,
+			ENDPOINT.UnFavorite:            tweets,
+			ENDPOINT.FollowUser:            relationships,
+			ENDPOINT.UnFollowUser:          relationships,
+			ENDPOINT.BlockUser:             relationships,
+			ENDPOINT.UnBlockUser:           relationships,
+			ENDPOINT.MuteUser:              relationships,
+			ENDPOINT.UnmuteUser:            relationships,
+			ENDPOINT.FriendshipUpdate:      relationships,
+		},
+	}
+}
+
+func (C *CachePolicy) ttl(Endpoint string) time.Duration {
+
+	for e, ttl := range C.TTL {
+		if matchEndpoint(e, Endpoint) {
+			return ttl
+		}
+	}
+
+	return 0
+}
+
+// cacheKey scopes entries to the access token, different accounts see different relationship flags
+func (P *Account) cacheKey(Endpoint string, Params url.Values) string {
+	return P.cachePrefix(Endpoint) + "?" + Params.Encode()
+}
+
+func (P *Account) cachePrefix(Endpoint string) string {
+
+	var Token string
+
+	if _, Credentials := P.credentials(); Credentials != nil {
+		Token = Credentials.Token
+	}
+
+	sum := sha256.Sum256([]byte(Token))
+
+	//IDs are part of the URL for :id endpoints, strip them so the prefix covers every ID
+	if i := strings.Index(Endpoint, ":id"); i >= 0 {
+		Endpoint = Endpoint[:i]
+	}
+
+	return hex.EncodeToString(sum[:8]) + " " + Endpoint
+}
+
+// InvalidateCache drops this account's cached responses for the given endpoints
+func (P *Account) InvalidateCache(Endpoints ...string) {
+
+	if P.Cache == nil || P.Cache.Store == nil {
+		return
+	}
+
+	for _, e := range Endpoints {
+		P.Cache.Store.DeletePrefix(P.cachePrefix(e))
+	}
+}
+
+func (P *Account) cached(Endpoint string, Params url.Values, Method string) (string, bool) {
+
+	if P.Cache == nil || P.Cache.Store == nil || Method != "GET" || P.Cache.ttl(Endpoint) <= 0 {
+		return "", false
+	}
+
+	v, ok := P.Cache.Store.Get(P.cacheKey(Endpoint, Params))
+
+	return string(v), ok
+}
+
+// updateCache stores a fresh GET response, or invalidates what a successful POST made stale
+func (P *Account) updateCache(Endpoint string, Params url.Values, Method, Body string) {
+
+	if P.Cache == nil || P.Cache.Store == nil {
+		return
+	}
+
+	if Method == "GET" {
+		if ttl := P.Cache.ttl(Endpoint); ttl > 0 {
+			P.Cache.Store.Set(P.cacheKey(Endpoint, Params), []byte(Body), ttl)
+		}
+		return
+	}
+
+	for e, stale := range P.Cache.Invalidates {
+		if matchEndpoint(e, Endpoint) {
+			P.InvalidateCache(stale...)
+		}
+	}
+}
+
+// LRUCache is an in-memory Cache holding at most Size entries
+type LRUCache struct {
+	Size int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	Key     string
+	Value   []byte
+	Expires time.Time
+}
+
+// NewLRUCache returns an empty in-memory cache evicting the least recently used entry past Size
+func NewLRUCache(Size int) *LRUCache {
+	return &LRUCache{Size: Size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (C *LRUCache) Get(Key string) ([]byte, bool) {
+
+	C.mu.Lock()
+	defer C.mu.Unlock()
+
+	e, ok := C.entries[Key]
+
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*lruEntry)
+
+	if time.Now().After(entry.Expires) {
+		C.order.Remove(e)
+		delete(C.entries, Key)
+		return nil, false
+	}
+
+	C.order.MoveToFront(e)
+
+	return entry.Value, true
+}
+
+func (C *LRUCache) Set(Key string, Value []byte, TTL time.Duration) {
+
+	C.mu.Lock()
+	defer C.mu.Unlock()
+
+	if e, ok := C.entries[Key]; ok {
+		C.order.Remove(e)
+	}
+
+	C.entries[Key] = C.order.PushFront(&lruEntry{Key: Key, Value: Value, Expires: time.Now().Add(TTL)})
+
+	for C.Size > 0 && C.order.Len() > C.Size {
+		oldest := C.order.Back()
+		C.order.Remove(oldest)
+		delete(C.entries, oldest.Value.(*lruEntry).Key)
+	}
+}
+
+func (C *LRUCache) DeletePrefix(Prefix string) {
+
+	C.mu.Lock()
+	defer C.mu.Unlock()
+
+	for k, e := range C.entries {
+		if strings.HasPrefix(k, Prefix) {
+			C.order.Remove(e)
+			delete(C.entries, k)
+		}
+	}
+}
+
+// DiskCache is a Cache keeping one file per entry in Dir, so it survives restarts and can be shared between processes
+type DiskCache struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// NewDiskCache creates Dir if needed
+func NewDiskCache(Dir string) (*DiskCache, error) {
+
+	if err := os.MkdirAll(Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{Dir: Dir}, nil
+}
+
+func (C *DiskCache) path(Key string) string {
+	sum := sha256.Sum256([]byte(Key))
+	return filepath.Join(C.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (C *DiskCache) read(Path string) (*lruEntry, error) {
+
+	data, err := ioutil.ReadFile(Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entry lruEntry
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (C *DiskCache) Get(Key string) ([]byte, bool) {
+
+	C.mu.Lock()
+	defer C.mu.Unlock()
+
+	entry, err := C.read(C.path(Key))
+
+	if err != nil || entry.Key != Key {
+		return nil, false
+	}
+
+	if time.Now().After(entry.Expires) {
+		os.Remove(C.path(Key))
+		return nil, false
+	}
+
+	return entry.Value, true
+}
+
+func (C *DiskCache) Set(Key string, Value []byte, TTL time.Duration) {
+
+	C.mu.Lock()
+	defer C.mu.Unlock()
+
+	data, err := json.Marshal(lruEntry{Key: Key, Value: Value, Expires: time.Now().Add(TTL)})
+
+	if err != nil {
+		return
+	}
+
+	//written to a temporary file first so readers never see half an entry
+	tmp, err := ioutil.TempFile(C.Dir, ".tmp-")
+
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.Write(data)
+	tmp.Close()
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), C.path(Key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (C *DiskCache) DeletePrefix(Prefix string) {
+
+	C.mu.Lock()
+	defer C.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(C.Dir, "*.json"))
+
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		if entry, err := C.read(f); err == nil && strings.HasPrefix(entry.Key, Prefix) {
+			os.Remove(f)
+		}
+	}
+}
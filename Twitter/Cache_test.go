@@ -0,0 +1,147 @@
+package TwitterAPI
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestCacheScoping(t *testing.T) {
+
+	stores := []struct {
+		name  string
+		store func(t *testing.T) Cache
+	}{
+		{"lru", func(t *testing.T) Cache { return NewLRUCache(10) }},
+		{"disk", func(t *testing.T) Cache {
+
+			C, err := NewDiskCache(t.TempDir())
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return C
+		}},
+	}
+
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+
+			S := TwitterTest.NewServer("ck", "cs")
+			defer S.Close()
+
+			A := login(t, S, "alice")
+			B := login(t, S, "bob")
+
+			Policy := NewCachePolicy(st.store(t))
+			A.Cache, B.Cache = Policy, Policy
+
+			resp, err := A.Tweet("cached", "", "", false, false)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ID := idOf(t, resp)
+
+			steps := []struct {
+				name  string
+				call  func() (string, error)
+				shows int
+			}{
+				{"alice shows", func() (string, error) { return A.ShowTweet(ID) }, 1},
+				{"alice shows again", func() (string, error) { return A.ShowTweet(ID) }, 1},
+				{"bob has his own entry", func() (string, error) { return B.ShowTweet(ID) }, 2},
+				{"alice favorites", func() (string, error) { return A.FavouriteTweet(ID) }, 2},
+				{"alice's entry is gone", func() (string, error) { return A.ShowTweet(ID) }, 3},
+				{"bob's entry is kept", func() (string, error) { return B.ShowTweet(ID) }, 3},
+			}
+
+			for _, s := range steps {
+
+				if _, err := s.call(); err != nil {
+					t.Fatalf("%s: %v", s.name, err)
+				}
+
+				Shows := 0
+
+				for _, R := range S.Requests() {
+					if R.Path == "statuses/show.json" {
+						Shows++
+					}
+				}
+
+				if Shows != s.shows {
+					t.Errorf("%s: server saw %d shows, want %d", s.name, Shows, s.shows)
+				}
+			}
+		})
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		fill    func(C *LRUCache)
+		present []string
+		absent  []string
+	}{
+		{"evicts the least recently used", func(C *LRUCache) {
+			C.Set("a", []byte("1"), time.Minute)
+			C.Set("b", []byte("2"), time.Minute)
+			C.Get("a")
+			C.Set("c", []byte("3"), time.Minute)
+		}, []string{"a", "c"}, []string{"b"}},
+		{"expires", func(C *LRUCache) {
+			C.Set("a", []byte("1"), -time.Second)
+		}, nil, []string{"a"}},
+		{"deletes by prefix", func(C *LRUCache) {
+			C.Set("p/1", []byte("1"), time.Minute)
+			C.Set("q/1", []byte("2"), time.Minute)
+			C.DeletePrefix("p/")
+		}, []string{"q/1"}, []string{"p/1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			C := NewLRUCache(2)
+			tt.fill(C)
+
+			for _, k := range tt.present {
+				if _, ok := C.Get(k); !ok {
+					t.Errorf("%q is missing", k)
+				}
+			}
+
+			for _, k := range tt.absent {
+				if _, ok := C.Get(k); ok {
+					t.Errorf("%q is still cached", k)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+
+	Policy := NewCachePolicy(NewLRUCache(1))
+
+	tests := []struct {
+		endpoint string
+		want     time.Duration
+	}{
+		{ENDPOINT.ShowTweet, 5 * time.Minute},
+		{ENDPOINT.Oembed, time.Hour},
+		{ENDPOINT.HomeTimeline, 0},
+	}
+
+	for _, tt := range tests {
+		if got := Policy.ttl(tt.endpoint); got != tt.want {
+			t.Errorf("ttl(%q) = %v, want %v", tt.endpoint, got, tt.want)
+		}
+	}
+}
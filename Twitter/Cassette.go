@@ -0,0 +1,370 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RecorderMode picks whether a Recorder talks to the network or serves a cassette
+type RecorderMode int
+
+const (
+	// Record sends requests to the real API and saves every interaction to the cassette
+	Record RecorderMode = iota
+	// Replay serves responses from the cassette and never touches the network
+	Replay
+)
+
+// Redacted replaces credentials in recorded interactions
+const Redacted = "REDACTED"
+
+// Interaction is one recorded request/response pair
+type Interaction struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestHeader  http.Header `json:"request_header"`
+	RequestBody    string      `json:"request_body"`
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"response_header"`
+	ResponseBody   string      `json:"response_body"`
+}
+
+// Cassette is the file format written by a Recorder
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records traffic to, or replays traffic from, a cassette file
+//
+//	rec, err := TwitterAPI.NewRecorder("testdata/tweet.json", TwitterAPI.Record)
+//	TwitterAPI.HTTPClient = rec.Client()
+type Recorder struct {
+	Path string
+	Mode RecorderMode
+
+	// Transport makes the real requests while recording, http.DefaultTransport when nil
+	Transport http.RoundTripper
+
+	// RedactHeaders are blanked out on both requests and responses, Authorization is always redacted
+	RedactHeaders []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder loads the cassette at Path for replaying, or starts an empty one for recording
+func NewRecorder(Path string, Mode RecorderMode) (*Recorder, error) {
+
+	R := &Recorder{Path: Path, Mode: Mode, RedactHeaders: []string{"Set-Cookie", "Cookie"}}
+
+	if Mode == Record {
+		return R, nil
+	}
+
+	data, err := ioutil.ReadFile(Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &R.cassette); err != nil {
+		return nil, fmt.Errorf("Cassette %s is corrupt: %v", Path, err)
+	}
+
+	R.used = make([]bool, len(R.cassette.Interactions))
+
+	return R, nil
+}
+
+// Client returns an http.Client using the recorder, assign it to HTTPClient
+func (R *Recorder) Client() *http.Client {
+	return &http.Client{Transport: R}
+}
+
+func (R *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	var body []byte
+
+	if req.Body != nil {
+
+		var err error
+
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if R.Mode == Replay {
+		return R.replay(req, body)
+	}
+
+	return R.record(req, body)
+}
+
+func (R *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+
+	transport := R.Transport
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	I := Interaction{
+		Method:         req.Method,
+		URL:            redactURL(req.URL),
+		RequestHeader:  R.redactHeader(req.Header),
+		RequestBody:    redactBody(req.Header.Get("Content-Type"), body),
+		Status:         resp.StatusCode,
+		ResponseHeader: R.redactHeader(resp.Header),
+		ResponseBody:   redactBody(resp.Header.Get("Content-Type"), respBody),
+	}
+
+	R.mu.Lock()
+	defer R.mu.Unlock()
+
+	R.cassette.Interactions = append(R.cassette.Interactions, I)
+
+	//saved on every interaction so a crashing test still leaves a usable cassette behind
+	if err := R.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (R *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+
+	R.mu.Lock()
+	defer R.mu.Unlock()
+
+	key := matchKey(req.Method, redactURL(req.URL), redactBody(req.Header.Get("Content-Type"), body))
+
+	for i, I := range R.cassette.Interactions {
+
+		if R.used[i] || matchKey(I.Method, I.URL, I.RequestBody) != key {
+			continue
+		}
+
+		R.used[i] = true
+
+		header := http.Header{}
+
+		for k, v := range I.ResponseHeader {
+			header[k] = append([]string(nil), v...)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", I.Status, http.StatusText(I.Status)),
+			StatusCode:    I.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(I.ResponseBody)),
+			ContentLength: int64(len(I.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("No recorded interaction left for %s %s in %s", req.Method, req.URL.Path, R.Path)
+}
+
+// Interactions returns what has been recorded (or loaded for replay) so far
+func (R *Recorder) Interactions() []Interaction {
+
+	R.mu.Lock()
+	defer R.mu.Unlock()
+
+	return append([]Interaction(nil), R.cassette.Interactions...)
+}
+
+// Unused returns the replayable interactions that no request has matched yet
+func (R *Recorder) Unused() []Interaction {
+
+	R.mu.Lock()
+	defer R.mu.Unlock()
+
+	var U []Interaction
+
+	for i, used := range R.used {
+		if !used {
+			U = append(U, R.cassette.Interactions[i])
+		}
+	}
+
+	return U
+}
+
+func (R *Recorder) save() error {
+
+	data, err := json.MarshalIndent(R.cassette, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(R.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(R.Path, data, 0644)
+}
+
+func (R *Recorder) redactHeader(H http.Header) http.Header {
+
+	C := http.Header{}
+
+	for k, v := range H {
+		C[k] = append([]string(nil), v...)
+	}
+
+	for _, k := range append([]string{"Authorization"}, R.RedactHeaders...) {
+		if C.Get(k) != "" {
+			C.Set(k, Redacted)
+		}
+	}
+
+	return C
+}
+
+func isSecret(Key string) bool {
+	return strings.HasPrefix(Key, "oauth_")
+}
+
+func redactValues(V url.Values) url.Values {
+
+	for k := range V {
+		if isSecret(k) {
+			V.Set(k, Redacted)
+		}
+	}
+
+	return V
+}
+
+func redactURL(u *url.URL) string {
+
+	C := *u
+	C.RawQuery = redactValues(u.Query()).Encode()
+
+	return C.String()
+}
+
+// redactBody strips oauth_* values out of form encoded bodies, like the access token response
+func redactBody(ContentType string, Body []byte) string {
+
+	//uploads are far too big to keep around, only the text fields are kept and matched on
+	if strings.HasPrefix(ContentType, "multipart/") {
+		return multipartFields(ContentType, Body)
+	}
+
+	if !strings.HasPrefix(ContentType, "application/x-www-form-urlencoded") && !strings.HasPrefix(ContentType, "text/html") && !strings.HasPrefix(ContentType, "text/plain") {
+		return string(Body)
+	}
+
+	V, err := url.ParseQuery(string(Body))
+
+	if err != nil || len(V) == 0 {
+		return string(Body)
+	}
+
+	for k := range V {
+		if isSecret(k) {
+			return redactValues(V).Encode()
+		}
+	}
+
+	return string(Body)
+}
+
+// multipartFields returns the non file fields of a multipart body url encoded, "" when there are none
+func multipartFields(ContentType string, Body []byte) string {
+
+	_, Params, err := mime.ParseMediaType(ContentType)
+
+	if err != nil {
+		return ""
+	}
+
+	V := url.Values{}
+	R := multipart.NewReader(bytes.NewReader(Body), Params["boundary"])
+
+	for {
+
+		Part, err := R.NextPart()
+
+		if err != nil {
+			break
+		}
+
+		if Part.FileName() == "" {
+			if Value, err := ioutil.ReadAll(Part); err == nil {
+				V.Add(Part.FormName(), string(Value))
+			}
+		}
+	}
+
+	return redactValues(V).Encode()
+}
+
+// matchKey ignores parameter order so a replayed request matches however the params were built
+func matchKey(Method, URL, Body string) string {
+
+	u, err := url.Parse(URL)
+
+	if err != nil {
+		return Method + " " + URL + " " + Body
+	}
+
+	V := u.Query()
+
+	if B, err := url.ParseQuery(Body); err == nil && !strings.HasPrefix(strings.TrimSpace(Body), "{") {
+		for k, v := range B {
+			V[k] = append(V[k], v...)
+		}
+	}
+
+	for k := range V {
+		if isSecret(k) {
+			delete(V, k)
+		} else {
+			sort.Strings(V[k])
+		}
+	}
+
+	u.RawQuery = ""
+
+	return Method + " " + u.String() + "?" + V.Encode()
+}
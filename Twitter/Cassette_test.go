@@ -0,0 +1,142 @@
+package TwitterAPI
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestRecordReplay(t *testing.T) {
+
+	Path := filepath.Join(t.TempDir(), "cassette.json")
+
+	S := TwitterTest.NewServer("ck", "cs")
+	T := login(t, S, "gopher")
+	T.RefreshConfiguration()
+
+	rec, err := NewRecorder(Path, Record)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec.Transport = S.Client().Transport
+	HTTPClient = rec.Client()
+
+	Tweeted, err := T.Tweet("recorded", "", "", false, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Shown, err := T.ShowTweet(idOf(t, Tweeted))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	S.Close()
+
+	data, err := ioutil.ReadFile(Path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{T.AccessToken, T.AccessSecret, "oauth_signature="} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	for _, I := range rec.Interactions() {
+		if got := I.RequestHeader.Get("Authorization"); got != Redacted {
+			t.Errorf("%s %s: Authorization %q, want it redacted", I.Method, I.URL, got)
+		}
+	}
+
+	//the server is gone, everything has to come from the cassette
+	replay, err := NewRecorder(Path, Replay)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	HTTPClient = replay.Client()
+	T.RefreshConfiguration()
+
+	calls := []struct {
+		name string
+		call func() (string, error)
+		want string
+	}{
+		{"tweet", func() (string, error) { return T.Tweet("recorded", "", "", false, false) }, Tweeted},
+		{"show", func() (string, error) { return T.ShowTweet(idOf(t, Tweeted)) }, Shown},
+	}
+
+	for _, c := range calls {
+		if got, err := c.call(); err != nil || got != c.want {
+			t.Errorf("%s: got %q, %v, want the recorded response", c.name, got, err)
+		}
+	}
+
+	if U := replay.Unused(); len(U) != 0 {
+		t.Errorf("%d interactions were never replayed", len(U))
+	}
+
+	if _, err := T.ShowTweet(idOf(t, Tweeted)); err == nil || !strings.Contains(err.Error(), "No recorded interaction") {
+		t.Errorf("replaying past the cassette: got %v", err)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+
+	multipart := "--b\r\nContent-Disposition: form-data; name=\"media_category\"\r\n\r\ntweet_image\r\n" +
+		"--b\r\nContent-Disposition: form-data; name=\"media\"; filename=\"a.png\"\r\n\r\nPNGDATA\r\n--b--\r\n"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"token response", "text/html; charset=utf-8", "oauth_token=abc&oauth_token_secret=def&user_id=1", "oauth_token=REDACTED&oauth_token_secret=REDACTED&user_id=1"},
+		{"form without secrets", "application/x-www-form-urlencoded", "status=hello+world", "status=hello+world"},
+		{"json", "application/json", `{"oauth_token":"abc"}`, `{"oauth_token":"abc"}`},
+		{"multipart keeps only fields", "multipart/form-data; boundary=b", multipart, "media_category=tweet_image"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactBody(tt.contentType, []byte(tt.body)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		a, b  [3]string
+		match bool
+	}{
+		{"param order", [3]string{"GET", "https://api.twitter.com/1.1/x.json?a=1&b=2", ""}, [3]string{"GET", "https://api.twitter.com/1.1/x.json?b=2&a=1", ""}, true},
+		{"oauth params ignored", [3]string{"GET", "https://api.twitter.com/1.1/x.json?a=1&oauth_nonce=1", ""}, [3]string{"GET", "https://api.twitter.com/1.1/x.json?a=1&oauth_nonce=2", ""}, true},
+		{"body and query", [3]string{"POST", "https://api.twitter.com/1.1/x.json", "status=hi"}, [3]string{"POST", "https://api.twitter.com/1.1/x.json?status=hi", ""}, true},
+		{"different value", [3]string{"GET", "https://api.twitter.com/1.1/x.json?a=1", ""}, [3]string{"GET", "https://api.twitter.com/1.1/x.json?a=2", ""}, false},
+		{"different method", [3]string{"GET", "https://api.twitter.com/1.1/x.json", ""}, [3]string{"POST", "https://api.twitter.com/1.1/x.json", ""}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchKey(tt.a[0], tt.a[1], tt.a[2]) == matchKey(tt.b[0], tt.b[1], tt.b[2]); got != tt.match {
+				t.Errorf("match = %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
@@ -0,0 +1,467 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TimelineOrder is how the tweets of a collection are ordered
+type TimelineOrder string
+
+const (
+	// CurationOrder keeps the order set by adding and moving tweets, newest additions first
+	CurationOrder     TimelineOrder = "curation_reverse_chron"
+	TweetChron        TimelineOrder = "tweet_chron"
+	TweetReverseChron TimelineOrder = "tweet_reverse_chron"
+)
+
+// Collection is a curated timeline, ID looks like "custom-539487832448843776"
+type Collection struct {
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description"`
+	URL            string        `json:"url"`
+	CollectionURL  string        `json:"collection_url"`
+	UserID         string        `json:"user_id"`
+	Visibility     string        `json:"visibility"`
+	TimelineOrder  TimelineOrder `json:"timeline_order"`
+	CollectionType string        `json:"collection_type"`
+}
+
+// CollectionEntry is a tweet in a collection, SortIndex is its position
+type CollectionEntry struct {
+	Tweet     Tweet
+	SortIndex string
+}
+
+// CollectionPage is one page of CollectionEntries, pass MinPosition as MaxPosition to get the next
+// one while WasTruncated is true
+type CollectionPage struct {
+	Collection   Collection
+	Entries      []CollectionEntry
+	MaxPosition  string
+	MinPosition  string
+	WasTruncated bool
+}
+
+// CollectionChange is a single operation of a CollectionCurate batch, Op is "add" or "remove"
+type CollectionChange struct {
+	Op      string `json:"op"`
+	TweetID string `json:"tweet_id"`
+}
+
+// CollectionChangeError is a change Twitter refused, Reason is like "not_found" or "duplicate"
+type CollectionChangeError struct {
+	Change CollectionChange `json:"change"`
+	Reason string           `json:"reason"`
+}
+
+func (E *CollectionChangeError) Error() string {
+	return fmt.Sprintf("Collection %s of tweet %s failed: %s", E.Change.Op, E.Change.TweetID, E.Reason)
+}
+
+// collectionResponse is the envelope every collections endpoint answers with
+type collectionResponse struct {
+	Objects struct {
+		Tweets    map[string]Tweet      `json:"tweets"`
+		Users     map[string]User       `json:"users"`
+		Timelines map[string]Collection `json:"timelines"`
+	} `json:"objects"`
+	Response struct {
+		TimelineID string `json:"timeline_id"`
+		Position   struct {
+			MaxPosition  string `json:"max_position"`
+			MinPosition  string `json:"min_position"`
+			WasTruncated bool   `json:"was_truncated"`
+		} `json:"position"`
+		Timeline []struct {
+			Tweet struct {
+				ID        string `json:"id"`
+				SortIndex string `json:"sort_index"`
+			} `json:"tweet"`
+		} `json:"timeline"`
+		Errors []CollectionChangeError `json:"errors"`
+	} `json:"response"`
+}
+
+// collection returns the collection the response is about
+func (C *collectionResponse) collection() (*Collection, error) {
+
+	ID := C.Response.TimelineID
+
+	T, ok := C.Objects.Timelines[ID]
+
+	if !ok {
+		return nil, errors.New("Collection missing from the response")
+	}
+
+	T.ID = ID
+
+	return &T, nil
+}
+
+// changeErrors returns the first refused change, if any
+func (C *collectionResponse) changeErrors() error {
+
+	if len(C.Response.Errors) > 0 {
+		return &C.Response.Errors[0]
+	}
+
+	return nil
+}
+
+var collectionPath = regexp.MustCompile(`^(?:custom-)?(\d+)$`)
+
+// collectionIDArg accepts "custom-123", "123" or a link like https://twitter.com/jack/timelines/123
+func collectionIDArg(ID string) (string, error) {
+
+	ID = strings.TrimSpace(ID)
+
+	if m := collectionPath.FindStringSubmatch(ID); m != nil {
+		return "custom-" + m[1], nil
+	}
+
+	_, Segments, err := twitterPath(ID)
+
+	if err == nil {
+		for i := 0; i+1 < len(Segments); i++ {
+			if Segments[i] == "timelines" && numericID.MatchString(Segments[i+1]) {
+				return "custom-" + Segments[i+1], nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("Invalid collection ID %q", ID)
+}
+
+func (P *Account) collectionRequest(Endpoint string, Params url.Values, Method string) (*collectionResponse, error) {
+
+	resp, err := P.DoRequest(Endpoint, Params, Method)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var C collectionResponse
+
+	return &C, Decode(resp, &C)
+}
+
+// CollectionCreate makes a new collection, Order is CurationOrder when empty
+func (P *Account) CollectionCreate(Name, Description, URL string, Order TimelineOrder) (*Collection, error) {
+
+	var Params = url.Values{}
+
+	if Name == "" {
+		return nil, errors.New("Name cannot be empty")
+	}
+
+	Params.Add("name", Name)
+
+	if Description != "" {
+		Params.Add("description", Description)
+	}
+
+	if URL != "" {
+		Params.Add("url", URL)
+	}
+
+	if Order != "" {
+		Params.Add("timeline_order", string(Order))
+	}
+
+	C, err := P.collectionRequest(ENDPOINT.CollectionCreate, Params, "POST")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return C.collection()
+}
+
+// CollectionUpdate changes the name, description and URL of a collection, all three are sent as given
+func (P *Account) CollectionUpdate(ID, Name, Description, URL string) (*Collection, error) {
+
+	ID, err := collectionIDArg(ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var Params = url.Values{}
+
+	Params.Add("id", ID)
+	Params.Add("name", Name)
+	Params.Add("description", Description)
+	Params.Add("url", URL)
+
+	C, err := P.collectionRequest(ENDPOINT.CollectionUpdate, Params, "POST")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return C.collection()
+}
+
+func (P *Account) CollectionDestroy(ID string) error {
+
+	ID, err := collectionIDArg(ID)
+
+	if err != nil {
+		return err
+	}
+
+	var Params = url.Values{}
+
+	Params.Add("id", ID)
+
+	_, err = P.DoRequest(ENDPOINT.CollectionDestroy, Params, "POST")
+
+	return err
+}
+
+func (P *Account) CollectionShow(ID string) (*Collection, error) {
+
+	ID, err := collectionIDArg(ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var Params = url.Values{}
+
+	Params.Add("id", ID)
+
+	C, err := P.collectionRequest(ENDPOINT.CollectionShow, Params, "GET")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return C.collection()
+}
+
+// CollectionEntries reads a page of up to Count (at most 200) tweets. MaxPosition and MinPosition
+// are optional, see CollectionPage.
+func (P *Account) CollectionEntries(ID string, Count int, MaxPosition, MinPosition string) (*CollectionPage, error) {
+
+	ID, err := collectionIDArg(ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var Params = url.Values{}
+
+	Params.Add("id", ID)
+
+	if Count != 0 {
+		if Count < 1 || Count > 200 {
+			return nil, fmt.Errorf("Count must be between 1 and 200, got %d", Count)
+		}
+		Params.Add("count", strconv.Itoa(Count))
+	}
+
+	if MaxPosition != "" {
+		Params.Add("max_position", MaxPosition)
+	}
+
+	if MinPosition != "" {
+		Params.Add("min_position", MinPosition)
+	}
+
+	C, err := P.collectionRequest(ENDPOINT.CollectionEntries, Params, "GET")
+
+	if err != nil {
+		return nil, err
+	}
+
+	Collection, err := C.collection()
+
+	if err != nil {
+		return nil, err
+	}
+
+	Page := &CollectionPage{
+		Collection:   *Collection,
+		MaxPosition:  C.Response.Position.MaxPosition,
+		MinPosition:  C.Response.Position.MinPosition,
+		WasTruncated: C.Response.Position.WasTruncated,
+	}
+
+	for _, e := range C.Response.Timeline {
+
+		T, ok := C.Objects.Tweets[e.Tweet.ID]
+
+		if !ok {
+			continue
+		}
+
+		//tweets only carry the user's ID, the users come separately
+		if T.User != nil {
+			if U, ok := C.Objects.Users[T.User.IDStr]; ok {
+				T.User = &U
+			}
+		}
+
+		Page.Entries = append(Page.Entries, CollectionEntry{Tweet: T, SortIndex: e.Tweet.SortIndex})
+	}
+
+	return Page, nil
+}
+
+// EachCollectionEntry walks every tweet of a collection in its order, a page of 200 at a time
+func (P *Account) EachCollectionEntry(ID string, fn func(CollectionEntry) error) error {
+
+	var Max string
+
+	for {
+
+		Page, err := P.CollectionEntries(ID, 200, Max, "")
+
+		if err != nil {
+			return err
+		}
+
+		for _, e := range Page.Entries {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+
+		if !Page.WasTruncated || len(Page.Entries) == 0 || Page.MinPosition == "" {
+			return nil
+		}
+
+		Max = Page.MinPosition
+	}
+}
+
+// CollectionTweets is a TweetSource over the tweets of a collection, e.g. for a BulkOperation
+func (P *Account) CollectionTweets(ID string) TweetSource {
+	return func(fn func(Tweet) error) error {
+		return P.EachCollectionEntry(ID, func(e CollectionEntry) error { return fn(e.Tweet) })
+	}
+}
+
+func (P *Account) collectionEntry(Endpoint, ID, TweetID, RelativeTo string, Above bool) error {
+
+	ID, err := collectionIDArg(ID)
+
+	if err != nil {
+		return err
+	}
+
+	TweetID, err = tweetIDArg(TweetID)
+
+	if err != nil {
+		return err
+	}
+
+	RelativeTo, err = tweetIDArg(RelativeTo)
+
+	if err != nil {
+		return err
+	}
+
+	if TweetID == "" {
+		return errors.New("TweetID cannot be empty")
+	}
+
+	var Params = url.Values{}
+
+	Params.Add("id", ID)
+	Params.Add("tweet_id", TweetID)
+
+	if RelativeTo != "" {
+		Params.Add("relative_to", RelativeTo)
+		Params.Add("above", strconv.FormatBool(Above))
+	}
+
+	C, err := P.collectionRequest(Endpoint, Params, "POST")
+
+	if err != nil {
+		return err
+	}
+
+	return C.changeErrors()
+}
+
+// CollectionAdd adds a tweet to a collection. For CurationOrder collections RelativeTo places it
+// above or below another tweet of the collection, otherwise it goes on top.
+func (P *Account) CollectionAdd(ID, TweetID, RelativeTo string, Above bool) error {
+	return P.collectionEntry(ENDPOINT.CollectionAdd, ID, TweetID, RelativeTo, Above)
+}
+
+func (P *Account) CollectionRemove(ID, TweetID string) error {
+	return P.collectionEntry(ENDPOINT.CollectionRemove, ID, TweetID, "", false)
+}
+
+// CollectionMove moves a tweet above or below RelativeTo in a CurationOrder collection
+func (P *Account) CollectionMove(ID, TweetID, RelativeTo string, Above bool) error {
+
+	if RelativeTo == "" {
+		return errors.New("RelativeTo cannot be empty")
+	}
+
+	return P.collectionEntry(ENDPOINT.CollectionMove, ID, TweetID, RelativeTo, Above)
+}
+
+// collectionCurateBatch is the most changes entries/curate takes at once
+const collectionCurateBatch = 100
+
+// CollectionCurate applies many adds and removes, sent a hundred at a time. Changes Twitter refused
+// are returned, the error is only about requests that failed.
+func (P *Account) CollectionCurate(ID string, Changes []CollectionChange) ([]CollectionChangeError, error) {
+
+	ID, err := collectionIDArg(ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	Changes = append([]CollectionChange(nil), Changes...)
+
+	for i := range Changes {
+
+		if Changes[i].Op != "add" && Changes[i].Op != "remove" {
+			return nil, fmt.Errorf("Invalid collection change %q, expected add or remove", Changes[i].Op)
+		}
+
+		if Changes[i].TweetID, err = tweetIDArg(Changes[i].TweetID); err != nil {
+			return nil, err
+		}
+	}
+
+	var Refused []CollectionChangeError
+
+	for start := 0; start < len(Changes); start += collectionCurateBatch {
+
+		end := start + collectionCurateBatch
+
+		if end > len(Changes) {
+			end = len(Changes)
+		}
+
+		resp, err := P.DoJSON(ENDPOINT.CollectionCurate, nil, map[string]interface{}{"id": ID, "changes": Changes[start:end]})
+
+		if err != nil {
+			return Refused, err
+		}
+
+		var C collectionResponse
+
+		if err := Decode(resp, &C); err != nil {
+			return Refused, err
+		}
+
+		Refused = append(Refused, C.Response.Errors...)
+	}
+
+	return Refused, nil
+}
@@ -0,0 +1,215 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestCollectionIDArg(t *testing.T) {
+
+	tests := []struct {
+		in    string
+		want  string
+		fails bool
+	}{
+		{"123", "custom-123", false},
+		{" custom-123 ", "custom-123", false},
+		{"https://twitter.com/jack/timelines/123", "custom-123", false},
+		{"https://x.com/jack/timelines/123?s=20", "custom-123", false},
+		{"custom-", "", true},
+		{"https://twitter.com/jack/status/123", "", true},
+		{"https://example.com/jack/timelines/123", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got, err := collectionIDArg(tt.in); got != tt.want || (err != nil) != tt.fails {
+				t.Errorf("got %q, %v, want %q, failure %v", got, err, tt.want, tt.fails)
+			}
+		})
+	}
+}
+
+func TestCollectionEntries(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	C, err := A.CollectionCreate("Best of", "", "", "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if C.TimelineOrder != CurationOrder || C.Name != "Best of" {
+		t.Errorf("got %+v, want a curated collection", C)
+	}
+
+	ID := map[string]string{}
+
+	for _, Status := range []string{"one", "two", "three", "four"} {
+
+		resp, err := A.Tweet(Status, "", "", false, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ID[Status] = idOf(t, resp)
+	}
+
+	Link := "https://twitter.com/gopher/status/" + ID["four"]
+
+	steps := []struct {
+		name   string
+		change func() error
+		want   []string
+		reason string
+	}{
+		{"add on top", func() error { return A.CollectionAdd(C.CollectionURL, ID["one"], "", false) }, []string{"one"}, ""},
+		{"add above", func() error { return A.CollectionAdd(C.ID, ID["two"], ID["one"], true) }, []string{"two", "one"}, ""},
+		{"add below", func() error { return A.CollectionAdd(C.ID, ID["three"], ID["two"], false) }, []string{"two", "three", "one"}, ""},
+		{"add a link", func() error { return A.CollectionAdd(C.ID, Link, "", false) }, []string{"four", "two", "three", "one"}, ""},
+		{"move", func() error { return A.CollectionMove(C.ID, ID["four"], ID["one"], false) }, []string{"two", "three", "one", "four"}, ""},
+		{"remove", func() error { return A.CollectionRemove(C.ID, ID["three"]) }, []string{"two", "one", "four"}, ""},
+		{"duplicate", func() error { return A.CollectionAdd(C.ID, ID["one"], "", false) }, []string{"two", "one", "four"}, "duplicate"},
+		{"remove missing", func() error { return A.CollectionRemove(C.ID, ID["three"]) }, []string{"two", "one", "four"}, "not_found"},
+	}
+
+	for _, s := range steps {
+		t.Run(s.name, func(t *testing.T) {
+
+			err := s.change()
+
+			var E *CollectionChangeError
+
+			if s.reason == "" && err != nil || s.reason != "" && (!errors.As(err, &E) || E.Reason != s.reason) {
+				t.Errorf("got %v, want reason %q", err, s.reason)
+			}
+
+			var Got []string
+
+			if err := A.EachCollectionEntry(C.ID, func(e CollectionEntry) error { Got = append(Got, e.Tweet.Text); return nil }); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(Got, s.want) {
+				t.Errorf("got %q, want %q", Got, s.want)
+			}
+		})
+	}
+
+	//paging follows MinPosition down the collection
+	var Pages [][]string
+	var Max string
+
+	for {
+
+		Page, err := A.CollectionEntries(C.ID, 2, Max, "")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var Texts []string
+
+		for _, e := range Page.Entries {
+
+			if e.Tweet.User == nil || e.Tweet.User.ScreenName != "gopher" {
+				t.Errorf("entry %s has user %+v, want it filled in", e.Tweet.Text, e.Tweet.User)
+			}
+
+			Texts = append(Texts, e.Tweet.Text)
+		}
+
+		Pages = append(Pages, Texts)
+
+		if !Page.WasTruncated {
+			break
+		}
+
+		Max = Page.MinPosition
+	}
+
+	if want := [][]string{{"two", "one"}, {"four"}}; !reflect.DeepEqual(Pages, want) {
+		t.Errorf("got pages %q, want %q", Pages, want)
+	}
+
+	if _, err := A.CollectionEntries(C.ID, 201, "", ""); err == nil {
+		t.Error("CollectionEntries accepted a count of 201")
+	}
+}
+
+func TestCollectionCurate(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	C, err := A.CollectionCreate("Newest", "", "", TweetReverseChron)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := A.Tweet("only one", "", "", false, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the same tweet added over and over, everything after the first add is refused
+	var Changes []CollectionChange
+
+	for i := 0; i < collectionCurateBatch+1; i++ {
+		Changes = append(Changes, CollectionChange{Op: "add", TweetID: "https://x.com/gopher/status/" + idOf(t, resp)})
+	}
+
+	Refused, err := A.CollectionCurate(C.ID, Changes)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(Refused) != collectionCurateBatch || Refused[0].Reason != "duplicate" || Refused[0].Change.TweetID != idOf(t, resp) {
+		t.Errorf("got %d refused, first %+v", len(Refused), Refused[0])
+	}
+
+	var Batches int
+
+	for _, R := range S.Requests() {
+		if R.Path == "collections/entries/curate.json" {
+			Batches++
+		}
+	}
+
+	if Batches != 2 {
+		t.Errorf("sent %d batches, want 2", Batches)
+	}
+
+	if _, err := A.CollectionCurate(C.ID, []CollectionChange{{Op: "move", TweetID: "1"}}); err == nil {
+		t.Error("CollectionCurate accepted a move")
+	}
+
+	Other := login(t, S, "other")
+
+	var E *APIError
+
+	if err := Other.CollectionDestroy(C.ID); !errors.As(err, &E) || E.StatusCode != 403 {
+		t.Errorf("got %v, want someone else's collection forbidden", err)
+	}
+
+	if err := A.CollectionDestroy(C.CollectionURL); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := A.CollectionShow(C.ID); !errors.As(err, &E) || E.StatusCode != 404 {
+		t.Errorf("got %v, want the destroyed collection gone", err)
+	}
+}
@@ -0,0 +1,348 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/antonholmquist/jason"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Credentials is everything needed to act as one user, as kept by a CredentialStore
+type Credentials struct {
+	ScreenName     string `json:"screen_name"`
+	UserID         string `json:"user_id,omitempty"`
+	ConsumerKey    string `json:"consumer_key"`
+	ConsumerSecret string `json:"consumer_secret"`
+	AccessToken    string `json:"access_token"`
+	AccessSecret   string `json:"access_secret"`
+}
+
+// CredentialStore saves access tokens by screen name. Screen names are matched case insensitively
+// the way Twitter does. Implementations must be safe for concurrent use.
+type CredentialStore interface {
+	Save(C Credentials) error
+	// Load returns ErrNoCredentials when nothing is stored for ScreenName
+	Load(ScreenName string) (Credentials, error)
+	Delete(ScreenName string) error
+	// List returns the stored screen names sorted
+	List() ([]string, error)
+}
+
+// ErrNoCredentials is returned by Load for an unknown screen name
+var ErrNoCredentials = errors.New("No credentials stored for that screen name")
+
+// ErrBadPassphrase is returned when a FileStore can not be decrypted
+var ErrBadPassphrase = errors.New("Wrong passphrase or corrupt credential store")
+
+// LoadAccount builds an Account acting as ScreenName from the credentials in Store
+func LoadAccount(Store CredentialStore, ScreenName string) (*Account, error) {
+
+	C, err := Store.Load(ScreenName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Account{
+		ConsumerKey:    C.ConsumerKey,
+		ConsumerSecret: C.ConsumerSecret,
+		ScreenName:     C.ScreenName,
+		AccessToken:    C.AccessToken,
+		AccessSecret:   C.AccessSecret,
+	}, nil
+}
+
+// SaveCredentials stores the account's tokens in Store, asking Twitter who the token belongs to
+// when ScreenName isn't known yet
+func (P *Account) SaveCredentials(Store CredentialStore) error {
+
+	Client, Token := P.credentials()
+
+	if Token == nil {
+		return errors.New("Account has no access token, call Auth or SetAccessToken first")
+	}
+
+	C := Credentials{
+		ScreenName:     P.ScreenName,
+		ConsumerKey:    Client.Credentials.Token,
+		ConsumerSecret: Client.Credentials.Secret,
+		AccessToken:    Token.Token,
+		AccessSecret:   Token.Secret,
+	}
+
+	if C.ScreenName == "" {
+
+		resp, err := P.VerifyCredential()
+
+		if err != nil {
+			return err
+		}
+
+		v, err := jason.NewObjectFromBytes([]byte(resp))
+
+		if err != nil {
+			return err
+		}
+
+		C.ScreenName, _ = v.GetString("screen_name")
+		C.UserID, _ = v.GetString("id_str")
+		P.ScreenName = C.ScreenName
+	}
+
+	return Store.Save(C)
+}
+
+// FileStore is a CredentialStore keeping every account in one file, encrypted with AES-256-GCM
+// under a key derived from a passphrase
+type FileStore struct {
+	Path string
+
+	mu         sync.Mutex
+	passphrase string
+
+	//deriving the key is slow on purpose, so it is kept for as long as the salt stays the same
+	salt []byte
+	aead cipher.AEAD
+}
+
+// fileStoreFormat is what ends up on disk, Data holds the sealed JSON of all credentials
+type fileStoreFormat struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+const fileStoreIterations = 600000
+
+// NewFileStore opens the store at Path, the file is created on the first Save. Opening an existing
+// store with the wrong passphrase fails with ErrBadPassphrase.
+func NewFileStore(Path, Passphrase string) (*FileStore, error) {
+
+	if Passphrase == "" {
+		return nil, errors.New("A passphrase is required to encrypt credentials")
+	}
+
+	S := &FileStore{Path: Path, passphrase: Passphrase}
+
+	if _, err := S.read(); err != nil {
+		return nil, err
+	}
+
+	return S, nil
+}
+
+func storeKey(ScreenName string) string {
+	return strings.ToLower(strings.TrimPrefix(ScreenName, "@"))
+}
+
+func (S *FileStore) Save(C Credentials) error {
+
+	if C.ScreenName == "" {
+		return errors.New("Credentials need a screen name")
+	}
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	all, err := S.read()
+
+	if err != nil {
+		return err
+	}
+
+	all[storeKey(C.ScreenName)] = C
+
+	return S.write(all)
+}
+
+func (S *FileStore) Load(ScreenName string) (Credentials, error) {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	all, err := S.read()
+
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	C, ok := all[storeKey(ScreenName)]
+
+	if !ok {
+		return Credentials{}, ErrNoCredentials
+	}
+
+	return C, nil
+}
+
+func (S *FileStore) Delete(ScreenName string) error {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	all, err := S.read()
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := all[storeKey(ScreenName)]; !ok {
+		return ErrNoCredentials
+	}
+
+	delete(all, storeKey(ScreenName))
+
+	return S.write(all)
+}
+
+func (S *FileStore) List() ([]string, error) {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	all, err := S.read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var Names []string
+
+	for _, C := range all {
+		Names = append(Names, C.ScreenName)
+	}
+
+	sort.Slice(Names, func(i, j int) bool { return storeKey(Names[i]) < storeKey(Names[j]) })
+
+	return Names, nil
+}
+
+func (S *FileStore) gcm(Salt []byte, Iterations int) (cipher.AEAD, error) {
+
+	if S.aead != nil && bytes.Equal(Salt, S.salt) {
+		return S.aead, nil
+	}
+
+	key := pbkdf2.Key([]byte(S.passphrase), Salt, Iterations, 32, sha256.New)
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, err
+	}
+
+	S.salt, S.aead = Salt, aead
+
+	return aead, nil
+}
+
+// read decrypts the whole store, a missing file is an empty store
+func (S *FileStore) read() (map[string]Credentials, error) {
+
+	all := map[string]Credentials{}
+
+	data, err := ioutil.ReadFile(S.Path)
+
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var F fileStoreFormat
+
+	if err := json.Unmarshal(data, &F); err != nil {
+		return nil, ErrBadPassphrase
+	}
+
+	if F.Version != 1 {
+		return nil, fmt.Errorf("Unsupported credential store version %d", F.Version)
+	}
+
+	aead, err := S.gcm(F.Salt, F.Iterations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(F.Nonce) != aead.NonceSize() {
+		return nil, ErrBadPassphrase
+	}
+
+	plain, err := aead.Open(nil, F.Nonce, F.Data, nil)
+
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+
+	if err := json.Unmarshal(plain, &all); err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// write seals the store with a fresh nonce and swaps it in atomically
+func (S *FileStore) write(all map[string]Credentials) error {
+
+	plain, err := json.Marshal(all)
+
+	if err != nil {
+		return err
+	}
+
+	F := fileStoreFormat{Version: 1, Iterations: fileStoreIterations, Salt: S.salt}
+
+	if F.Salt == nil {
+
+		F.Salt = make([]byte, 16)
+
+		if _, err := rand.Read(F.Salt); err != nil {
+			return err
+		}
+	}
+
+	aead, err := S.gcm(F.Salt, F.Iterations)
+
+	if err != nil {
+		return err
+	}
+
+	F.Nonce = make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(F.Nonce); err != nil {
+		return err
+	}
+
+	F.Data = aead.Seal(nil, F.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(F, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(S.Path, data, 0600)
+}
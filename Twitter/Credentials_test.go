@@ -0,0 +1,119 @@
+package TwitterAPI
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestSaveCredentials(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	Path := filepath.Join(t.TempDir(), "accounts.json")
+
+	Store, err := NewFileStore(Path, "hunter2")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	T := login(t, S, "Gopher")
+
+	//without a screen name the store asks Twitter who the token belongs to
+	T.ScreenName = ""
+
+	if err := T.SaveCredentials(Store); err != nil {
+		t.Fatal(err)
+	}
+
+	if T.ScreenName != "Gopher" {
+		t.Errorf("ScreenName %q, want it filled in from verify_credentials", T.ScreenName)
+	}
+
+	data, err := ioutil.ReadFile(Path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), T.AccessSecret) || strings.Contains(string(data), T.AccessToken) {
+		t.Error("the store holds the token in plain text")
+	}
+
+	Reopened, err := NewFileStore(Path, "hunter2")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, Name := range []string{"Gopher", "gopher", "@GOPHER"} {
+
+		Loaded, err := LoadAccount(Reopened, Name)
+
+		if err != nil {
+			t.Fatalf("LoadAccount(%q): %v", Name, err)
+		}
+
+		if _, err := Loaded.VerifyCredential(); err != nil {
+			t.Errorf("LoadAccount(%q) can't sign requests: %v", Name, err)
+		}
+	}
+}
+
+func TestFileStore(t *testing.T) {
+
+	Path := filepath.Join(t.TempDir(), "accounts.json")
+
+	Store, err := NewFileStore(Path, "hunter2")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, Name := range []string{"zed", "Amy", "bob"} {
+		if err := Store.Save(Credentials{ScreenName: Name, AccessToken: Name + "-token"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Store.Delete("BOB"); err != nil {
+		t.Fatal(err)
+	}
+
+	if Names, err := Store.List(); err != nil || !reflect.DeepEqual(Names, []string{"Amy", "zed"}) {
+		t.Errorf("List() = %v, %v, want [Amy zed]", Names, err)
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		screenName string
+		err        error
+	}{
+		{"stored", "hunter2", "amy", nil},
+		{"deleted", "hunter2", "bob", ErrNoCredentials},
+		{"unknown", "hunter2", "nobody", ErrNoCredentials},
+		{"wrong passphrase", "hunter3", "amy", ErrBadPassphrase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Reopened, err := NewFileStore(Path, tt.passphrase)
+
+			if err == nil {
+				_, err = Reopened.Load(tt.screenName)
+			}
+
+			if err != tt.err {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
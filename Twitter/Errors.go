@@ -0,0 +1,87 @@
+package TwitterAPI
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antonholmquist/jason"
+)
+
+// APIError is returned for any response with an HTTP status of 400 or above
+type APIError struct {
+	StatusCode int
+	// Code is Twitter's error code (e.g. 187 for a duplicate status), 0 when the body didn't include one
+	Code    int
+	Message string
+	Body    string
+	// Header holds the response headers, including the x-rate-limit-* ones
+	Header http.Header
+}
+
+func (E *APIError) Error() string {
+
+	if E.Code != 0 {
+		return fmt.Sprintf("Twitter error %d (HTTP %d): %s", E.Code, E.StatusCode, E.Message)
+	}
+
+	return fmt.Sprintf("Twitter HTTP %d: %s", E.StatusCode, E.Message)
+}
+
+// Reset is when the rate limit window of the failed request ends, zero when the response didn't say
+func (E *APIError) Reset() time.Time {
+
+	reset, err := strconv.ParseInt(E.Header.Get("x-rate-limit-reset"), 10, 64)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(reset, 0)
+}
+
+// IsErrorCode reports whether err is an APIError carrying one of the Twitter error codes
+func IsErrorCode(err error, Codes ...int) bool {
+
+	E, ok := err.(*APIError)
+
+	if !ok {
+		return false
+	}
+
+	for _, c := range Codes {
+		if E.Code == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newAPIError reads Twitter's {"errors":[{"code":..,"message":..}]} body, falling back to the older {"error":".."} form
+func newAPIError(StatusCode int, Body []byte) *APIError {
+
+	E := &APIError{StatusCode: StatusCode, Body: string(Body), Message: string(Body)}
+
+	j, err := jason.NewObjectFromBytes(Body)
+
+	if err != nil {
+		return E
+	}
+
+	if errs, err := j.GetObjectArray("errors"); err == nil && len(errs) > 0 {
+
+		code, _ := errs[0].GetInt64("code")
+		E.Code = int(code)
+		E.Message, _ = errs[0].GetString("message")
+
+		return E
+	}
+
+	if msg, err := j.GetString("error"); err == nil {
+		E.Message = msg
+	}
+
+	return E
+}
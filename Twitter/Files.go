@@ -0,0 +1,50 @@
+package TwitterAPI
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic replaces Path with Data through a temporary file, so a crash never leaves half a
+// file behind for the stores that keep their state on disk
+func writeFileAtomic(Path string, Data []byte, Perm os.FileMode) error {
+
+	dir := filepath.Dir(Path)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(Path)+"-")
+
+	if err != nil {
+		return err
+	}
+
+	if err := tmp.Chmod(Perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	_, err = tmp.Write(Data)
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	tmp.Close()
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), Path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,321 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Configuration holds the limits Twitter currently applies, as returned by help/configuration
+type Configuration struct {
+	CharactersReservedPerMedia int                  `json:"characters_reserved_per_media"`
+	DMTextCharacterLimit       int                  `json:"dm_text_character_limit"`
+	MaxMediaPerUpload          int                  `json:"max_media_per_upload"`
+	NonUsernamePaths           []string             `json:"non_username_paths"`
+	PhotoSizeLimit             int                  `json:"photo_size_limit"`
+	PhotoSizes                 map[string]PhotoSize `json:"photo_sizes"`
+	ShortURLLength             int                  `json:"short_url_length"`
+	ShortURLLengthHTTPS        int                  `json:"short_url_length_https"`
+}
+
+// PhotoSize is one of the sizes Twitter serves uploaded photos at, Resize is "fit" or "crop"
+type PhotoSize struct {
+	W      int    `json:"w"`
+	H      int    `json:"h"`
+	Resize string `json:"resize"`
+}
+
+// DefaultConfiguration is used for validation until Configuration has fetched help/configuration
+var DefaultConfiguration = Configuration{
+	CharactersReservedPerMedia: 24,
+	DMTextCharacterLimit:       10000,
+	MaxMediaPerUpload:          1,
+	PhotoSizeLimit:             3145728,
+	PhotoSizes: map[string]PhotoSize{
+		"thumb":  {W: 150, H: 150, Resize: "crop"},
+		"small":  {W: 680, H: 680, Resize: "fit"},
+		"medium": {W: 1200, H: 1200, Resize: "fit"},
+		"large":  {W: 2048, H: 2048, Resize: "fit"},
+	},
+	ShortURLLength:      23,
+	ShortURLLengthHTTPS: 23,
+}
+
+// Language is a language Twitter supports, Code is what the lang parameters take
+type Language struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	LocalName string `json:"local_name"`
+	Status    string `json:"status"`
+	Debug     bool   `json:"debug"`
+}
+
+// ConfigurationTTL is how long the configuration and languages are kept, Twitter asks for no more
+// than a request a day
+var ConfigurationTTL = 24 * time.Hour
+
+// helpCache keeps the configuration and languages per consumer key, they are the same for every user
+var helpCache = struct {
+	sync.Mutex
+	config    map[string]helpEntry
+	languages map[string]helpEntry
+}{config: make(map[string]helpEntry), languages: make(map[string]helpEntry)}
+
+type helpEntry struct {
+	value   interface{}
+	fetched time.Time
+}
+
+// cached returns the entry of Key in Entries when it's fresh, or fetches and stores it
+func cached(Entries map[string]helpEntry, Key string, fetch func() (interface{}, error)) (interface{}, error) {
+
+	helpCache.Lock()
+	E, ok := Entries[Key]
+	helpCache.Unlock()
+
+	if ok && time.Since(E.fetched) < ConfigurationTTL {
+		return E.value, nil
+	}
+
+	V, err := fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	helpCache.Lock()
+	Entries[Key] = helpEntry{value: V, fetched: time.Now()}
+	helpCache.Unlock()
+
+	return V, nil
+}
+
+// RefreshConfiguration drops the cached configuration and languages of the account's app
+func (P *Account) RefreshConfiguration() {
+
+	helpCache.Lock()
+	delete(helpCache.config, P.ConsumerKey)
+	delete(helpCache.languages, P.ConsumerKey)
+	helpCache.Unlock()
+}
+
+func (P *Account) HelpConfiguration() (string, error) {
+	return P.DoRequest(ENDPOINT.HelpConfiguration, nil, "GET")
+}
+
+func (P *Account) HelpLanguages() (string, error) {
+	return P.DoRequest(ENDPOINT.HelpLanguages, nil, "GET")
+}
+
+func (P *Account) HelpPrivacy() (string, error) {
+	return P.DoRequest(ENDPOINT.HelpPrivacy, nil, "GET")
+}
+
+func (P *Account) HelpTOS() (string, error) {
+	return P.DoRequest(ENDPOINT.HelpTOS, nil, "GET")
+}
+
+// Configuration returns the current limits, fetched at most once per ConfigurationTTL
+func (P *Account) Configuration() (*Configuration, error) {
+
+	V, err := cached(helpCache.config, P.ConsumerKey, func() (interface{}, error) {
+
+		resp, err := P.HelpConfiguration()
+
+		if err != nil {
+			return nil, err
+		}
+
+		var C Configuration
+
+		if err := Decode(resp, &C); err != nil {
+			return nil, err
+		}
+
+		return &C, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	C := *V.(*Configuration)
+
+	return &C, nil
+}
+
+// limits is the configuration validation uses: the one Configuration last fetched, even when it's
+// past ConfigurationTTL, or DefaultConfiguration. It never sends a request, checking a tweet or an
+// upload mustn't add a help/configuration call in front of it.
+func (P *Account) limits() *Configuration {
+
+	helpCache.Lock()
+	E, ok := helpCache.config[P.ConsumerKey]
+	helpCache.Unlock()
+
+	C := DefaultConfiguration
+
+	if ok {
+		C = *E.value.(*Configuration)
+	}
+
+	return &C
+}
+
+// Languages returns the languages Twitter supports, fetched at most once per ConfigurationTTL
+func (P *Account) Languages() ([]Language, error) {
+
+	V, err := cached(helpCache.languages, P.ConsumerKey, func() (interface{}, error) {
+
+		resp, err := P.HelpLanguages()
+
+		if err != nil {
+			return nil, err
+		}
+
+		var L []Language
+
+		return L, Decode(resp, &L)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]Language(nil), V.([]Language)...), nil
+}
+
+// SupportedLanguage reports whether Code is one of the Languages
+func (P *Account) SupportedLanguage(Code string) (bool, error) {
+
+	Languages, err := P.Languages()
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, L := range Languages {
+		if strings.EqualFold(L.Code, Code) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// PrivacyPolicy returns the text of Twitter's privacy policy
+func (P *Account) PrivacyPolicy() (string, error) {
+
+	resp, err := P.HelpPrivacy()
+
+	if err != nil {
+		return "", err
+	}
+
+	var Result struct {
+		Privacy string `json:"privacy"`
+	}
+
+	return Result.Privacy, Decode(resp, &Result)
+}
+
+// TermsOfService returns the text of Twitter's terms of service
+func (P *Account) TermsOfService() (string, error) {
+
+	resp, err := P.HelpTOS()
+
+	if err != nil {
+		return "", err
+	}
+
+	var Result struct {
+		TOS string `json:"tos"`
+	}
+
+	return Result.TOS, Decode(resp, &Result)
+}
+
+// ErrPhotoTooLarge is wrapped by the errors returned for images over the photo size limit
+var ErrPhotoTooLarge = errors.New("Image is too large")
+
+// CheckPhotoSize returns an error wrapping ErrPhotoTooLarge when Size bytes is over the photo size limit
+func (P *Account) CheckPhotoSize(Size int) error {
+
+	if Limit := P.limits().PhotoSizeLimit; Limit > 0 && Size > Limit {
+		return fmt.Errorf("%w, it is %d bytes and the limit is %d", ErrPhotoTooLarge, Size, Limit)
+	}
+
+	return nil
+}
+
+// MaxTweetLength is the longest status, in weighted characters
+const MaxTweetLength = 280
+
+// ErrTweetTooLong is wrapped by the errors returned for statuses over MaxTweetLength
+var ErrTweetTooLong = errors.New("Tweet is too long")
+
+// tweetURL finds what Twitter will link and wrap in t.co: anything with a scheme or www., and bare
+// domains on common top level domains
+var tweetURL = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+|\b(?:[a-z0-9-]+\.)+(?:com|net|org|io|co|dev|app|me|info|edu|gov|ly)\b(?:/[^\s<>"]*)?`)
+
+// TweetLength counts Status the way Twitter does: every link costs the length of a t.co link and
+// characters outside the Latin and common punctuation ranges, like CJK and emoji, count twice
+func (C *Configuration) TweetLength(Status string) int {
+
+	var Length int
+
+	for {
+
+		loc := tweetURL.FindStringIndex(Status)
+
+		if loc == nil {
+			return Length + weightedLength(Status)
+		}
+
+		Link := strings.TrimRight(Status[loc[0]:loc[1]], ".,;:!?)'")
+
+		Length += weightedLength(Status[:loc[0]])
+
+		if strings.HasPrefix(strings.ToLower(Link), "https://") {
+			Length += C.ShortURLLengthHTTPS
+		} else {
+			Length += C.ShortURLLength
+		}
+
+		Status = Status[loc[0]+len(Link):]
+	}
+}
+
+func weightedLength(Text string) int {
+
+	var Length int
+
+	for _, r := range Text {
+		switch {
+		case r <= 4351, r >= 8192 && r <= 8205, r >= 8208 && r <= 8223, r >= 8242 && r <= 8247:
+			Length++
+		default:
+			Length += 2
+		}
+	}
+
+	return Length
+}
+
+// TweetLength is Configuration.TweetLength with the cached configuration, see limits
+func (P *Account) TweetLength(Status string) int {
+	return P.limits().TweetLength(Status)
+}
+
+// CheckTweetLength returns an error wrapping ErrTweetTooLong when Status is over MaxTweetLength
+func (P *Account) CheckTweetLength(Status string) error {
+
+	if n := P.TweetLength(Status); n > MaxTweetLength {
+		return fmt.Errorf("%w, it counts as %d characters and the limit is %d", ErrTweetTooLong, n, MaxTweetLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestTweetLength(t *testing.T) {
+
+	C := &Configuration{ShortURLLength: 20, ShortURLLengthHTTPS: 23}
+
+	tests := []struct {
+		status string
+		want   int
+	}{
+		{"hello", 5},
+		{"café – “quoted”", 15},
+		{"日本語", 6},
+		{"go 🚀", 5},
+		{"see https://example.com/a/very/long/path/that/is/shortened", 4 + 23},
+		{"see http://example.com.", 4 + 20 + 1},
+		{"(www.golang.org)", 1 + 20 + 1},
+		{"golang.dev and go.mod", 20 + 5 + 2 + 1 + 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := C.TweetLength(tt.status); got != tt.want {
+				t.Errorf("TweetLength = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigurationCache(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	S.Configuration["short_url_length_https"] = 30
+
+	A := login(t, S, "gopher")
+	A.RefreshConfiguration()
+	defer A.RefreshConfiguration()
+
+	S.InjectError("help/configuration.json", 503, 0, "Over capacity", 1)
+
+	Status := "https://golang.org"
+
+	steps := []struct {
+		name     string
+		fetch    bool
+		fails    bool
+		length   int
+		requests int
+	}{
+		{"defaults before a fetch", false, false, 23, 0},
+		{"failed fetch keeps the defaults", true, true, 23, 0},
+		{"fetched", true, false, 30, 1},
+		{"cached", true, false, 30, 1},
+		{"counting never fetches", false, false, 30, 1},
+	}
+
+	for _, s := range steps {
+
+		if s.fetch {
+			if _, err := A.Configuration(); (err != nil) != s.fails {
+				t.Errorf("%s: Configuration() = %v, want failure %v", s.name, err, s.fails)
+			}
+		}
+
+		if got := A.TweetLength(Status); got != s.length {
+			t.Errorf("%s: TweetLength = %d, want %d", s.name, got, s.length)
+		}
+
+		var Requests int
+
+		for _, R := range S.Requests() {
+			if R.Path == "help/configuration.json" {
+				Requests++
+			}
+		}
+
+		if Requests != s.requests {
+			t.Errorf("%s: %d configuration requests, want %d", s.name, Requests, s.requests)
+		}
+	}
+
+	//an entry past ConfigurationTTL is still better than the defaults
+	defer func(TTL time.Duration) { ConfigurationTTL = TTL }(ConfigurationTTL)
+	ConfigurationTTL = 0
+
+	if got := A.TweetLength(Status); got != 30 {
+		t.Errorf("TweetLength = %d with an expired configuration, want 30", got)
+	}
+
+	//the cache is per app, not per user
+	if got := login(t, S, "other").TweetLength(Status); got != 30 {
+		t.Errorf("TweetLength = %d for another user of the app, want 30", got)
+	}
+
+	if Supported, err := A.SupportedLanguage("JA"); err != nil || !Supported {
+		t.Errorf("SupportedLanguage(JA) = %v, %v", Supported, err)
+	}
+
+	if Supported, _ := A.SupportedLanguage("xx"); Supported {
+		t.Error("SupportedLanguage(xx) = true")
+	}
+}
+
+func TestTweetTooLongNotSent(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+	A.RefreshConfiguration()
+	defer A.RefreshConfiguration()
+
+	resp, err := A.Tweet("first", "", "", false, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ID := idOf(t, resp)
+
+	//the fake counts links at full length, only whether the request is sent matters
+	tests := []struct {
+		name    string
+		call    func() (string, error)
+		tooLong bool
+	}{
+		{"tweet", func() (string, error) { return A.Tweet(strings.Repeat("a", 281), "", "", false, false) }, true},
+		{"wide characters", func() (string, error) { return A.Tweet(strings.Repeat("語", 141), "", "", false, false) }, true},
+		{"reply", func() (string, error) { return A.Reply(ID, strings.Repeat("a", 281), "") }, true},
+		{"long link", func() (string, error) {
+			return A.Tweet(strings.Repeat("a", 250)+" https://example.com/"+strings.Repeat("b", 100), "", "", false, false)
+		}, false},
+		{"reply at the limit", func() (string, error) { return A.Reply(ID, strings.Repeat("a", 280), "") }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Before := len(S.Requests())
+
+			if _, err := tt.call(); errors.Is(err, ErrTweetTooLong) != tt.tooLong {
+				t.Errorf("got %v, want too long %v", err, tt.tooLong)
+			}
+
+			if Sent := len(S.Requests()) > Before; Sent == tt.tooLong {
+				t.Errorf("sent %v, want %v", Sent, !tt.tooLong)
+			}
+		})
+	}
+
+	for _, R := range S.Requests() {
+		if R.Path == "help/configuration.json" {
+			t.Error("tweeting fetched the configuration")
+		}
+	}
+}
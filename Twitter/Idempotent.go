@@ -0,0 +1,200 @@
+package TwitterAPI
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/antonholmquist/jason"
+)
+
+// timelineLag is how long a new tweet may take to show up on its author's timeline
+var timelineLag = 3 * time.Second
+
+// tweetIdempotent posts Params to statuses/update. When the outcome is unknown (the connection dropped,
+// the request timed out or Twitter answered 5xx) or Twitter reports a duplicate (187), the authenticated
+// user's recent timeline is searched and the tweet found there is returned instead of an error. The
+// timeline is searched twice, timelineLag apart, and the tweet is never sent again: when neither search
+// finds it the original error is returned, since it may still have been published.
+func (P *Account) tweetIdempotent(Params url.Values) (string, error) {
+
+	resp, err := P.DoRequest(ENDPOINT.Tweet, Params, "POST")
+
+	if err == nil {
+		return resp, nil
+	}
+
+	if !IsErrorCode(err, 187) && !ambiguous(err) {
+		return "", err
+	}
+
+	for Lookup := 1; ; Lookup++ {
+
+		found, lookupErr := P.findPublished(Params)
+
+		switch {
+		case lookupErr != nil:
+			return "", err
+		case found != "":
+			return found, nil
+		case Lookup >= 2:
+			return "", err
+		}
+
+		time.Sleep(timelineLag)
+	}
+}
+
+// ambiguous reports whether a failed POST might still have been applied. Requests that failed
+// before they were sent, like ones a middleware canceled, were not.
+func ambiguous(err error) bool {
+
+	if E, ok := err.(*APIError); ok {
+		return E.StatusCode >= 500
+	}
+
+	return networkError(err)
+}
+
+// findPublished returns the JSON of the newest tweet on the authenticated user's timeline matching Params, or "" when there is none
+func (P *Account) findPublished(Params url.Values) (string, error) {
+
+	me, err := P.DoRequest(ENDPOINT.VerifyCredentials, url.Values{"skip_status": {"true"}}, "GET")
+
+	if err != nil {
+		return "", err
+	}
+
+	user, err := jason.NewObjectFromBytes([]byte(me))
+
+	if err != nil {
+		return "", err
+	}
+
+	UserID, err := user.GetString("id_str")
+
+	if err != nil {
+		return "", err
+	}
+
+	timeline, err := P.DoRequest(ENDPOINT.UserTimeline, url.Values{
+		"user_id":     {UserID},
+		"count":       {"50"},
+		"include_rts": {"false"},
+		"trim_user":   {"true"},
+		"tweet_mode":  {"extended"},
+	}, "GET")
+
+	if err != nil {
+		return "", err
+	}
+
+	v, err := jason.NewValueFromBytes([]byte(timeline))
+
+	if err != nil {
+		return "", err
+	}
+
+	tweets, err := v.Array()
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, t := range tweets {
+
+		tweet, err := t.Object()
+
+		if err != nil || !samePost(tweet, Params) {
+			continue
+		}
+
+		b, err := t.Marshal()
+
+		if err != nil {
+			return "", err
+		}
+
+		return string(b), nil
+	}
+
+	return "", nil
+}
+
+// samePost compares a timeline tweet with the params it would have been created from
+func samePost(Tweet *jason.Object, Params url.Values) bool {
+
+	if ReplyID := Params.Get("in_reply_to_status_id"); ReplyID != "" {
+		if id, _ := Tweet.GetString("in_reply_to_status_id_str"); id != ReplyID {
+			return false
+		}
+	}
+
+	if MediaIDs := Params.Get("media_ids"); MediaIDs != "" {
+
+		media, _ := Tweet.GetObjectArray("entities", "media")
+
+		for _, want := range strings.Split(MediaIDs, ",") {
+
+			found := false
+
+			for _, m := range media {
+				if id, _ := m.GetString("id_str"); id == want {
+					found = true
+				}
+			}
+
+			if !found {
+				return false
+			}
+		}
+	}
+
+	//the timeline is read in extended mode, text is cut to 140 characters otherwise
+	Text, err := Tweet.GetString("full_text")
+
+	if err != nil {
+		Text, _ = Tweet.GetString("text")
+	}
+
+	Text = normalizeText(Tweet, Text)
+
+	//auto populated replies start with the @mentions of the conversation
+	if Params.Get("auto_populate_reply_metadata") == "true" {
+		Text = leadingMentions.ReplaceAllString(Text, "")
+	}
+
+	return Text == strings.TrimSpace(Params.Get("status"))
+}
+
+var leadingMentions = regexp.MustCompile(`^(@\w+\s+)+`)
+
+// normalizeText undoes what Twitter does to a status on the way in: HTML escaping, t.co links and appended media links
+func normalizeText(Tweet *jason.Object, Text string) string {
+
+	Text = html.UnescapeString(Text)
+
+	urls, _ := Tweet.GetObjectArray("entities", "urls")
+
+	for _, u := range urls {
+
+		short, _ := u.GetString("url")
+		expanded, _ := u.GetString("expanded_url")
+
+		if short != "" && expanded != "" {
+			Text = strings.Replace(Text, short, expanded, -1)
+		}
+	}
+
+	media, _ := Tweet.GetObjectArray("entities", "media")
+
+	for _, m := range media {
+		if short, _ := m.GetString("url"); short != "" {
+			Text = strings.Replace(Text, short, "", -1)
+		}
+	}
+
+	return strings.TrimSpace(Text)
+}
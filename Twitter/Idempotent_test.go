@@ -0,0 +1,121 @@
+package TwitterAPI
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestIdempotentTweet(t *testing.T) {
+
+	defer func(Lag time.Duration) { timelineLag = Lag }(timelineLag)
+	timelineLag = time.Millisecond
+
+	tests := []struct {
+		name    string
+		status  string
+		fault   TwitterTest.Fault
+		before  bool
+		fails   bool
+		tweets  int
+		lookups int
+	}{
+		{"published, connection dropped", "once", TwitterTest.Fault{Drop: true, AfterHandler: true}, false, false, 1, 1},
+		{"published, 503", "once", TwitterTest.Fault{Status: 503, AfterHandler: true}, false, false, 1, 1},
+		{"not published, connection dropped", "once", TwitterTest.Fault{Drop: true}, false, true, 0, 2},
+		{"not published, 503", "once", TwitterTest.Fault{Status: 503}, false, true, 0, 2},
+		{"duplicate", "once", TwitterTest.Fault{}, true, false, 1, 1},
+		{"long status, connection dropped", strings.Repeat("long status ", 16) + "ends here", TwitterTest.Fault{Drop: true, AfterHandler: true}, false, false, 1, 1},
+		{"rejected", "once", TwitterTest.Fault{Status: 403, Code: 326, Message: "Account locked"}, false, true, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			S := TwitterTest.NewServer("ck", "cs")
+			defer S.Close()
+
+			T := login(t, S, "gopher")
+			T.IdempotentTweets = true
+
+			if tt.before {
+				if _, err := T.DoRequest(ENDPOINT.Tweet, url.Values{"status": {tt.status}}, "POST"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			Posts := 0
+
+			T.Use(Middleware{BeforeRequest: func(R *Request) error {
+				if R.Endpoint == ENDPOINT.Tweet {
+					Posts++
+				}
+				return nil
+			}})
+
+			S.InjectFault("statuses/update.json", tt.fault, 1)
+
+			resp, err := T.Tweet(tt.status, "", "", false, false)
+
+			if (err != nil) != tt.fails {
+				t.Fatalf("got error %v, want failure %v", err, tt.fails)
+			}
+
+			if err == nil && idOf(t, resp) != S.Tweets()[0].IDStr {
+				t.Errorf("returned %s, want the published tweet", resp)
+			}
+
+			if Posts != 1 {
+				t.Errorf("posted %d times, want once", Posts)
+			}
+
+			if n := len(S.Tweets()); n != tt.tweets {
+				t.Errorf("server has %d tweets, want %d", n, tt.tweets)
+			}
+
+			Lookups := 0
+
+			for _, R := range S.Requests() {
+				if R.Path == "statuses/user_timeline.json" {
+					Lookups++
+				}
+			}
+
+			if Lookups != tt.lookups {
+				t.Errorf("searched the timeline %d times, want %d", Lookups, tt.lookups)
+			}
+		})
+	}
+}
+
+func TestIdempotentReply(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	T := login(t, S, "gopher")
+	Other := login(t, S, "other")
+	Other.IdempotentTweets = true
+
+	resp, err := T.Tweet("question", "", "", false, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	S.InjectFault("statuses/update.json", TwitterTest.Fault{Drop: true, AfterHandler: true}, 1)
+
+	//the stored text has "@gopher " in front, the lookup still has to match it
+	reply, err := Other.Reply(idOf(t, resp), "answer & more", "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if idOf(t, reply) != S.Tweets()[0].IDStr || len(S.Tweets()) != 2 {
+		t.Errorf("got %s, want the published reply", reply)
+	}
+}
@@ -0,0 +1,294 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+)
+
+// ImageSpec is what an upload accepts. PrepareImage checks an image against it and, when needed,
+// scales it down and recompresses it until it fits.
+type ImageSpec struct {
+	// MaxBytes is the largest result, no limit when zero. MediaUploadFrom uses photo_size_limit from
+	// the configuration instead of a zero.
+	MaxBytes int
+
+	// Images smaller than MinWidth x MinHeight are refused, larger than MaxWidth x MaxHeight
+	// scaled down. Zero means no limit.
+	MinWidth  int
+	MinHeight int
+	MaxWidth  int
+	MaxHeight int
+
+	// Aspect is the required width / height, any when zero
+	Aspect float64
+	// Crop cuts the middle out of images with another aspect instead of refusing them
+	Crop bool
+
+	// KeepDimensions only recompresses, for uploads with offsets into the image
+	KeepDimensions bool
+}
+
+// The profile images have byte limits of their own, photo_size_limit is for tweet media only
+var (
+	// AvatarImage is a square profile picture of at most 700 KB, shown at up to 400x400
+	AvatarImage = ImageSpec{MaxBytes: 700 * 1024, MaxWidth: 400, MaxHeight: 400, Aspect: 1}
+
+	// BannerImage is a 3:1 profile banner of at least 1500x500
+	BannerImage = ImageSpec{MinWidth: 1500, MinHeight: 500, MaxWidth: 1500, MaxHeight: 500, Aspect: 3}
+
+	// BackgroundImage is a profile background of at most 800 KB
+	BackgroundImage = ImageSpec{MaxBytes: 800 * 1024}
+
+	// MediaImage is a photo attached to a tweet, MaxBytes comes from the configuration
+	MediaImage = ImageSpec{MaxWidth: 4096, MaxHeight: 4096}
+)
+
+// ErrUnsupportedImage is returned for data that isn't a JPEG, PNG or GIF
+var ErrUnsupportedImage = errors.New("Unsupported image format, expected JPEG, PNG or GIF")
+
+// maxImagePixels caps the images PrepareImage accepts. Decoding allocates 4 bytes a pixel whatever
+// the file size, a tiny PNG claiming to be 50000x50000 would take 10 GB.
+const maxImagePixels = 8192 * 8192
+
+// aspectTolerance is how far off an aspect ratio may be and still count as matching
+const aspectTolerance = 0.02
+
+// ImageFormat detects the format of an image from its content: "jpeg", "png" or "gif"
+func ImageFormat(Data []byte) (string, error) {
+
+	_, Format, err := image.DecodeConfig(bytes.NewReader(Data))
+
+	if err != nil {
+		return "", ErrUnsupportedImage
+	}
+
+	return Format, nil
+}
+
+// PrepareImage returns Data ready to upload under Spec. An image that already fits is returned
+// unchanged, otherwise it is cropped, scaled down and re-encoded: PNG and GIF stay PNG as long as
+// that fits, then JPEG at decreasing quality, then smaller. Animated GIFs are never re-encoded.
+func PrepareImage(Data []byte, Spec ImageSpec) ([]byte, error) {
+
+	Config, Format, err := image.DecodeConfig(bytes.NewReader(Data))
+
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	W, H := Config.Width, Config.Height
+
+	if W <= 0 || H <= 0 || W*H > maxImagePixels {
+		return nil, fmt.Errorf("Image is %dx%d, it can have at most %d pixels", W, H, maxImagePixels)
+	}
+
+	if W < Spec.MinWidth || H < Spec.MinHeight {
+		return nil, fmt.Errorf("Image is %dx%d, it must be at least %dx%d", W, H, Spec.MinWidth, Spec.MinHeight)
+	}
+
+	CW, CH := W, H
+	Aspect := float64(W) / float64(H)
+
+	if Spec.Aspect > 0 && math.Abs(Aspect-Spec.Aspect)/Spec.Aspect > aspectTolerance {
+
+		if !Spec.Crop {
+			return nil, fmt.Errorf("Image is %dx%d, it must have an aspect ratio of %g:1", W, H, Spec.Aspect)
+		}
+
+		CW, CH = cropSize(W, H, Spec.Aspect)
+	}
+
+	Fits := CW == W && CH == H && fitsWithin(W, H, Spec.MaxWidth, Spec.MaxHeight)
+
+	if Fits && (Spec.MaxBytes <= 0 || len(Data) <= Spec.MaxBytes) {
+		return Data, nil
+	}
+
+	if Format == "gif" {
+		if G, err := gif.DecodeAll(bytes.NewReader(Data)); err == nil && len(G.Image) > 1 {
+			return nil, errors.New("Animated GIFs can't be resized or recompressed, upload a smaller one")
+		}
+	}
+
+	Img, _, err := image.Decode(bytes.NewReader(Data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	RGBA := toRGBA(Img)
+
+	if CW != W || CH != H {
+		X, Y := (W-CW)/2, (H-CH)/2
+		RGBA = toRGBA(RGBA.SubImage(image.Rect(X, Y, X+CW, Y+CH)))
+	}
+
+	if !fitsWithin(CW, CH, Spec.MaxWidth, Spec.MaxHeight) {
+
+		if Spec.KeepDimensions {
+			return nil, fmt.Errorf("Image is %dx%d, it can be at most %dx%d", CW, CH, Spec.MaxWidth, Spec.MaxHeight)
+		}
+
+		RGBA = downscale(RGBA, fitSize(CW, CH, Spec.MaxWidth, Spec.MaxHeight))
+	}
+
+	for {
+
+		Out, err := encodeImage(RGBA, Format != "jpeg", Spec.MaxBytes)
+
+		if err == nil {
+			return Out, nil
+		}
+
+		if Spec.KeepDimensions {
+			return nil, err
+		}
+
+		B := RGBA.Bounds()
+		Next := image.Pt(B.Dx()*4/5, B.Dy()*4/5)
+
+		if Next.X < 1 || Next.Y < 1 || Next.X < Spec.MinWidth || Next.Y < Spec.MinHeight {
+			return nil, err
+		}
+
+		RGBA = downscale(RGBA, Next)
+	}
+}
+
+// jpegQualities are tried in turn until the image fits
+var jpegQualities = []int{92, 85, 75, 65, 50}
+
+// encodeImage encodes Img within MaxBytes, as PNG first when Lossless is set
+func encodeImage(Img *image.RGBA, Lossless bool, MaxBytes int) ([]byte, error) {
+
+	var Buf bytes.Buffer
+
+	if Lossless {
+
+		E := png.Encoder{CompressionLevel: png.BestCompression}
+
+		if err := E.Encode(&Buf, Img); err != nil {
+			return nil, err
+		}
+
+		if MaxBytes <= 0 || Buf.Len() <= MaxBytes {
+			return Buf.Bytes(), nil
+		}
+	}
+
+	//JPEG has no transparency, put the image on white first
+	Flat := image.NewRGBA(Img.Bounds())
+	draw.Draw(Flat, Flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(Flat, Flat.Bounds(), Img, Img.Bounds().Min, draw.Over)
+
+	for _, Quality := range jpegQualities {
+
+		Buf.Reset()
+
+		if err := jpeg.Encode(&Buf, Flat, &jpeg.Options{Quality: Quality}); err != nil {
+			return nil, err
+		}
+
+		if MaxBytes <= 0 || Buf.Len() <= MaxBytes {
+			return Buf.Bytes(), nil
+		}
+	}
+
+	B := Img.Bounds()
+
+	return nil, fmt.Errorf("%w, it is %d bytes at %dx%d and the limit is %d", ErrPhotoTooLarge, Buf.Len(), B.Dx(), B.Dy(), MaxBytes)
+}
+
+func fitsWithin(W, H, MaxW, MaxH int) bool {
+	return (MaxW <= 0 || W <= MaxW) && (MaxH <= 0 || H <= MaxH)
+}
+
+// fitSize scales W x H down to fit MaxW x MaxH, keeping the aspect ratio
+func fitSize(W, H, MaxW, MaxH int) image.Point {
+
+	Scale := 1.0
+
+	if MaxW > 0 && W > MaxW {
+		Scale = float64(MaxW) / float64(W)
+	}
+
+	if MaxH > 0 && H > MaxH {
+		Scale = math.Min(Scale, float64(MaxH)/float64(H))
+	}
+
+	return image.Pt(int(math.Max(1, math.Round(float64(W)*Scale))), int(math.Max(1, math.Round(float64(H)*Scale))))
+}
+
+// cropSize is the largest W x H area with the given aspect ratio
+func cropSize(W, H int, Aspect float64) (int, int) {
+
+	if float64(W)/float64(H) > Aspect {
+		return int(math.Round(float64(H) * Aspect)), H
+	}
+
+	return W, int(math.Round(float64(W) / Aspect))
+}
+
+func toRGBA(Img image.Image) *image.RGBA {
+
+	B := Img.Bounds()
+	RGBA := image.NewRGBA(image.Rect(0, 0, B.Dx(), B.Dy()))
+	draw.Draw(RGBA, RGBA.Bounds(), Img, B.Min, draw.Src)
+
+	return RGBA
+}
+
+// downscale shrinks Src to Size, every destination pixel averaging the source pixels it covers
+func downscale(Src *image.RGBA, Size image.Point) *image.RGBA {
+
+	B := Src.Bounds()
+	Dst := image.NewRGBA(image.Rect(0, 0, Size.X, Size.Y))
+
+	for y := 0; y < Size.Y; y++ {
+
+		Y0, Y1 := y*B.Dy()/Size.Y, (y+1)*B.Dy()/Size.Y
+
+		if Y1 <= Y0 {
+			Y1 = Y0 + 1
+		}
+
+		for x := 0; x < Size.X; x++ {
+
+			X0, X1 := x*B.Dx()/Size.X, (x+1)*B.Dx()/Size.X
+
+			if X1 <= X0 {
+				X1 = X0 + 1
+			}
+
+			var Sum [4]int
+
+			for sy := Y0; sy < Y1; sy++ {
+
+				Row := Src.Pix[sy*Src.Stride:]
+
+				for sx := X0; sx < X1; sx++ {
+					for c := 0; c < 4; c++ {
+						Sum[c] += int(Row[sx*4+c])
+					}
+				}
+			}
+
+			n := (Y1 - Y0) * (X1 - X0)
+			i := y*Dst.Stride + x*4
+
+			for c := 0; c < 4; c++ {
+				Dst.Pix[i+c] = uint8(Sum[c] / n)
+			}
+		}
+	}
+
+	return Dst
+}
@@ -0,0 +1,237 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+// testImage encodes a W x H image as Format, noisy images don't compress
+func testImage(t *testing.T, W, H int, Format string, Noisy bool) []byte {
+
+	t.Helper()
+
+	Img := image.NewRGBA(image.Rect(0, 0, W, H))
+	R := rand.New(rand.NewSource(1))
+
+	for i := range Img.Pix {
+		if Noisy {
+			Img.Pix[i] = byte(R.Intn(256))
+		} else {
+			Img.Pix[i] = 200
+		}
+	}
+
+	var Buf bytes.Buffer
+	var err error
+
+	switch Format {
+	case "png":
+		err = png.Encode(&Buf, Img)
+	case "jpeg":
+		err = jpeg.Encode(&Buf, Img, nil)
+	case "gif":
+		err = gif.Encode(&Buf, Img, nil)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Buf.Bytes()
+}
+
+// pngHeader is a PNG claiming to be W x H with no pixel data, enough for image.DecodeConfig
+func pngHeader(W, H uint32) []byte {
+
+	IHDR := make([]byte, 17)
+	copy(IHDR, "IHDR")
+	binary.BigEndian.PutUint32(IHDR[4:], W)
+	binary.BigEndian.PutUint32(IHDR[8:], H)
+	IHDR[12], IHDR[13] = 8, 6
+
+	var Buf bytes.Buffer
+
+	Buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&Buf, binary.BigEndian, uint32(13))
+	Buf.Write(IHDR)
+	binary.Write(&Buf, binary.BigEndian, crc32.ChecksumIEEE(IHDR))
+
+	return Buf.Bytes()
+}
+
+func TestPrepareImage(t *testing.T) {
+
+	Square := testImage(t, 300, 300, "png", false)
+
+	Animated := &gif.GIF{Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 600, 600), palette.Plan9), image.NewPaletted(image.Rect(0, 0, 600, 600), palette.Plan9)}, Delay: []int{10, 10}}
+	var AnimatedGIF bytes.Buffer
+	gif.EncodeAll(&AnimatedGIF, Animated)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		spec   ImageSpec
+		w, h   int
+		format string
+		err    error
+		fails  bool
+	}{
+		{"fits unchanged", Square, AvatarImage, 300, 300, "png", nil, false},
+		{"scaled down", testImage(t, 5000, 100, "jpeg", false), MediaImage, 4096, 82, "jpeg", nil, false},
+		{"wrong aspect", testImage(t, 600, 300, "png", false), AvatarImage, 0, 0, "", nil, true},
+		{"cropped", testImage(t, 600, 300, "png", false), ImageSpec{MaxWidth: 400, MaxHeight: 400, Aspect: 1, Crop: true}, 300, 300, "png", nil, false},
+		{"banner too small", testImage(t, 1200, 400, "png", false), BannerImage, 0, 0, "", nil, true},
+		{"banner scaled", testImage(t, 3000, 1000, "png", false), BannerImage, 1500, 500, "png", nil, false},
+		{"recompressed as jpeg", testImage(t, 200, 200, "png", true), ImageSpec{MaxBytes: 40000}, 200, 200, "jpeg", nil, false},
+		{"gif stays png", testImage(t, 800, 800, "gif", false), ImageSpec{MaxWidth: 500, MaxHeight: 500}, 500, 500, "png", nil, false},
+		{"shrunk to fit", testImage(t, 400, 400, "jpeg", true), ImageSpec{MaxBytes: 20000}, 0, 0, "jpeg", nil, false},
+		{"cannot fit above the minimum", testImage(t, 50, 50, "png", true), ImageSpec{MaxBytes: 1000, MinWidth: 50, MinHeight: 50}, 0, 0, "", ErrPhotoTooLarge, true},
+		{"keep dimensions", testImage(t, 500, 500, "png", false), ImageSpec{MaxWidth: 400, KeepDimensions: true}, 0, 0, "", nil, true},
+		{"animated gif", AnimatedGIF.Bytes(), ImageSpec{MaxWidth: 300, MaxHeight: 300}, 0, 0, "", nil, true},
+		{"too many pixels", pngHeader(8193, 8192), ImageSpec{}, 0, 0, "", nil, true},
+		{"not an image", []byte("GIF89 but not really"), ImageSpec{}, 0, 0, "", ErrUnsupportedImage, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Out, err := PrepareImage(tt.data, tt.spec)
+
+			if (err != nil) != tt.fails || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want failure %v, %v", err, tt.fails, tt.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			Config, Format, err := image.DecodeConfig(bytes.NewReader(Out))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if Format != tt.format || tt.w > 0 && (Config.Width != tt.w || Config.Height != tt.h) {
+				t.Errorf("got %s %dx%d, want %s %dx%d", Format, Config.Width, Config.Height, tt.format, tt.w, tt.h)
+			}
+
+			if tt.spec.MaxBytes > 0 && len(Out) > tt.spec.MaxBytes {
+				t.Errorf("got %d bytes, want at most %d", len(Out), tt.spec.MaxBytes)
+			}
+		})
+	}
+
+	if Out, _ := PrepareImage(Square, AvatarImage); &Out[0] != &Square[0] {
+		t.Error("an image that fits was copied")
+	}
+}
+
+func TestImageFormat(t *testing.T) {
+
+	tests := []struct {
+		data []byte
+		want string
+		err  error
+	}{
+		{testImage(t, 2, 2, "png", false), "png", nil},
+		{testImage(t, 2, 2, "jpeg", false), "jpeg", nil},
+		{testImage(t, 2, 2, "gif", false), "gif", nil},
+		{[]byte("<svg></svg>"), "", ErrUnsupportedImage},
+	}
+
+	for _, tt := range tests {
+		if got, err := ImageFormat(tt.data); got != tt.want || err != tt.err {
+			t.Errorf("got %q, %v, want %q, %v", got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestProfileImageUpload(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	//photo_size_limit is for tweet media, the profile images keep their own limits
+	S.Configuration["photo_size_limit"] = 30000
+
+	A := login(t, S, "gopher")
+	A.RefreshConfiguration()
+	defer A.RefreshConfiguration()
+
+	if _, err := A.Configuration(); err != nil {
+		t.Fatal(err)
+	}
+
+	Avatar := func() []byte { return S.AddUser("gopher").ProfileImage }
+	Banner := func() []byte { return S.AddUser("gopher").ProfileBanner }
+
+	tests := []struct {
+		name   string
+		upload func(Data []byte) error
+		data   []byte
+		image  func() []byte
+		w, h   int
+		limit  int
+	}{
+		{"avatar", func(Data []byte) error { _, err := A.ChangeProfilePictureFrom(BytesSource(Data)); return err }, testImage(t, 800, 800, "png", true),
+			Avatar, 400, 400, AvatarImage.MaxBytes},
+		{"banner", func(Data []byte) error { _, err := A.UpdateBannerFrom(BytesSource(Data), "", "", "", ""); return err }, testImage(t, 3000, 1000, "jpeg", false),
+			Banner, 1500, 500, 0},
+		{"banner with offsets", func(Data []byte) error {
+			_, err := A.UpdateBannerFrom(BytesSource(Data), "1500", "500", "10", "10")
+			return err
+		}, testImage(t, 1600, 600, "jpeg", false),
+			Banner, 1600, 600, 0},
+		{"tweet media", func(Data []byte) error {
+
+			ID, err := A.MediaUploadFrom(BytesSource(Data))
+
+			if err == nil {
+				_, err = A.Tweet("photo", "", ID, false, false)
+			}
+
+			return err
+		}, testImage(t, 400, 400, "jpeg", true),
+			func() []byte { return S.Tweets()[0].Entities.Media[0].Data }, 0, 0, 30000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if err := tt.upload(tt.data); err != nil {
+				t.Fatal(err)
+			}
+
+			Data := tt.image()
+			Config, _, err := image.DecodeConfig(bytes.NewReader(Data))
+
+			if err != nil || tt.w > 0 && (Config.Width != tt.w || Config.Height != tt.h) || tt.limit > 0 && len(Data) > tt.limit {
+				t.Errorf("server got %d bytes at %dx%d, %v, want %dx%d within %d", len(Data), Config.Width, Config.Height, err, tt.w, tt.h, tt.limit)
+			}
+		})
+	}
+
+	//the noisy avatar is well over photo_size_limit, the configuration wasn't applied to it
+	if n := len(Avatar()); n <= 30000 {
+		t.Errorf("avatar shrunk to %d bytes, want the photo size limit left to tweet media", n)
+	}
+
+	if _, err := A.ChangeProfilePictureFrom(BytesSource(testImage(t, 800, 400, "png", false))); err == nil {
+		t.Error("uploaded a picture that isn't square")
+	}
+
+	if len(S.Requests()) != 6 {
+		t.Errorf("%d requests, want the configuration, 4 uploads and the tweet", len(S.Requests()))
+	}
+}
@@ -0,0 +1,223 @@
+package TwitterAPI
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the latency histogram buckets in seconds
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics counts what accounts do with the API and renders it in the Prometheus text exposition format.
+// It needs nothing running, scrape it through ServeHTTP or write it out with WriteTo.
+//
+//	M := TwitterAPI.NewMetrics()
+//	T.Use(M.Middleware("brand-bot"))
+//	http.Handle("/metrics", M)
+type Metrics struct {
+	// Buckets apply to the series created after they are set, existing ones keep theirs
+	Buckets []float64
+
+	//every series is keyed by its rendered label set, e.g. account="bot",endpoint="search/tweets.json"
+	mu        sync.Mutex
+	requests  map[string]float64
+	errors    map[string]float64
+	latency   map[string]*histogram
+	remaining map[string]float64
+	limit     map[string]float64
+	uploaded  map[string]float64
+}
+
+type histogram struct {
+	buckets []float64
+	counts  []float64
+	sum     float64
+	count   float64
+}
+
+// NewMetrics returns an empty collector using DefaultBuckets
+func NewMetrics() *Metrics {
+	return &Metrics{
+		Buckets:   append([]float64(nil), DefaultBuckets...),
+		requests:  make(map[string]float64),
+		errors:    make(map[string]float64),
+		latency:   make(map[string]*histogram),
+		remaining: make(map[string]float64),
+		limit:     make(map[string]float64),
+		uploaded:  make(map[string]float64),
+	}
+}
+
+// Middleware records every attempt made by an account, Account becomes the "account" label
+func (M *Metrics) Middleware(Account string) Middleware {
+	return Middleware{
+		AfterResponse: func(R *Request, Res *Response) {
+			M.observe(Account, R, Res)
+		},
+		OnError: func(R *Request, Res *Response, err error) {
+			//responses were already counted by AfterResponse
+			if Res == nil {
+				M.observe(Account, R, nil)
+			}
+		},
+	}
+}
+
+var idSegment = regexp.MustCompile(`/\d+(\.json)$`)
+
+// endpointLabel turns a request URL into a low cardinality label like "statuses/destroy/:id.json"
+func endpointLabel(Endpoint string) string {
+
+	u, err := url.Parse(Endpoint)
+
+	if err != nil {
+		return Endpoint
+	}
+
+	return idSegment.ReplaceAllString(strings.TrimPrefix(u.Path, "/1.1/"), "/:id$1")
+}
+
+func (M *Metrics) observe(Account string, R *Request, Res *Response) {
+
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	key := labels("account", Account, "endpoint", endpointLabel(R.Endpoint))
+
+	Status := "error"
+
+	if Res != nil {
+
+		Status = strconv.Itoa(Res.StatusCode)
+
+		H, ok := M.latency[key]
+
+		if !ok {
+			H = &histogram{buckets: append([]float64(nil), M.Buckets...), counts: make([]float64, len(M.Buckets))}
+			M.latency[key] = H
+		}
+
+		seconds := Res.Duration.Seconds()
+
+		for i, b := range H.buckets {
+			if seconds <= b {
+				H.counts[i]++
+			}
+		}
+
+		H.sum += seconds
+		H.count++
+
+		if v, err := strconv.ParseFloat(Res.Header.Get("x-rate-limit-remaining"), 64); err == nil {
+			M.remaining[key] = v
+		}
+
+		if v, err := strconv.ParseFloat(Res.Header.Get("x-rate-limit-limit"), 64); err == nil {
+			M.limit[key] = v
+		}
+
+		if Res.StatusCode >= 400 {
+			if E := newAPIError(Res.StatusCode, Res.Body); E.Code != 0 {
+				M.errors[key+","+labels("code", strconv.Itoa(E.Code))]++
+			}
+		}
+	}
+
+	M.requests[key+","+labels("method", R.Method, "status", Status)]++
+
+	if R.Endpoint == ENDPOINT.MediaUpload {
+		M.uploaded[labels("account", Account)] += float64(R.BodySize)
+	}
+}
+
+// ServeHTTP writes the metrics in the text exposition format
+func (M *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	M.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the text exposition format
+func (M *Metrics) WriteTo(w io.Writer) (int64, error) {
+
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	var b strings.Builder
+
+	series := func(Name, Type, Help string, Values map[string]float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", Name, Help, Name, Type)
+		for _, k := range sortedKeys(Values) {
+			fmt.Fprintf(&b, "%s{%s} %s\n", Name, k, number(Values[k]))
+		}
+	}
+
+	series("twitter_requests_total", "counter", "API requests by endpoint, method and HTTP status (\"error\" when no response came back).", M.requests)
+	series("twitter_api_errors_total", "counter", "Twitter error codes returned by the API.", M.errors)
+
+	fmt.Fprintf(&b, "# HELP twitter_request_duration_seconds API request latency.\n# TYPE twitter_request_duration_seconds histogram\n")
+	var keys []string
+	for k := range M.latency {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+
+		H := M.latency[k]
+
+		for i, le := range H.buckets {
+			fmt.Fprintf(&b, "twitter_request_duration_seconds_bucket{%s,le=%s} %s\n", k, quote(number(le)), number(H.counts[i]))
+		}
+
+		fmt.Fprintf(&b, "twitter_request_duration_seconds_bucket{%s,le=\"+Inf\"} %s\n", k, number(H.count))
+		fmt.Fprintf(&b, "twitter_request_duration_seconds_sum{%s} %s\n", k, number(H.sum))
+		fmt.Fprintf(&b, "twitter_request_duration_seconds_count{%s} %s\n", k, number(H.count))
+	}
+
+	series("twitter_rate_limit_remaining", "gauge", "x-rate-limit-remaining from the last response.", M.remaining)
+	series("twitter_rate_limit_limit", "gauge", "x-rate-limit-limit from the last response.", M.limit)
+	series("twitter_media_uploaded_bytes_total", "counter", "Bytes sent to the media upload endpoint.", M.uploaded)
+
+	n, err := io.WriteString(w, b.String())
+
+	return int64(n), err
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func number(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// labels renders name/value pairs as a label set
+func labels(NameValues ...string) string {
+
+	var L []string
+
+	for i := 0; i+1 < len(NameValues); i += 2 {
+		L = append(L, NameValues[i]+"="+quote(NameValues[i+1]))
+	}
+
+	return strings.Join(L, ",")
+}
+
+func sortedKeys(m map[string]float64) []string {
+
+	var K []string
+
+	for k := range m {
+		K = append(K, k)
+	}
+
+	sort.Strings(K)
+
+	return K
+}
@@ -0,0 +1,115 @@
+package TwitterAPI
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestMetrics(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	T := login(t, S, "gopher")
+
+	M := NewMetrics()
+	T.Use(M.Middleware("bot"))
+
+	S.SetRateLimit("statuses/home_timeline.json", 5, time.Minute)
+	S.InjectFault("statuses/update.json", TwitterTest.Fault{Drop: true}, 1)
+
+	T.GetHomeTimeline("")
+	T.GetHomeTimeline("")
+	T.ShowTweet("1")
+
+	//a GET on a reused connection would be resent by net/http itself
+	T.DoRequest(ENDPOINT.Tweet, url.Values{"status": {"dropped"}}, "POST")
+
+	var b strings.Builder
+	M.WriteTo(&b)
+
+	tests := []string{
+		`twitter_requests_total{account="bot",endpoint="statuses/home_timeline.json",method="GET",status="200"} 2`,
+		`twitter_requests_total{account="bot",endpoint="statuses/show.json",method="GET",status="404"} 1`,
+		`twitter_requests_total{account="bot",endpoint="statuses/update.json",method="POST",status="error"} 1`,
+		`twitter_api_errors_total{account="bot",endpoint="statuses/show.json",code="144"} 1`,
+		`twitter_request_duration_seconds_count{account="bot",endpoint="statuses/home_timeline.json"} 2`,
+		`twitter_rate_limit_remaining{account="bot",endpoint="statuses/home_timeline.json"} 3`,
+		`twitter_rate_limit_limit{account="bot",endpoint="statuses/home_timeline.json"} 5`,
+	}
+
+	for _, want := range tests {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("missing %s", want)
+		}
+	}
+
+	if t.Failed() {
+		t.Log(b.String())
+	}
+}
+
+func TestMetricsBuckets(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	T := login(t, S, "gopher")
+
+	M := NewMetrics()
+	T.Use(M.Middleware("bot"))
+
+	T.GetHomeTimeline("")
+
+	//series created before the change keep their buckets, new ones use the new buckets
+	M.Buckets = []float64{1}
+
+	T.GetHomeTimeline("")
+	T.GetMentionsTimeline("")
+
+	var b strings.Builder
+	M.WriteTo(&b)
+
+	tests := []struct {
+		endpoint string
+		buckets  int
+	}{
+		{"statuses/home_timeline.json", len(DefaultBuckets) + 1},
+		{"statuses/mentions_timeline.json", 2},
+	}
+
+	for _, tt := range tests {
+
+		n := strings.Count(b.String(), `twitter_request_duration_seconds_bucket{account="bot",endpoint="`+tt.endpoint+`"`)
+
+		if n != tt.buckets {
+			t.Errorf("%s has %d buckets, want %d", tt.endpoint, n, tt.buckets)
+		}
+	}
+
+	if DefaultBuckets[0] != 0.05 {
+		t.Errorf("DefaultBuckets changed to %v", DefaultBuckets)
+	}
+}
+
+func TestEndpointLabel(t *testing.T) {
+
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{ENDPOINT.HomeTimeline, "statuses/home_timeline.json"},
+		{BASEURL + "statuses/destroy/123456.json", "statuses/destroy/:id.json"},
+		{BASEURL + "statuses/show.json?id=123", "statuses/show.json"},
+	}
+
+	for _, tt := range tests {
+		if got := endpointLabel(tt.endpoint); got != tt.want {
+			t.Errorf("endpointLabel(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package TwitterAPI
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Request is one attempt at an API call as seen by middleware
+type Request struct {
+	Method   string
+	Endpoint string
+	Params   url.Values
+
+	// Body is sent instead of the form encoded Params when set, the Params then go in the query
+	// string. Only the Params are part of the OAuth signature.
+	Body        []byte
+	ContentType string
+
+	// Files makes the request multipart/form-data, with the Params as fields next to the files.
+	// Neither is part of the OAuth signature.
+	Files []FormFile
+
+	// Header is sent along with the request, BeforeRequest hooks may add to it
+	Header http.Header
+
+	// Attempt starts at 1 and goes up every time the RetryPolicy sends the call again
+	Attempt int
+	Start   time.Time
+
+	// BodySize is the number of bytes sent as the request body, filled in once the request is sent
+	BodySize int64
+
+	// Values carries state between the hooks of a single attempt, like a tracing span
+	Values map[string]interface{}
+}
+
+// Response is what came back for a Request
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Duration   time.Duration
+}
+
+func (R *Response) httpResponse() *http.Response {
+
+	if R == nil {
+		return nil
+	}
+
+	return &http.Response{StatusCode: R.StatusCode, Header: R.Header}
+}
+
+// Middleware hooks into every request an Account makes. BeforeRequest hooks run in the order they
+// were added, AfterResponse and OnError hooks run in reverse so each middleware wraps the next.
+type Middleware struct {
+	// BeforeRequest runs before the request is signed, returning an error cancels it. The call then
+	// fails with a *CanceledError wrapping that error and is not retried.
+	BeforeRequest func(*Request) error
+
+	// AfterResponse runs for every response that came back, whatever its status
+	AfterResponse func(*Request, *Response)
+
+	// OnError runs when the attempt failed, the Response is nil when nothing came back
+	OnError func(*Request, *Response, error)
+}
+
+// CanceledError is returned for requests a BeforeRequest hook canceled, Err is what the hook returned
+type CanceledError struct {
+	Err error
+}
+
+func (E *CanceledError) Error() string {
+	return "Request canceled: " + E.Err.Error()
+}
+
+func (E *CanceledError) Unwrap() error {
+	return E.Err
+}
+
+// Use appends middleware to the account
+func (P *Account) Use(M ...Middleware) {
+	P.Middleware = append(P.Middleware, M...)
+}
+
+// SetHeader returns a middleware that adds a header to every request
+func SetHeader(Key, Value string) Middleware {
+	return Middleware{
+		BeforeRequest: func(R *Request) error {
+			R.Header.Set(Key, Value)
+			return nil
+		},
+	}
+}
+
+// LogRequests returns a middleware that writes a line per attempt to L, or the standard logger when L is nil
+func LogRequests(L *log.Logger) Middleware {
+
+	if L == nil {
+		L = log.New(log.Writer(), "", log.LstdFlags)
+	}
+
+	return Middleware{
+		AfterResponse: func(R *Request, Res *Response) {
+			L.Printf("%s %s attempt=%d status=%d duration=%s", R.Method, R.Endpoint, R.Attempt, Res.StatusCode, Res.Duration)
+		},
+		OnError: func(R *Request, Res *Response, err error) {
+			if Res == nil {
+				L.Printf("%s %s attempt=%d error=%q", R.Method, R.Endpoint, R.Attempt, err)
+			}
+		},
+	}
+}
@@ -0,0 +1,130 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		fault TwitterTest.Fault
+		want  string
+	}{
+		{"success", TwitterTest.Fault{}, "before a,before b,after b,after a"},
+		{"api error", TwitterTest.Fault{Status: 404, Code: 34}, "before a,before b,after b,after a,error b 404,error a 404"},
+		{"network error", TwitterTest.Fault{Drop: true}, "before a,before b,error b 0,error a 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			S := TwitterTest.NewServer("ck", "cs")
+			defer S.Close()
+
+			T := login(t, S, "gopher")
+
+			var Calls []string
+
+			for _, Name := range []string{"a", "b"} {
+
+				Name := Name
+
+				T.Use(Middleware{
+					BeforeRequest: func(R *Request) error {
+						Calls = append(Calls, "before "+Name)
+						return nil
+					},
+					AfterResponse: func(R *Request, Res *Response) {
+						Calls = append(Calls, "after "+Name)
+					},
+					OnError: func(R *Request, Res *Response, err error) {
+
+						Status := 0
+
+						if Res != nil {
+							Status = Res.StatusCode
+						}
+
+						Calls = append(Calls, "error "+Name+" "+strconv.Itoa(Status))
+					},
+				})
+			}
+
+			S.InjectFault("statuses/home_timeline.json", tt.fault, 1)
+			T.GetHomeTimeline("")
+
+			if got := strings.Join(Calls, ","); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanceledRequests(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	T := login(t, S, "gopher")
+	T.Retry = &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, Statuses: []int{503}}
+
+	Veto := errors.New("Quota used up")
+	Attempts := 0
+
+	T.Use(Middleware{BeforeRequest: func(R *Request) error {
+		Attempts++
+		return Veto
+	}})
+
+	_, err := T.GetHomeTimeline("")
+
+	var Canceled *CanceledError
+
+	switch {
+	case !errors.As(err, &Canceled):
+		t.Fatalf("got %v, want a *CanceledError", err)
+	case !errors.Is(err, Veto):
+		t.Errorf("got %v, want it to wrap the hook's error", err)
+	case Attempts != 1:
+		t.Errorf("made %d attempts, a canceled request must not be retried", Attempts)
+	case len(S.Requests()) != 0:
+		t.Errorf("the server saw %d requests, want none", len(S.Requests()))
+	}
+}
+
+func TestLogRequests(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	T := login(t, S, "gopher")
+
+	var Buf bytes.Buffer
+
+	T.Use(SetHeader("X-Test", "yes"), LogRequests(log.New(&Buf, "", 0)))
+
+	Seen := ""
+
+	T.Use(Middleware{AfterResponse: func(R *Request, Res *Response) { Seen = R.Header.Get("X-Test") }})
+
+	if _, err := T.GetHomeTimeline(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if Seen != "yes" {
+		t.Errorf("X-Test header %q, want yes", Seen)
+	}
+
+	if !strings.Contains(Buf.String(), "GET "+ENDPOINT.HomeTimeline+" attempt=1 status=200") {
+		t.Errorf("logged %q", Buf.String())
+	}
+}
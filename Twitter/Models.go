@@ -0,0 +1,171 @@
+package TwitterAPI
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The Account methods return raw JSON so nothing is lost, these types decode the parts most callers
+// need. Use Decode to turn a response into one of them.
+
+// User is a Twitter user object
+type User struct {
+	ID                   int64  `json:"id"`
+	IDStr                string `json:"id_str"`
+	Name                 string `json:"name"`
+	ScreenName           string `json:"screen_name"`
+	Location             string `json:"location"`
+	Description          string `json:"description"`
+	URL                  string `json:"url"`
+	Protected            bool   `json:"protected"`
+	Verified             bool   `json:"verified"`
+	FollowersCount       int    `json:"followers_count"`
+	FriendsCount         int    `json:"friends_count"`
+	ListedCount          int    `json:"listed_count"`
+	FavouritesCount      int    `json:"favourites_count"`
+	StatusesCount        int    `json:"statuses_count"`
+	CreatedAt            string `json:"created_at"`
+	Lang                 string `json:"lang,omitempty"`
+	ProfileImageURLHTTPS string `json:"profile_image_url_https,omitempty"`
+	ProfileBannerURL     string `json:"profile_banner_url,omitempty"`
+	ProfileLinkColor     string `json:"profile_link_color,omitempty"`
+	Following            bool   `json:"following"`
+	Muting               bool   `json:"muting"`
+	Blocking             bool   `json:"blocking"`
+	Status               *Tweet `json:"status,omitempty"`
+}
+
+// Tweet is a status, retweets and quotes carry the original in RetweetedStatus and QuotedStatus
+type Tweet struct {
+	ID                   int64    `json:"id"`
+	IDStr                string   `json:"id_str"`
+	Text                 string   `json:"text,omitempty"`
+	FullText             string   `json:"full_text,omitempty"`
+	CreatedAt            string   `json:"created_at"`
+	Source               string   `json:"source,omitempty"`
+	Truncated            bool     `json:"truncated"`
+	InReplyToStatusID    int64    `json:"in_reply_to_status_id,omitempty"`
+	InReplyToStatusIDStr string   `json:"in_reply_to_status_id_str,omitempty"`
+	InReplyToUserIDStr   string   `json:"in_reply_to_user_id_str,omitempty"`
+	InReplyToScreenName  string   `json:"in_reply_to_screen_name,omitempty"`
+	User                 *User    `json:"user,omitempty"`
+	RetweetCount         int      `json:"retweet_count"`
+	FavoriteCount        int      `json:"favorite_count"`
+	Favorited            bool     `json:"favorited"`
+	Retweeted            bool     `json:"retweeted"`
+	PossiblySensitive    bool     `json:"possibly_sensitive,omitempty"`
+	Lang                 string   `json:"lang,omitempty"`
+	Entities             Entities `json:"entities"`
+
+	ExtendedEntities  *Entities `json:"extended_entities,omitempty"`
+	RetweetedStatus   *Tweet    `json:"retweeted_status,omitempty"`
+	QuotedStatus      *Tweet    `json:"quoted_status,omitempty"`
+	QuotedStatusIDStr string    `json:"quoted_status_id_str,omitempty"`
+}
+
+// Entities are the hashtags, mentions, links and media found in a tweet
+type Entities struct {
+	Hashtags     []Hashtag     `json:"hashtags,omitempty"`
+	UserMentions []Mention     `json:"user_mentions,omitempty"`
+	URLs         []URLEntity   `json:"urls,omitempty"`
+	Media        []MediaEntity `json:"media,omitempty"`
+}
+
+type Hashtag struct {
+	Text    string `json:"text"`
+	Indices []int  `json:"indices"`
+}
+
+type Mention struct {
+	ID         int64  `json:"id"`
+	IDStr      string `json:"id_str"`
+	ScreenName string `json:"screen_name"`
+	Name       string `json:"name"`
+	Indices    []int  `json:"indices"`
+}
+
+type URLEntity struct {
+	URL         string `json:"url"`
+	ExpandedURL string `json:"expanded_url"`
+	DisplayURL  string `json:"display_url"`
+	Indices     []int  `json:"indices"`
+}
+
+type MediaEntity struct {
+	ID            int64  `json:"id"`
+	IDStr         string `json:"id_str"`
+	Type          string `json:"type"`
+	MediaURLHTTPS string `json:"media_url_https"`
+	URL           string `json:"url"`
+	DisplayURL    string `json:"display_url"`
+	ExpandedURL   string `json:"expanded_url"`
+	Indices       []int  `json:"indices"`
+}
+
+// DirectMessage is a direct message, ConversationID is only known for messages read from an archive
+type DirectMessage struct {
+	ID                  int64  `json:"id"`
+	IDStr               string `json:"id_str"`
+	Text                string `json:"text"`
+	CreatedAt           string `json:"created_at"`
+	SenderIDStr         string `json:"sender_id_str"`
+	RecipientIDStr      string `json:"recipient_id_str"`
+	SenderScreenName    string `json:"sender_screen_name,omitempty"`
+	RecipientScreenName string `json:"recipient_screen_name,omitempty"`
+	Sender              *User  `json:"sender,omitempty"`
+	Recipient           *User  `json:"recipient,omitempty"`
+	ConversationID      string `json:"conversation_id,omitempty"`
+}
+
+// createdAtLayouts covers the API's format and the ISO format used in archives
+var createdAtLayouts = []string{time.RubyDate, time.RFC3339Nano}
+
+func parseCreatedAt(CreatedAt string) (time.Time, error) {
+
+	var err error
+
+	for _, Layout := range createdAtLayouts {
+
+		var t time.Time
+
+		if t, err = time.Parse(Layout, CreatedAt); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// CreatedTime parses CreatedAt
+func (T *Tweet) CreatedTime() (time.Time, error) {
+	return parseCreatedAt(T.CreatedAt)
+}
+
+// Body is the tweet's text, whether it came back in extended or compatibility mode
+func (T *Tweet) Body() string {
+
+	if T.FullText != "" {
+		return T.FullText
+	}
+
+	return T.Text
+}
+
+// CreatedTime parses CreatedAt
+func (U *User) CreatedTime() (time.Time, error) {
+	return parseCreatedAt(U.CreatedAt)
+}
+
+// CreatedTime parses CreatedAt
+func (D *DirectMessage) CreatedTime() (time.Time, error) {
+	return parseCreatedAt(D.CreatedAt)
+}
+
+// Decode unmarshals a raw response from one of the Account methods into v
+//
+//	resp, err := T.ShowTweet("20")
+//	var tweet TwitterAPI.Tweet
+//	err = TwitterAPI.Decode(resp, &tweet)
+func Decode(Resp string, v interface{}) error {
+	return json.Unmarshal([]byte(Resp), v)
+}
@@ -0,0 +1,518 @@
+package TwitterAPI
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SearchMatch is a new tweet found by a Monitor, Query is the first query that found it
+type SearchMatch struct {
+	Query string
+	Tweet Tweet
+}
+
+// Monitor polls a set of searches and hands over each new tweet once.
+//
+// Every query remembers the newest tweet it has seen and only asks for tweets after it, paging
+// back when more arrived than fit on one page. When even MaxPages pages don't reach back to the
+// last position, the tweets in between are remembered as a gap and read on the following polls,
+// so they are handed over late instead of not at all. A tweet found by several queries is only
+// handed over for the first. The polling interval stretches to make the remaining search
+// requests last until the rate limit window resets, and shrinks back to Interval once there is
+// room again.
+//
+// With StatePath set, the position of every query is saved after each batch of tweets is handed
+// over, so a restarted monitor carries on where the last one stopped instead of handing over old
+// tweets again. A crash in the middle of a batch can hand over that batch again.
+type Monitor struct {
+	Account *Account
+	// Queries are the searches to run, SinceID and MaxID are managed by the monitor
+	Queries []SearchOptions
+
+	// Interval is the time between polls when there is plenty of rate limit left, a minute when zero
+	Interval time.Duration
+	// MaxInterval caps how far the interval stretches, 15 minutes when zero. An exhausted rate
+	// limit still waits for the reset.
+	MaxInterval time.Duration
+
+	// MaxPages is how many pages a query reads per poll, new tweets first and then gaps left by
+	// earlier polls, 5 when zero
+	MaxPages int
+
+	// SkipBacklog starts queries without a saved position at the newest tweet instead of
+	// handing over the tweets already there
+	SkipBacklog bool
+
+	// StatePath is where the query positions are kept, nothing is kept when empty
+	StatePath string
+
+	// OnTweet is called for every new tweet by Run, oldest first within each poll of a query
+	OnTweet func(SearchMatch)
+
+	// OnError is called with transient errors like timeouts, the monitor backs off and keeps going.
+	// Other errors, like an invalid query, stop Run.
+	OnError func(Query string, err error)
+
+	mu      sync.Mutex
+	once    sync.Once
+	state   *monitorState
+	limit   RateLimit
+	limited bool
+}
+
+// monitorState is what StatePath holds
+type monitorState struct {
+	SinceIDs map[string]string       `json:"since_ids"`
+	Gaps     map[string][]monitorGap `json:"gaps,omitempty"`
+	Seen     []string                `json:"seen"`
+
+	seen map[string]bool
+}
+
+// monitorGap is a range of tweets a query hasn't read yet, after SinceID and up to MaxID
+type monitorGap struct {
+	SinceID string `json:"since_id"`
+	MaxID   string `json:"max_id"`
+}
+
+// monitorSeen bounds how many IDs are kept for deduplicating across queries
+const monitorSeen = 10000
+
+// NewMonitor returns a monitor running Queries from A
+func NewMonitor(A *Account, Queries ...SearchOptions) *Monitor {
+	return &Monitor{Account: A, Queries: Queries}
+}
+
+// Run polls until ctx is canceled or a query fails for good, calling OnTweet with new tweets
+func (M *Monitor) Run(ctx context.Context) error {
+	return M.run(ctx, func(S SearchMatch) error {
+
+		if M.OnTweet != nil {
+			M.OnTweet(S)
+		}
+
+		return nil
+	})
+}
+
+// Start runs the monitor in the background and delivers new tweets on the first channel. The
+// second channel gets Run's error once the monitor stops, then both are closed.
+func (M *Monitor) Start(ctx context.Context) (<-chan SearchMatch, <-chan error) {
+
+	Matches := make(chan SearchMatch)
+	Errs := make(chan error, 1)
+
+	go func() {
+
+		defer close(Errs)
+		defer close(Matches)
+
+		Errs <- M.run(ctx, func(S SearchMatch) error {
+			select {
+			case Matches <- S:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return Matches, Errs
+}
+
+func (M *Monitor) run(ctx context.Context, emit func(SearchMatch) error) error {
+
+	if len(M.Queries) == 0 {
+		return errors.New("Monitor has no queries")
+	}
+
+	for _, O := range M.Queries {
+		if _, err := O.Values(); err != nil {
+			return fmt.Errorf("Search %q: %w", O.Query, err)
+		}
+	}
+
+	M.once.Do(func() { M.Account.Use(M.tracker()) })
+
+	if err := M.load(); err != nil {
+		return err
+	}
+
+	var Backoff int
+
+	for {
+
+		Requests := 0
+		Failed := false
+
+		for _, O := range M.Queries {
+
+			n, err := M.poll(O, emit)
+			Requests += n
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if err == nil {
+				continue
+			}
+
+			if Wait, ok := rateLimitWait(err); ok {
+
+				if err := sleepContext(ctx, Wait); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if !transient(err) {
+				return fmt.Errorf("Search %q: %w", O.Query, err)
+			}
+
+			Failed = true
+
+			if M.OnError != nil {
+				M.OnError(O.Query, err)
+			}
+		}
+
+		if Failed {
+			Backoff++
+		} else {
+			Backoff = 0
+		}
+
+		if err := sleepContext(ctx, M.next(Requests, Backoff)); err != nil {
+			return err
+		}
+	}
+}
+
+// poll runs one query and hands over what's new, returning how many requests it made
+func (M *Monitor) poll(O SearchOptions, emit func(SearchMatch) error) (int, error) {
+
+	Key, err := monitorKey(O)
+
+	if err != nil {
+		return 0, err
+	}
+
+	M.mu.Lock()
+	Since := M.state.SinceIDs[Key]
+	Gaps := append([]monitorGap(nil), M.state.Gaps[Key]...)
+	M.mu.Unlock()
+
+	MaxPages := M.MaxPages
+
+	if MaxPages <= 0 {
+		MaxPages = 5
+	}
+
+	if O.Count == 0 {
+		O.Count = 100
+	}
+
+	//without a position only the newest page is wanted
+	Pages := MaxPages
+
+	if Since == "" {
+		Pages = 1
+	}
+
+	Found, Requests, Complete, err := M.search(O, Since, "", Pages)
+
+	if err != nil {
+		return Requests, err
+	}
+
+	sort.SliceStable(Found, func(i, j int) bool { return Found[i].ID < Found[j].ID })
+
+	Newest := Since
+
+	if len(Found) > 0 {
+		Newest = Found[len(Found)-1].IDStr
+	}
+
+	//the pages ran out before reaching the last position, read the rest later
+	if Since != "" && !Complete {
+		Gaps = append([]monitorGap{{SinceID: Since, MaxID: strconv.FormatInt(Found[0].ID-1, 10)}}, Gaps...)
+	}
+
+	//the oldest gap is last, fill that one first
+	for len(Gaps) > 0 && Requests < MaxPages {
+
+		G := Gaps[len(Gaps)-1]
+
+		Older, n, Complete, err := M.search(O, G.SinceID, G.MaxID, MaxPages-Requests)
+		Requests += n
+
+		if err != nil {
+			return Requests, err
+		}
+
+		Found = append(Found, Older...)
+
+		if Complete {
+			Gaps = Gaps[:len(Gaps)-1]
+		} else {
+			Gaps[len(Gaps)-1].MaxID = strconv.FormatInt(Older[len(Older)-1].ID-1, 10)
+		}
+	}
+
+	sort.SliceStable(Found, func(i, j int) bool { return Found[i].ID < Found[j].ID })
+
+	if Since != "" || !M.SkipBacklog {
+
+		for _, T := range Found {
+
+			if M.isSeen(T.IDStr) {
+				continue
+			}
+
+			if err := emit(SearchMatch{Query: O.Query, Tweet: T}); err != nil {
+				return Requests, err
+			}
+
+			M.markSeen(T.IDStr)
+		}
+	}
+
+	M.mu.Lock()
+
+	if Newest != "" {
+		M.state.SinceIDs[Key] = Newest
+	}
+
+	if len(Gaps) > 0 {
+		M.state.Gaps[Key] = Gaps
+	} else {
+		delete(M.state.Gaps, Key)
+	}
+
+	M.mu.Unlock()
+
+	return Requests, M.save()
+}
+
+// search reads up to Pages pages of O after SinceID and up to MaxID, newest first. Complete is
+// true once a page comes back empty or without next_results in its search_metadata, the page
+// size says nothing as the API may return fewer tweets than asked for while more are left.
+func (M *Monitor) search(O SearchOptions, SinceID, MaxID string, Pages int) (Found []Tweet, Requests int, Complete bool, err error) {
+
+	O.SinceID, O.MaxID = SinceID, MaxID
+
+	for Requests < Pages {
+
+		resp, err := M.Account.SearchTweets(O)
+		Requests++
+
+		if err != nil {
+			return nil, Requests, false, err
+		}
+
+		var Result struct {
+			Statuses       []Tweet `json:"statuses"`
+			SearchMetadata struct {
+				NextResults string `json:"next_results"`
+			} `json:"search_metadata"`
+		}
+
+		if err := Decode(resp, &Result); err != nil {
+			return nil, Requests, false, err
+		}
+
+		Found = append(Found, Result.Statuses...)
+
+		if len(Result.Statuses) == 0 || Result.SearchMetadata.NextResults == "" {
+			return Found, Requests, true, nil
+		}
+
+		Oldest := Result.Statuses[len(Result.Statuses)-1].ID
+		O.MaxID = strconv.FormatInt(Oldest-1, 10)
+	}
+
+	return Found, Requests, false, nil
+}
+
+// monitorKey identifies a query in the state file, the same search with other options is another query
+func monitorKey(O SearchOptions) (string, error) {
+
+	O.SinceID, O.MaxID, O.Count = "", "", 0
+
+	Params, err := O.Values()
+
+	if err != nil {
+		return "", err
+	}
+
+	return Params.Encode(), nil
+}
+
+func (M *Monitor) isSeen(ID string) bool {
+
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	return M.state.seen[ID]
+}
+
+func (M *Monitor) markSeen(ID string) {
+
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	M.state.seen[ID] = true
+	M.state.Seen = append(M.state.Seen, ID)
+
+	if len(M.state.Seen) > monitorSeen {
+		for _, Old := range M.state.Seen[:len(M.state.Seen)-monitorSeen] {
+			delete(M.state.seen, Old)
+		}
+		M.state.Seen = append([]string(nil), M.state.Seen[len(M.state.Seen)-monitorSeen:]...)
+	}
+}
+
+// next is how long to wait before the next poll, given the requests a poll takes
+func (M *Monitor) next(Requests, Backoff int) time.Duration {
+
+	Interval := M.Interval
+
+	if Interval <= 0 {
+		Interval = time.Minute
+	}
+
+	MaxInterval := M.MaxInterval
+
+	if MaxInterval <= 0 {
+		MaxInterval = 15 * time.Minute
+	}
+
+	for i := 0; i < Backoff && Interval < MaxInterval; i++ {
+		Interval *= 2
+	}
+
+	M.mu.Lock()
+	L, ok := M.limit, M.limited
+	M.mu.Unlock()
+
+	UntilReset := time.Until(L.Reset)
+
+	if ok && UntilReset > 0 && Requests > 0 {
+
+		//nothing left, the window has to reset first
+		if L.Remaining < Requests {
+			return UntilReset + time.Second
+		}
+
+		//spread what's left over the rest of the window
+		if Spread := UntilReset / time.Duration(L.Remaining/Requests); Spread > Interval {
+			Interval = Spread
+		}
+	}
+
+	if Interval > MaxInterval {
+		Interval = MaxInterval
+	}
+
+	return Interval
+}
+
+// tracker remembers the search rate limit from the response headers
+func (M *Monitor) tracker() Middleware {
+	return Middleware{
+		AfterResponse: func(R *Request, Res *Response) {
+
+			if R.Endpoint != ENDPOINT.Search {
+				return
+			}
+
+			Limit, err := strconv.Atoi(Res.Header.Get("x-rate-limit-limit"))
+
+			if err != nil {
+				return
+			}
+
+			L := RateLimit{Limit: Limit}
+			L.Remaining, _ = strconv.Atoi(Res.Header.Get("x-rate-limit-remaining"))
+
+			if reset, err := strconv.ParseInt(Res.Header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+				L.Reset = time.Unix(reset, 0)
+			}
+
+			M.mu.Lock()
+			M.limit, M.limited = L, true
+			M.mu.Unlock()
+		},
+	}
+}
+
+func (M *Monitor) load() error {
+
+	M.mu.Lock()
+	defer M.mu.Unlock()
+
+	if M.state != nil {
+		return nil
+	}
+
+	S := &monitorState{SinceIDs: make(map[string]string), Gaps: make(map[string][]monitorGap), seen: make(map[string]bool)}
+
+	if M.StatePath != "" {
+
+		data, err := ioutil.ReadFile(M.StatePath)
+
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err == nil {
+
+			if err := json.Unmarshal(data, S); err != nil {
+				return fmt.Errorf("Monitor state %s is corrupt: %v", M.StatePath, err)
+			}
+
+			if S.SinceIDs == nil {
+				S.SinceIDs = make(map[string]string)
+			}
+
+			if S.Gaps == nil {
+				S.Gaps = make(map[string][]monitorGap)
+			}
+
+			for _, ID := range S.Seen {
+				S.seen[ID] = true
+			}
+		}
+	}
+
+	M.state = S
+
+	return nil
+}
+
+func (M *Monitor) save() error {
+
+	if M.StatePath == "" {
+		return nil
+	}
+
+	M.mu.Lock()
+	data, err := json.MarshalIndent(M.state, "", "  ")
+	M.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(M.StatePath, data, 0600)
+}
@@ -0,0 +1,214 @@
+package TwitterAPI
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestMonitorGaps(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+	Path := filepath.Join(t.TempDir(), "monitor.json")
+
+	var Posted int
+
+	post := func(n int) {
+		for i := 0; i < n; i++ {
+			if _, err := A.Tweet("go "+strconv.Itoa(Posted), "", "", false, false); err != nil {
+				t.Fatal(err)
+			}
+			Posted++
+		}
+	}
+
+	//two pages of two tweets per poll, so a burst of seven leaves a gap behind
+	polls := []struct {
+		name    string
+		post    int
+		restart bool
+		want    []string
+		gaps    int
+	}{
+		{"backlog", 1, false, []string{"go 0"}, 0},
+		{"burst", 7, false, []string{"go 4", "go 5", "go 6", "go 7"}, 1},
+		{"gap", 0, false, []string{"go 2", "go 3"}, 1},
+		{"gap after a restart", 1, true, []string{"go 1", "go 8"}, 0},
+		{"quiet", 0, false, nil, 0},
+	}
+
+	var M *Monitor
+
+	for _, p := range polls {
+
+		post(p.post)
+
+		if M == nil || p.restart {
+			M = &Monitor{Account: A, Queries: []SearchOptions{{Query: "go", Count: 2}}, MaxPages: 2, StatePath: Path}
+		}
+
+		if err := M.load(); err != nil {
+			t.Fatal(err)
+		}
+
+		var Got []string
+
+		if _, err := M.poll(M.Queries[0], func(S SearchMatch) error { Got = append(Got, S.Tweet.Text); return nil }); err != nil {
+			t.Fatalf("%s: %v", p.name, err)
+		}
+
+		if !reflect.DeepEqual(Got, p.want) {
+			t.Errorf("%s: got %q, want %q", p.name, Got, p.want)
+		}
+
+		Key, _ := monitorKey(M.Queries[0])
+
+		if n := len(M.state.Gaps[Key]); n != p.gaps {
+			t.Errorf("%s: %d gaps left, want %d", p.name, n, p.gaps)
+		}
+	}
+}
+
+func TestMonitorSearchComplete(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+	M := &Monitor{Account: A}
+
+	var IDs []string
+
+	for i := 0; i < 4; i++ {
+
+		resp, err := A.Tweet("go "+strconv.Itoa(i), "", "", false, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		IDs = append(IDs, idOf(t, resp))
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		since    string
+		pages    int
+		found    int
+		requests int
+		complete bool
+	}{
+		{"full last page", "go", IDs[1], 3, 2, 1, true},
+		{"two pages", "go", "", 3, 4, 2, true},
+		{"out of pages", "go", "", 1, 2, 1, false},
+		{"no matches", "rust", "", 3, 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Found, Requests, Complete, err := M.search(SearchOptions{Query: tt.query, Count: 2}, tt.since, "", tt.pages)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(Found) != tt.found || Requests != tt.requests || Complete != tt.complete {
+				t.Errorf("found %d in %d requests, complete %v, want %d in %d, %v", len(Found), Requests, Complete, tt.found, tt.requests, tt.complete)
+			}
+		})
+	}
+}
+
+func TestMonitorStart(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	for _, Status := range []string{"old golang news", "rust news", "golang and rust"} {
+		A.Tweet(Status, "", "", false, false)
+	}
+
+	tests := []struct {
+		name    string
+		monitor *Monitor
+		want    []string
+	}{
+		{"found by both queries once", NewMonitor(A, SearchOptions{Query: "golang"}, SearchOptions{Query: "rust"}), []string{"golang: old golang news", "golang: golang and rust", "rust: rust news"}},
+		{"skip backlog", &Monitor{Account: A, Queries: []SearchOptions{{Query: "golang"}}, SkipBacklog: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			Matches, Errs := tt.monitor.Start(ctx)
+
+			var Got []string
+
+			for S := range Matches {
+				Got = append(Got, S.Query+": "+S.Tweet.Text)
+			}
+
+			if err := <-Errs; err != context.DeadlineExceeded {
+				t.Errorf("got %v, want the deadline", err)
+			}
+
+			if !reflect.DeepEqual(Got, tt.want) {
+				t.Errorf("got %q, want %q", Got, tt.want)
+			}
+		})
+	}
+
+	if err := NewMonitor(A, SearchOptions{Query: "go", Count: 500}).Run(context.Background()); err == nil {
+		t.Error("Run started with a bad count")
+	}
+}
+
+func TestMonitorInterval(t *testing.T) {
+
+	Reset := time.Now().Add(10 * time.Minute)
+
+	tests := []struct {
+		name     string
+		limit    *RateLimit
+		requests int
+		backoff  int
+		min, max time.Duration
+	}{
+		{"default", nil, 1, 0, time.Minute, time.Minute},
+		{"backing off", nil, 1, 2, 4 * time.Minute, 4 * time.Minute},
+		{"backoff capped", nil, 1, 10, 15 * time.Minute, 15 * time.Minute},
+		{"plenty left", &RateLimit{Limit: 180, Remaining: 170, Reset: Reset}, 1, 0, time.Minute, time.Minute},
+		{"spread over the window", &RateLimit{Limit: 180, Remaining: 4, Reset: Reset}, 2, 0, 4 * time.Minute, 5 * time.Minute},
+		{"exhausted", &RateLimit{Limit: 180, Remaining: 1, Reset: Reset}, 2, 0, 10 * time.Minute, 10*time.Minute + time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			M := &Monitor{}
+
+			if tt.limit != nil {
+				M.limit, M.limited = *tt.limit, true
+			}
+
+			if got := M.next(tt.requests, tt.backoff); got < tt.min || got > tt.max {
+				t.Errorf("next = %v, want between %v and %v", got, tt.min, tt.max)
+			}
+		})
+	}
+}
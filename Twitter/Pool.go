@@ -0,0 +1,324 @@
+package TwitterAPI
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pool runs the same operation across many accounts at once.
+//
+// Every account added to a pool gets a middleware that remembers the x-rate-limit headers of each
+// endpoint it calls. Once an endpoint is exhausted further calls to it fail fast with a
+// *RateLimitError until the window resets, instead of spending a request on a 429. The middleware
+// cancels those calls, so they arrive wrapped in a *CanceledError, use errors.As to find them.
+type Pool struct {
+	// Concurrency caps how many accounts run at the same time, 0 runs them all at once
+	Concurrency int
+
+	mu       sync.Mutex
+	accounts []*Account
+	names    map[string]*Account
+	limits   map[*Account]map[string]RateLimit
+}
+
+// RateLimit is the last known rate limit state of one endpoint for one account
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// RateLimitError is returned for calls the pool held back because the endpoint is exhausted
+type RateLimitError struct {
+	Account  string
+	Endpoint string
+	Reset    time.Time
+}
+
+func (E *RateLimitError) Error() string {
+	return fmt.Sprintf("%s has no requests left on %s until %s", E.Account, E.Endpoint, E.Reset.Format(time.Kitchen))
+}
+
+// NewPool returns a pool of the given accounts, see Add
+func NewPool(Accounts ...*Account) *Pool {
+
+	P := &Pool{names: make(map[string]*Account), limits: make(map[*Account]map[string]RateLimit)}
+
+	for _, A := range Accounts {
+		P.Add(A)
+	}
+
+	return P
+}
+
+// Add puts an account in the pool. Accounts are named by ScreenName, or by their position when it is empty.
+func (P *Pool) Add(A *Account) {
+
+	P.mu.Lock()
+	defer P.mu.Unlock()
+
+	if _, ok := P.limits[A]; ok {
+		return
+	}
+
+	P.accounts = append(P.accounts, A)
+	P.names[storeKey(P.name(A))] = A
+	P.limits[A] = make(map[string]RateLimit)
+
+	A.Use(P.tracker(A))
+}
+
+// Get returns the account with the given screen name, or nil
+func (P *Pool) Get(ScreenName string) *Account {
+
+	P.mu.Lock()
+	defer P.mu.Unlock()
+
+	return P.names[storeKey(ScreenName)]
+}
+
+// Accounts returns the accounts in the order they were added
+func (P *Pool) Accounts() []*Account {
+
+	P.mu.Lock()
+	defer P.mu.Unlock()
+
+	return append([]*Account(nil), P.accounts...)
+}
+
+// name must be called with P.mu held
+func (P *Pool) name(A *Account) string {
+
+	if A.ScreenName != "" {
+		return A.ScreenName
+	}
+
+	for i, a := range P.accounts {
+		if a == A {
+			return "#" + strconv.Itoa(i)
+		}
+	}
+
+	return "#" + strconv.Itoa(len(P.accounts))
+}
+
+// RateLimit returns what the pool last heard about Endpoint for A, Endpoint is an ENDPOINT value
+func (P *Pool) RateLimit(A *Account, Endpoint string) (RateLimit, bool) {
+
+	P.mu.Lock()
+	defer P.mu.Unlock()
+
+	L, ok := P.limits[A][endpointLabel(Endpoint)]
+
+	return L, ok
+}
+
+func (P *Pool) tracker(A *Account) Middleware {
+	return Middleware{
+		BeforeRequest: func(R *Request) error {
+
+			P.mu.Lock()
+			defer P.mu.Unlock()
+
+			Endpoint := endpointLabel(R.Endpoint)
+
+			if L, ok := P.limits[A][Endpoint]; ok && L.Remaining <= 0 && time.Now().Before(L.Reset) {
+				return &RateLimitError{Account: P.name(A), Endpoint: Endpoint, Reset: L.Reset}
+			}
+
+			return nil
+		},
+		AfterResponse: func(R *Request, Res *Response) {
+
+			Limit, err := strconv.Atoi(Res.Header.Get("x-rate-limit-limit"))
+
+			if err != nil {
+				return
+			}
+
+			L := RateLimit{Limit: Limit}
+			L.Remaining, _ = strconv.Atoi(Res.Header.Get("x-rate-limit-remaining"))
+
+			if reset, err := strconv.ParseInt(Res.Header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+				L.Reset = time.Unix(reset, 0)
+			}
+
+			P.mu.Lock()
+			P.limits[A][endpointLabel(R.Endpoint)] = L
+			P.mu.Unlock()
+		},
+	}
+}
+
+// Operation is one call made on behalf of an account, the string is the raw API response
+type Operation func(A *Account) (string, error)
+
+// Result is how an Operation went for one account
+type Result struct {
+	Account  string
+	Response string
+	Err      error
+	Duration time.Duration
+}
+
+// Report collects the results of a fan-out in the order the accounts were given
+type Report struct {
+	Results []Result
+}
+
+// Succeeded returns the results without an error
+func (R *Report) Succeeded() []Result {
+
+	var S []Result
+
+	for _, r := range R.Results {
+		if r.Err == nil {
+			S = append(S, r)
+		}
+	}
+
+	return S
+}
+
+// Failed returns the results with an error
+func (R *Report) Failed() []Result {
+
+	var F []Result
+
+	for _, r := range R.Results {
+		if r.Err != nil {
+			F = append(F, r)
+		}
+	}
+
+	return F
+}
+
+// Err summarises the failures, it is nil when every account succeeded
+func (R *Report) Err() error {
+
+	F := R.Failed()
+
+	if len(F) == 0 {
+		return nil
+	}
+
+	var Names []string
+
+	for _, r := range F {
+		Names = append(Names, r.Account)
+	}
+
+	return fmt.Errorf("%d of %d accounts failed: %s", len(F), len(R.Results), strings.Join(Names, ", "))
+}
+
+// String is a line per account, failures first
+func (R *Report) String() string {
+
+	Results := append([]Result(nil), R.Results...)
+
+	sort.SliceStable(Results, func(i, j int) bool { return Results[i].Err != nil && Results[j].Err == nil })
+
+	var B strings.Builder
+
+	for _, r := range Results {
+		if r.Err != nil {
+			fmt.Fprintf(&B, "FAIL %s: %v\n", r.Account, r.Err)
+		} else {
+			fmt.Fprintf(&B, "OK   %s (%s)\n", r.Account, r.Duration.Round(time.Millisecond))
+		}
+	}
+
+	return B.String()
+}
+
+// Run calls Op for every account in the pool
+func (P *Pool) Run(Op Operation) *Report {
+	return P.RunOn(P.Accounts(), Op)
+}
+
+// RunOn calls Op for the given accounts, at most Concurrency at a time
+func (P *Pool) RunOn(Accounts []*Account, Op Operation) *Report {
+
+	R := &Report{Results: make([]Result, len(Accounts))}
+
+	Concurrency := P.Concurrency
+
+	if Concurrency <= 0 || Concurrency > len(Accounts) {
+		Concurrency = len(Accounts)
+	}
+
+	slots := make(chan struct{}, Concurrency)
+
+	var wg sync.WaitGroup
+
+	for i, A := range Accounts {
+
+		P.mu.Lock()
+		R.Results[i].Account = P.name(A)
+		P.mu.Unlock()
+
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(i int, A *Account) {
+
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			Start := time.Now()
+			R.Results[i].Response, R.Results[i].Err = Op(A)
+			R.Results[i].Duration = time.Since(Start)
+		}(i, A)
+	}
+
+	wg.Wait()
+
+	return R
+}
+
+// Select returns the pooled accounts with the given screen names, failing on unknown names
+func (P *Pool) Select(ScreenNames ...string) ([]*Account, error) {
+
+	var Accounts []*Account
+
+	for _, Name := range ScreenNames {
+
+		A := P.Get(Name)
+
+		if A == nil {
+			return nil, fmt.Errorf("No account named %s in the pool", Name)
+		}
+
+		Accounts = append(Accounts, A)
+	}
+
+	return Accounts, nil
+}
+
+// Tweet posts Status from every account in the pool
+func (P *Pool) Tweet(Status string) *Report {
+	return P.Run(func(A *Account) (string, error) {
+		return A.Tweet(Status, "", "", false, false)
+	})
+}
+
+// Retweet retweets TweetID from every account in the pool
+func (P *Pool) Retweet(TweetID string) *Report {
+	return P.Run(func(A *Account) (string, error) {
+		return A.Retweet(TweetID)
+	})
+}
+
+// FollowUser follows ScreenName from every account in the pool
+func (P *Pool) FollowUser(ScreenName string) *Report {
+	return P.Run(func(A *Account) (string, error) {
+		return A.FollowUser(ScreenName, "")
+	})
+}
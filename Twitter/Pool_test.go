@@ -0,0 +1,104 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestPoolRateLimit(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	Busy := login(t, S, "busy")
+	Idle := login(t, S, "idle")
+
+	P := NewPool(Busy, Idle)
+
+	S.SetRateLimit("statuses/update.json", 1, time.Minute)
+
+	if _, err := Busy.Tweet("first", "", "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	Before := len(S.Requests())
+	R := P.Tweet("second")
+
+	tests := []struct {
+		account string
+		limited bool
+	}{
+		{"busy", true},
+		{"idle", false},
+	}
+
+	for i, tt := range tests {
+
+		r := R.Results[i]
+
+		var Limited *RateLimitError
+
+		if r.Account != tt.account || errors.As(r.Err, &Limited) != tt.limited {
+			t.Errorf("got %s: %v, want %s limited %v", r.Account, r.Err, tt.account, tt.limited)
+		}
+
+		if Limited != nil && (Limited.Account != "busy" || Limited.Endpoint != "statuses/update.json") {
+			t.Errorf("got %+v", Limited)
+		}
+	}
+
+	//only the idle account's tweet reached the server
+	if n := len(S.Requests()) - Before; n != 1 {
+		t.Errorf("the server saw %d more requests, want 1", n)
+	}
+
+	if L, ok := P.RateLimit(Idle, ENDPOINT.Tweet); !ok || L.Limit != 1 || L.Remaining != 0 {
+		t.Errorf("RateLimit(idle) = %+v, %v", L, ok)
+	}
+}
+
+func TestPoolReport(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	P := NewPool(login(t, S, "a"), login(t, S, "b"), login(t, S, "c"))
+	P.Concurrency = 1
+
+	if _, err := P.Get("B").Tweet("taken", "", "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	R := P.Tweet("taken")
+
+	tests := []struct {
+		account string
+		ok      bool
+	}{
+		{"a", true},
+		{"b", false},
+		{"c", true},
+	}
+
+	for i, tt := range tests {
+		if r := R.Results[i]; r.Account != tt.account || (r.Err == nil) != tt.ok {
+			t.Errorf("result %d: got %s %v, want %s ok %v", i, r.Account, r.Err, tt.account, tt.ok)
+		}
+	}
+
+	if err := R.Err(); err == nil || err.Error() != "1 of 3 accounts failed: b" {
+		t.Errorf("Err() = %v", err)
+	}
+
+	if !strings.HasPrefix(R.String(), "FAIL b: ") {
+		t.Errorf("String() = %q, want failures first", R.String())
+	}
+
+	if _, err := P.Select("a", "nobody"); err == nil {
+		t.Error("Select accepted an account that isn't in the pool")
+	}
+}
@@ -0,0 +1,248 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// String, Bool and Int return pointers for the optional fields of ProfileOptions and SettingsOptions
+func String(v string) *string { return &v }
+func Bool(v bool) *bool       { return &v }
+func Int(v int) *int          { return &v }
+
+// ProfileOptions are the fields of account/update_profile. Nil fields are left as they are, a
+// pointer to "" clears the field.
+type ProfileOptions struct {
+	// Name is up to 50 characters, URL up to 100, Location up to 30 and Description up to 160
+	Name        *string
+	URL         *string
+	Location    *string
+	Description *string
+	// ProfileLinkColor is a hex color like "1DA1F2" or "#F80", with or without the #
+	ProfileLinkColor *string
+
+	// ExcludeEntities sends include_entities=false, SkipStatus leaves the latest tweet out of the user
+	ExcludeEntities bool
+	SkipStatus      bool
+}
+
+var linkColor = regexp.MustCompile(`^#?([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// profileLimits are the longest values account/update_profile accepts, in characters
+var profileLimits = map[string]int{"name": 50, "url": 100, "location": 30, "description": 160}
+
+// Values validates the options and returns them as request parameters
+func (O ProfileOptions) Values() (url.Values, error) {
+
+	var Params = url.Values{}
+
+	for _, F := range []struct {
+		Name  string
+		Value *string
+	}{{"name", O.Name}, {"url", O.URL}, {"location", O.Location}, {"description", O.Description}} {
+
+		if F.Value == nil {
+			continue
+		}
+
+		if n := utf8.RuneCountInString(*F.Value); n > profileLimits[F.Name] {
+			return nil, fmt.Errorf("Profile %s is %d characters, the limit is %d", F.Name, n, profileLimits[F.Name])
+		}
+
+		Params.Set(F.Name, *F.Value)
+	}
+
+	if O.ProfileLinkColor != nil {
+
+		m := linkColor.FindStringSubmatch(*O.ProfileLinkColor)
+
+		if m == nil {
+			return nil, fmt.Errorf("Invalid profile link color %q, expected a hex color like 1DA1F2", *O.ProfileLinkColor)
+		}
+
+		Params.Set("profile_link_color", m[1])
+	}
+
+	if len(Params) == 0 {
+		return nil, errors.New("ProfileOptions has nothing to change")
+	}
+
+	if O.ExcludeEntities {
+		Params.Set("include_entities", "false")
+	}
+
+	if O.SkipStatus {
+		Params.Set("skip_status", "true")
+	}
+
+	return Params, nil
+}
+
+// UpdateProfile changes the fields set in O and returns the updated user
+func (P *Account) UpdateProfile(O ProfileOptions) (*User, error) {
+
+	Params, err := O.Values()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := P.DoRequest(ENDPOINT.UpdateProfile, Params, "POST")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var U User
+
+	return &U, Decode(resp, &U)
+}
+
+// AccountSettings is what account/settings returns
+type AccountSettings struct {
+	ScreenName            string          `json:"screen_name"`
+	Language              string          `json:"language"`
+	Protected             bool            `json:"protected"`
+	GeoEnabled            bool            `json:"geo_enabled"`
+	AlwaysUseHTTPS        bool            `json:"always_use_https"`
+	DiscoverableByEmail   bool            `json:"discoverable_by_email"`
+	DisplaySensitiveMedia bool            `json:"display_sensitive_media"`
+	AllowDMsFrom          string          `json:"allow_dms_from"`
+	TimeZone              TimeZone        `json:"time_zone"`
+	SleepTime             SleepTime       `json:"sleep_time"`
+	TrendLocation         []TrendLocation `json:"trend_location"`
+}
+
+// TimeZone is the account's time zone, UTCOffset in seconds
+type TimeZone struct {
+	Name       string `json:"name"`
+	UTCOffset  int    `json:"utc_offset"`
+	TZInfoName string `json:"tzinfo_name"`
+}
+
+// SleepTime is when notifications are held back, the hours are nil while it's disabled
+type SleepTime struct {
+	Enabled   bool `json:"enabled"`
+	StartTime *int `json:"start_time"`
+	EndTime   *int `json:"end_time"`
+}
+
+// TrendLocation is the place trends are shown for, WOEID is its Yahoo! Where On Earth ID
+type TrendLocation struct {
+	Name        string `json:"name"`
+	Country     string `json:"country"`
+	CountryCode string `json:"countryCode"`
+	WOEID       int    `json:"woeid"`
+}
+
+// SettingsOptions are the fields of POST account/settings, nil and zero fields are left as they are
+type SettingsOptions struct {
+	SleepTimeEnabled *bool
+	// StartSleepTime and EndSleepTime are hours from 0 to 23 in the account's time zone
+	StartSleepTime *int
+	EndSleepTime   *int
+
+	// TimeZone is a Rails time zone name like "Europe/Berlin" or "Pacific Time (US & Canada)"
+	TimeZone string
+	// Lang is the language of the Twitter interface, one of Languages
+	Lang string
+	// TrendLocationWOEID picks where trends are shown for, 1 is worldwide
+	TrendLocationWOEID int
+}
+
+// Values validates the options and returns them as request parameters, Lang is only checked for
+// its form here, ChangeAccountSettings also checks it against the supported languages
+func (O SettingsOptions) Values() (url.Values, error) {
+
+	var Params = url.Values{}
+
+	if O.SleepTimeEnabled != nil {
+		Params.Set("sleep_time_enabled", strconv.FormatBool(*O.SleepTimeEnabled))
+	}
+
+	for _, F := range []struct {
+		Name  string
+		Value *int
+	}{{"start_sleep_time", O.StartSleepTime}, {"end_sleep_time", O.EndSleepTime}} {
+
+		if F.Value == nil {
+			continue
+		}
+
+		if *F.Value < 0 || *F.Value > 23 {
+			return nil, fmt.Errorf("%s must be an hour between 0 and 23, got %d", F.Name, *F.Value)
+		}
+
+		Params.Set(F.Name, fmt.Sprintf("%02d", *F.Value))
+	}
+
+	if O.TimeZone != "" {
+		Params.Set("time_zone", strings.TrimSpace(O.TimeZone))
+	}
+
+	if O.Lang != "" {
+		if !langCode.MatchString(O.Lang) {
+			return nil, fmt.Errorf("Invalid language code %q", O.Lang)
+		}
+		Params.Set("lang", O.Lang)
+	}
+
+	if O.TrendLocationWOEID != 0 {
+		if O.TrendLocationWOEID < 0 {
+			return nil, fmt.Errorf("Invalid trend location WOEID %d", O.TrendLocationWOEID)
+		}
+		Params.Set("trend_location_woeid", strconv.Itoa(O.TrendLocationWOEID))
+	}
+
+	if len(Params) == 0 {
+		return nil, errors.New("SettingsOptions has nothing to change")
+	}
+
+	return Params, nil
+}
+
+// ChangeAccountSettings changes the settings set in O and returns them all
+func (P *Account) ChangeAccountSettings(O SettingsOptions) (*AccountSettings, error) {
+
+	Params, err := O.Values()
+
+	if err != nil {
+		return nil, err
+	}
+
+	//the language list may be unreachable, the API still has the last word then
+	if O.Lang != "" {
+		if ok, err := P.SupportedLanguage(O.Lang); err == nil && !ok {
+			return nil, fmt.Errorf("Language %q isn't supported by Twitter", O.Lang)
+		}
+	}
+
+	resp, err := P.DoRequest(ENDPOINT.ChangeAccountSettings, Params, "POST")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var S AccountSettings
+
+	return &S, Decode(resp, &S)
+}
+
+// Settings returns the account's settings decoded
+func (P *Account) Settings() (*AccountSettings, error) {
+
+	resp, err := P.GetAccountSettings()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var S AccountSettings
+
+	return &S, Decode(resp, &S)
+}
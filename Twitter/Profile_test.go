@@ -0,0 +1,153 @@
+package TwitterAPI
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestProfileOptions(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		options ProfileOptions
+		want    map[string][]string
+		fails   bool
+	}{
+		{"name", ProfileOptions{Name: String("Gopher")}, map[string][]string{"name": {"Gopher"}}, false},
+		{"clear description", ProfileOptions{Description: String("")}, map[string][]string{"description": {""}}, false},
+		{"short color", ProfileOptions{ProfileLinkColor: String("#F80"), SkipStatus: true, ExcludeEntities: true},
+			map[string][]string{"profile_link_color": {"F80"}, "skip_status": {"true"}, "include_entities": {"false"}}, false},
+		{"wide characters count once", ProfileOptions{Location: String(strings.Repeat("東", 30))}, map[string][]string{"location": {strings.Repeat("東", 30)}}, false},
+		{"location too long", ProfileOptions{Location: String(strings.Repeat("a", 31))}, nil, true},
+		{"description too long", ProfileOptions{Description: String(strings.Repeat("a", 161))}, nil, true},
+		{"bad color", ProfileOptions{ProfileLinkColor: String("blue")}, nil, true},
+		{"nothing to change", ProfileOptions{SkipStatus: true}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got, err := tt.options.Values()
+
+			if (err != nil) != tt.fails || (err == nil && !reflect.DeepEqual(map[string][]string(got), tt.want)) {
+				t.Errorf("got %v, %v, want %v, failure %v", got, err, tt.want, tt.fails)
+			}
+		})
+	}
+}
+
+func TestSettingsOptions(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		options SettingsOptions
+		want    map[string][]string
+		fails   bool
+	}{
+		{"sleep time", SettingsOptions{SleepTimeEnabled: Bool(true), StartSleepTime: Int(0), EndSleepTime: Int(7)},
+			map[string][]string{"sleep_time_enabled": {"true"}, "start_sleep_time": {"00"}, "end_sleep_time": {"07"}}, false},
+		{"everything else", SettingsOptions{TimeZone: " Europe/Berlin ", Lang: "ja", TrendLocationWOEID: 1},
+			map[string][]string{"time_zone": {"Europe/Berlin"}, "lang": {"ja"}, "trend_location_woeid": {"1"}}, false},
+		{"disable sleep time", SettingsOptions{SleepTimeEnabled: Bool(false)}, map[string][]string{"sleep_time_enabled": {"false"}}, false},
+		{"hour too late", SettingsOptions{EndSleepTime: Int(24)}, nil, true},
+		{"negative hour", SettingsOptions{StartSleepTime: Int(-1)}, nil, true},
+		{"bad language", SettingsOptions{Lang: "japanese"}, nil, true},
+		{"bad woeid", SettingsOptions{TrendLocationWOEID: -1}, nil, true},
+		{"nothing to change", SettingsOptions{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got, err := tt.options.Values()
+
+			if (err != nil) != tt.fails || (err == nil && !reflect.DeepEqual(map[string][]string(got), tt.want)) {
+				t.Errorf("got %v, %v, want %v, failure %v", got, err, tt.want, tt.fails)
+			}
+		})
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	steps := []struct {
+		name    string
+		options ProfileOptions
+		want    User
+	}{
+		{"set", ProfileOptions{Name: String("Gopher"), Location: String("Mountain View"), Description: String("Go!"), ProfileLinkColor: String("#1DA1F2")},
+			User{Name: "Gopher", Location: "Mountain View", Description: "Go!", ProfileLinkColor: "1DA1F2"}},
+		{"nil fields are kept", ProfileOptions{URL: String("https://go.dev")},
+			User{Name: "Gopher", Location: "Mountain View", Description: "Go!", URL: "https://go.dev", ProfileLinkColor: "1DA1F2"}},
+		{"empty fields are cleared", ProfileOptions{Description: String(""), Location: String("")},
+			User{Name: "Gopher", URL: "https://go.dev", ProfileLinkColor: "1DA1F2"}},
+	}
+
+	for _, s := range steps {
+
+		U, err := A.UpdateProfile(s.options)
+
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+
+		got := User{Name: U.Name, Location: U.Location, Description: U.Description, URL: U.URL, ProfileLinkColor: U.ProfileLinkColor}
+
+		if !reflect.DeepEqual(got, s.want) {
+			t.Errorf("%s: got %+v, want %+v", s.name, got, s.want)
+		}
+	}
+
+	Before := len(S.Requests())
+
+	if _, err := A.UpdateProfile(ProfileOptions{Name: String(strings.Repeat("a", 51))}); err == nil || len(S.Requests()) != Before {
+		t.Errorf("got %v after %d requests, want a long name refused before sending", err, len(S.Requests())-Before)
+	}
+}
+
+func TestChangeAccountSettings(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+	A.RefreshConfiguration()
+	defer A.RefreshConfiguration()
+
+	Settings, err := A.ChangeAccountSettings(SettingsOptions{SleepTimeEnabled: Bool(true), StartSleepTime: Int(22), EndSleepTime: Int(6), TimeZone: "Asia/Tokyo", Lang: "ja"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !Settings.SleepTime.Enabled || *Settings.SleepTime.StartTime != 22 || *Settings.SleepTime.EndTime != 6 || Settings.TimeZone.UTCOffset != 9*3600 || Settings.Language != "ja" {
+		t.Errorf("got %+v", *Settings)
+	}
+
+	if Read, err := A.Settings(); err != nil || !reflect.DeepEqual(Read, Settings) {
+		t.Errorf("Settings() = %+v, %v, want what was set", Read, err)
+	}
+
+	//xx has the form of a language code but isn't one of help/languages
+	Before := len(S.Requests())
+
+	if _, err := A.ChangeAccountSettings(SettingsOptions{Lang: "xx"}); err == nil || len(S.Requests()) != Before {
+		t.Errorf("got %v after %d requests, want an unsupported language refused before sending", err, len(S.Requests())-Before)
+	}
+
+	//when the languages can't be fetched the API has the last word
+	A.RefreshConfiguration()
+	S.InjectError("help/languages.json", 503, 0, "Over capacity", 1)
+
+	if _, err := A.ChangeAccountSettings(SettingsOptions{Lang: "fr"}); err != nil {
+		t.Errorf("got %v, want the setting sent without the language list", err)
+	}
+}
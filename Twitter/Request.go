@@ -0,0 +1,302 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/garyburd/go-oauth/oauth"
+)
+
+// DoRequest signs and sends a request, retrying it according to P.Retry and answering from P.Cache
+// when it can. Responses with an HTTP status of 400 or above come back as an *APIError.
+func (P *Account) DoRequest(Endpoint string, Params url.Values, Method string) (string, error) {
+
+	if Method != "GET" && Method != "POST" {
+		return "", errors.New("You must supply either a GET or POST method.")
+	}
+
+	return P.do(Request{Endpoint: Endpoint, Params: Params, Method: Method})
+}
+
+// DoJSON POSTs Body encoded as JSON, for the endpoints that take a JSON document instead of a form.
+// Params go in the query string.
+func (P *Account) DoJSON(Endpoint string, Params url.Values, Body interface{}) (string, error) {
+
+	data, err := json.Marshal(Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	return P.do(Request{Endpoint: Endpoint, Params: Params, Method: "POST", Body: data, ContentType: "application/json"})
+}
+
+// FormFile is a file sent by DoMultipart
+type FormFile struct {
+	// Field is the form field, like "media"
+	Field string
+	// Name is the file name, ContentType is sniffed from Data when empty
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// DoMultipart POSTs Params and Files as multipart/form-data. As OAuth 1.0a requires for multipart
+// bodies, only the oauth_* parameters are signed, the Params aren't.
+func (P *Account) DoMultipart(Endpoint string, Params url.Values, Files ...FormFile) (string, error) {
+
+	if len(Files) == 0 {
+		return "", errors.New("DoMultipart needs at least one file")
+	}
+
+	return P.do(Request{Endpoint: Endpoint, Params: Params, Method: "POST", Files: Files})
+}
+
+// multipartBody encodes the form as a stream over the files' data, which isn't copied. Only the
+// part headers are written out up front, so the length of the body is known before it is sent:
+// Twitter's upload endpoints refuse chunked bodies.
+func multipartBody(Params url.Values, Files []FormFile) (io.Reader, int64, string, error) {
+
+	var Head bytes.Buffer
+	var Parts []io.Reader
+	var Size int64
+
+	M := multipart.NewWriter(&Head)
+
+	//next moves what M has written so far into the body
+	next := func() {
+		Written := append([]byte(nil), Head.Bytes()...)
+		Parts = append(Parts, bytes.NewReader(Written))
+		Size += int64(len(Written))
+		Head.Reset()
+	}
+
+	for k, vs := range Params {
+		for _, v := range vs {
+			if err := M.WriteField(k, v); err != nil {
+				return nil, 0, "", err
+			}
+		}
+	}
+
+	for _, F := range Files {
+
+		ContentType := F.ContentType
+
+		if ContentType == "" {
+			ContentType = DetectMIME(F.Data)
+		}
+
+		H := textproto.MIMEHeader{}
+		H.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(F.Field), quoteEscaper.Replace(F.Name)))
+		H.Set("Content-Type", ContentType)
+
+		if _, err := M.CreatePart(H); err != nil {
+			return nil, 0, "", err
+		}
+
+		next()
+		Parts = append(Parts, bytes.NewReader(F.Data))
+		Size += int64(len(F.Data))
+	}
+
+	if err := M.Close(); err != nil {
+		return nil, 0, "", err
+	}
+
+	next()
+
+	return io.MultiReader(Parts...), Size, M.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"", "\r", "", "\n", "")
+
+func (P *Account) do(Template Request) (string, error) {
+
+	if body, ok := P.cached(Template.Endpoint, Template.Params, Template.Method); ok {
+		return body, nil
+	}
+
+	Policy := P.retryPolicy()
+
+	for Attempt := 1; ; Attempt++ {
+
+		R := Template
+		R.Attempt = Attempt
+
+		resp, err := P.send(&R)
+
+		if err == nil {
+			P.updateCache(R.Endpoint, R.Params, R.Method, string(resp.Body))
+			return string(resp.Body), nil
+		}
+
+		if Attempt >= Policy.MaxAttempts || !Policy.Retriable(R.Endpoint, R.Method, err) {
+			return "", err
+		}
+
+		Delay := Policy.Backoff(Attempt, resp.httpResponse())
+
+		if Policy.OnRetry != nil {
+			Policy.OnRetry(RetryEvent{Endpoint: R.Endpoint, Method: R.Method, Attempt: Attempt, Delay: Delay, Err: err})
+		}
+
+		time.Sleep(Delay)
+	}
+}
+
+// send makes a single attempt wrapped in P's middleware. The response is returned alongside an
+// APIError so its headers can still be inspected, it is nil when nothing came back.
+func (P *Account) send(R *Request) (*Response, error) {
+
+	R.Header = http.Header{}
+	R.Values = make(map[string]interface{})
+	R.Start = time.Now()
+
+	for _, M := range P.Middleware {
+		if M.BeforeRequest != nil {
+			if err := M.BeforeRequest(R); err != nil {
+				err = &CanceledError{Err: err}
+				P.onError(R, nil, err)
+				return nil, err
+			}
+		}
+	}
+
+	resp, err := P.roundTrip(R)
+
+	if resp != nil {
+		for i := len(P.Middleware) - 1; i >= 0; i-- {
+			if M := P.Middleware[i]; M.AfterResponse != nil {
+				M.AfterResponse(R, resp)
+			}
+		}
+	}
+
+	if err != nil {
+		P.onError(R, resp, err)
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func (P *Account) onError(R *Request, resp *Response, err error) {
+
+	for i := len(P.Middleware) - 1; i >= 0; i-- {
+		if M := P.Middleware[i]; M.OnError != nil {
+			M.OnError(R, resp, err)
+		}
+	}
+}
+
+// credentials signs with the account's own keys, falling back to the ones set by the last Auth call
+func (P *Account) credentials() (*oauth.Client, *oauth.Credentials) {
+
+	Client := oauthClient
+	Token := token
+
+	if P.ConsumerKey != "" {
+		Client.Credentials = oauth.Credentials{Token: P.ConsumerKey, Secret: P.ConsumerSecret}
+	}
+
+	if P.AccessToken != "" {
+		Token = &oauth.Credentials{Token: P.AccessToken, Secret: P.AccessSecret}
+	}
+
+	return &Client, Token
+}
+
+func (P *Account) roundTrip(R *Request) (*Response, error) {
+
+	var body io.Reader
+	var ContentType string
+
+	Signed := R.Params
+
+	switch {
+	case len(R.Files) > 0:
+		Form, Size, Type, err := multipartBody(R.Params, R.Files)
+
+		if err != nil {
+			return nil, err
+		}
+
+		R.BodySize = Size
+		body = Form
+		ContentType = Type
+		Signed = nil
+	case R.Body != nil:
+		R.BodySize = int64(len(R.Body))
+		body = bytes.NewReader(R.Body)
+	case R.Method == "POST":
+		encoded := R.Params.Encode()
+		R.BodySize = int64(len(encoded))
+		body = strings.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(R.Method, R.Endpoint, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range R.Header {
+		req.Header[k] = v
+	}
+
+	Client, Token := P.credentials()
+
+	if err := Client.SetAuthorizationHeader(req.Header, Token, R.Method, req.URL, Signed); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(R.Files) > 0:
+		//net/http only knows the length of its own reader types, the form would go out chunked
+		req.ContentLength = R.BodySize
+		req.Header.Set("Content-Type", ContentType)
+	case R.Body != nil:
+		req.URL.RawQuery = R.Params.Encode()
+		req.Header.Set("Content-Type", R.ContentType)
+	case R.Method == "GET":
+		req.URL.RawQuery = R.Params.Encode()
+	default:
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := HTTPClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	Res := &Response{StatusCode: resp.StatusCode, Header: resp.Header}
+
+	Res.Body, err = ioutil.ReadAll(resp.Body)
+	Res.Duration = time.Since(R.Start)
+
+	if err != nil {
+		return Res, err
+	}
+
+	if resp.StatusCode >= 400 {
+		E := newAPIError(resp.StatusCode, Res.Body)
+		E.Header = resp.Header
+		return Res, E
+	}
+
+	return Res, nil
+}
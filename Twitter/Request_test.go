@@ -0,0 +1,216 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestMultipartBody(t *testing.T) {
+
+	PNG := testImage(t, 2, 2, "png", false)
+
+	tests := []struct {
+		name   string
+		params url.Values
+		file   FormFile
+		fields map[string]string
+		header string
+		sniff  string
+	}{
+		{"sniffed type", url.Values{"tile": {"true"}}, FormFile{Field: "image", Name: "a.png", Data: PNG}, map[string]string{"tile": "true"}, `form-data; name="image"; filename="a.png"`, "image/png"},
+		{"given type", nil, FormFile{Field: "media", Name: "clip", ContentType: "video/mp4", Data: []byte("data")}, map[string]string{}, `form-data; name="media"; filename="clip"`, "video/mp4"},
+		{"escaped name", url.Values{"a": {"1"}, "b": {"2"}}, FormFile{Field: "banner", Name: "my \"best\"\r\n.txt", Data: []byte("text")}, map[string]string{"a": "1", "b": "2"}, `form-data; name="banner"; filename="my \"best\".txt"`, "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Form, Size, ContentType, err := multipartBody(tt.params, []FormFile{tt.file})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Body, err := ioutil.ReadAll(Form)
+
+			if err != nil || int64(len(Body)) != Size {
+				t.Fatalf("read %d bytes, %v, want the %d announced", len(Body), err, Size)
+			}
+
+			_, MediaParams, err := mime.ParseMediaType(ContentType)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			R := multipart.NewReader(bytes.NewReader(Body), MediaParams["boundary"])
+			Fields := map[string]string{}
+
+			for {
+
+				Part, err := R.NextPart()
+
+				if err == io.EOF {
+					break
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				Data, _ := ioutil.ReadAll(Part)
+
+				if Part.FileName() == "" {
+					Fields[Part.FormName()] = string(Data)
+					continue
+				}
+
+				if H := Part.Header.Get("Content-Disposition"); H != tt.header || !bytes.Equal(Data, tt.file.Data) || !strings.HasPrefix(Part.Header.Get("Content-Type"), tt.sniff) {
+					t.Errorf("file part %s %s with %d bytes, want %s %s", H, Part.Header.Get("Content-Type"), len(Data), tt.header, tt.sniff)
+				}
+			}
+
+			if len(Fields) != len(tt.fields) {
+				t.Errorf("fields %v, want %v", Fields, tt.fields)
+			}
+
+			for k, v := range tt.fields {
+				if Fields[k] != v {
+					t.Errorf("field %s = %q, want %q", k, Fields[k], v)
+				}
+			}
+		})
+	}
+}
+
+// roundTripper lets a test look at the requests going to the fake server
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestDoMultipart(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	var Sent *http.Request
+
+	HTTPClient = &http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		Sent = r
+		return S.Client().Transport.RoundTrip(r)
+	})}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		params   url.Values
+		files    []FormFile
+		path     string
+		fails    bool
+	}{
+		{"params next to the file", ENDPOINT.UpdateBanner, url.Values{"width": {"1500"}, "offset_top": {"a b&c"}}, []FormFile{{Field: "banner", Name: "b.png", Data: testImage(t, 1500, 500, "png", false)}}, "account/update_profile_banner.json", false},
+		{"file only", ENDPOINT.MediaUpload, nil, []FormFile{{Field: "media", Name: "m.png", Data: testImage(t, 10, 10, "png", false)}}, "media/upload.json", false},
+		{"no files", ENDPOINT.MediaUpload, nil, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Sent = nil
+
+			_, err := A.DoMultipart(tt.endpoint, tt.params, tt.files...)
+
+			if (err != nil) != tt.fails {
+				t.Fatalf("got %v, want failure %v", err, tt.fails)
+			}
+
+			if tt.fails {
+				if Sent != nil {
+					t.Error("a request was sent")
+				}
+				return
+			}
+
+			//the fake checks the signature, so getting here means only the oauth_* parameters were signed
+			if Sent.ContentLength <= 0 || len(Sent.TransferEncoding) > 0 || !strings.HasPrefix(Sent.Header.Get("Content-Type"), "multipart/form-data; boundary=") {
+				t.Errorf("sent length %d, encoding %v, type %s", Sent.ContentLength, Sent.TransferEncoding, Sent.Header.Get("Content-Type"))
+			}
+
+			R := S.Requests()
+			Last := R[len(R)-1]
+
+			if Last.Path != tt.path {
+				t.Errorf("server got %s, want %s", Last.Path, tt.path)
+			}
+
+			for k := range tt.params {
+				if Last.Params.Get(k) != tt.params.Get(k) {
+					t.Errorf("server got %s=%q, want %q", k, Last.Params.Get(k), tt.params.Get(k))
+				}
+			}
+		})
+	}
+}
+
+func TestChunkedMultipartRefused(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	Form, _, ContentType, err := multipartBody(nil, []FormFile{{Field: "media", Name: "m", Data: []byte("data")}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Body, _ := ioutil.ReadAll(Form)
+
+	tests := []struct {
+		name   string
+		body   io.Reader
+		status int
+	}{
+		//a reader of unknown length makes net/http send the body chunked
+		{"chunked", io.MultiReader(bytes.NewReader(Body)), http.StatusLengthRequired},
+		{"with a length", bytes.NewReader(Body), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r, err := http.NewRequest("POST", S.URL+"/1.1/media/upload.json", tt.body)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.Header.Set("Content-Type", ContentType)
+
+			resp, err := S.Client().Do(r)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp.Body.Close()
+
+			//without OAuth the request that gets past the length check is refused as unauthenticated
+			if resp.StatusCode != tt.status {
+				t.Errorf("got %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
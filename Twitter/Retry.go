@@ -0,0 +1,182 @@
+package TwitterAPI
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy decides which failed requests DoRequest sends again and how long it waits in between.
+//
+// GETs are always safe to repeat. POSTs are only retried when their endpoint is listed in
+// POSTEndpoints, because a POST that timed out may still have been applied.
+type RetryPolicy struct {
+	// MaxAttempts counts the first try, 1 disables retrying
+	MaxAttempts int
+
+	// BaseDelay is doubled after every attempt up to MaxDelay, each wait is jittered by up to half
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Statuses are the HTTP statuses worth retrying, Codes the Twitter error codes. Network errors
+	// (timeouts, dropped connections) are always retried. Local failures, like a request that
+	// couldn't be signed, and calls held back by a Pool never are.
+	Statuses []int
+	Codes    []int
+
+	// POSTEndpoints opts POSTs in, e.g. []string{ENDPOINT.Tweet, ENDPOINT.FollowUser}
+	POSTEndpoints []string
+
+	// OnRetry is called before each wait
+	OnRetry func(RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is about to be retried
+type RetryEvent struct {
+	Endpoint string
+	Method   string
+	Attempt  int
+	Delay    time.Duration
+	Err      error
+}
+
+// DefaultRetryPolicy retries GETs on 5xx responses and Twitter's "over capacity" (130) and "internal error" (131)
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	Statuses:    []int{500, 502, 503, 504},
+	Codes:       []int{130, 131},
+}
+
+// NoRetry sends every request exactly once
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+func (P *Account) retryPolicy() *RetryPolicy {
+
+	if P.Retry != nil {
+		return P.Retry
+	}
+
+	return &DefaultRetryPolicy
+}
+
+// Retriable reports whether a request that failed with err may be sent again
+func (R *RetryPolicy) Retriable(Endpoint, Method string, err error) bool {
+
+	if Method != "GET" && !R.allowsPOST(Endpoint) {
+		return false
+	}
+
+	var Canceled *CanceledError
+
+	if errors.As(err, &Canceled) {
+		return false
+	}
+
+	E, ok := err.(*APIError)
+
+	if !ok {
+		return networkError(err)
+	}
+
+	for _, s := range R.Statuses {
+		if E.StatusCode == s {
+			return true
+		}
+	}
+
+	return IsErrorCode(err, R.Codes...)
+}
+
+// networkError reports whether err came from the connection rather than from building the request,
+// only those may go away by themselves. net/http wraps every failure in a *url.Error, so the decision
+// is made on what it wraps: a canceled context or a certificate that doesn't verify stays failed.
+func networkError(err error) bool {
+
+	var URLErr *url.Error
+
+	if errors.As(err, &URLErr) {
+		err = URLErr.Err
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var NetErr net.Error
+
+	return errors.As(err, &NetErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (R *RetryPolicy) allowsPOST(Endpoint string) bool {
+
+	for _, e := range R.POSTEndpoints {
+		if matchEndpoint(e, Endpoint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchEndpoint compares an ENDPOINT value, which may contain :id, with the URL actually requested
+func matchEndpoint(Pattern, Endpoint string) bool {
+
+	i := strings.Index(Pattern, ":id")
+
+	if i < 0 {
+		return Pattern == Endpoint
+	}
+
+	prefix, suffix := Pattern[:i], Pattern[i+len(":id"):]
+
+	return len(Endpoint) > len(prefix)+len(suffix) && strings.HasPrefix(Endpoint, prefix) && strings.HasSuffix(Endpoint, suffix)
+}
+
+// Backoff is how long to wait after the given attempt failed. A Retry-After or x-rate-limit-reset
+// header on resp stretches the wait, MaxDelay always caps it.
+func (R *RetryPolicy) Backoff(Attempt int, resp *http.Response) time.Duration {
+
+	Delay := R.BaseDelay
+
+	for i := 1; i < Attempt && Delay < R.MaxDelay; i++ {
+		Delay *= 2
+	}
+
+	if R.MaxDelay > 0 && Delay > R.MaxDelay {
+		Delay = R.MaxDelay
+	}
+
+	if Delay > 0 {
+		Delay = Delay/2 + time.Duration(rand.Int63n(int64(Delay/2)+1))
+	}
+
+	if resp != nil {
+
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(s)*time.Second > Delay {
+			Delay = time.Duration(s) * time.Second
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if reset, err := strconv.ParseInt(resp.Header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+				if wait := time.Until(time.Unix(reset, 0)); wait > Delay {
+					Delay = wait
+				}
+			}
+		}
+	}
+
+	if R.MaxDelay > 0 && Delay > R.MaxDelay {
+		Delay = R.MaxDelay
+	}
+
+	return Delay
+}
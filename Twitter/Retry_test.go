@@ -0,0 +1,144 @@
+package TwitterAPI
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestRetry(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		path     string
+		fault    TwitterTest.Fault
+		times    int
+		posts    []string
+		tweet    bool
+		attempts int
+		fails    bool
+	}{
+		{"GET 503 once", "statuses/home_timeline.json", TwitterTest.Fault{Status: 503}, 1, nil, false, 2, false},
+		{"GET 503 every time", "statuses/home_timeline.json", TwitterTest.Fault{Status: 503}, 5, nil, false, 3, true},
+		{"GET over capacity code", "statuses/home_timeline.json", TwitterTest.Fault{Status: 400, Code: 130}, 1, nil, false, 2, false},
+		{"GET 404", "statuses/home_timeline.json", TwitterTest.Fault{Status: 404, Code: 34}, 1, nil, false, 1, true},
+		{"GET dropped connection", "statuses/home_timeline.json", TwitterTest.Fault{Drop: true}, 1, nil, false, 2, false},
+		{"POST not opted in", "statuses/update.json", TwitterTest.Fault{Status: 503}, 1, nil, true, 1, true},
+		{"POST opted in", "statuses/update.json", TwitterTest.Fault{Status: 503}, 1, []string{ENDPOINT.Tweet}, true, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			S := TwitterTest.NewServer("ck", "cs")
+			defer S.Close()
+
+			T := login(t, S, "gopher")
+
+			Attempts := 1
+
+			T.Retry = &RetryPolicy{
+				MaxAttempts:   3,
+				BaseDelay:     time.Millisecond,
+				MaxDelay:      5 * time.Millisecond,
+				Statuses:      DefaultRetryPolicy.Statuses,
+				Codes:         DefaultRetryPolicy.Codes,
+				POSTEndpoints: tt.posts,
+				OnRetry:       func(RetryEvent) { Attempts++ },
+			}
+
+			S.InjectFault(tt.path, tt.fault, tt.times)
+
+			var err error
+
+			if tt.tweet {
+				_, err = T.DoRequest(ENDPOINT.Tweet, url.Values{"status": {"retry"}}, "POST")
+			} else {
+				_, err = T.GetHomeTimeline("")
+			}
+
+			if (err != nil) != tt.fails {
+				t.Errorf("got error %v, want failure %v", err, tt.fails)
+			}
+
+			if Attempts != tt.attempts {
+				t.Errorf("made %d attempts, want %d", Attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetriable(t *testing.T) {
+
+	Policy := &DefaultRetryPolicy
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"5xx", &APIError{StatusCode: 503}, true},
+		{"internal error code", &APIError{StatusCode: 400, Code: 131}, true},
+		{"4xx", &APIError{StatusCode: 403, Code: 187}, false},
+		{"connection reset", &url.Error{Op: "Get", URL: "https://api.twitter.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}, true},
+		{"connection closed", &url.Error{Op: "Get", URL: "https://api.twitter.com", Err: io.EOF}, true},
+		{"timeout", &url.Error{Op: "Get", URL: "https://api.twitter.com", Err: context.DeadlineExceeded}, true},
+		{"context canceled", &url.Error{Op: "Get", URL: "https://api.twitter.com", Err: context.Canceled}, false},
+		{"untrusted certificate", &url.Error{Op: "Get", URL: "https://api.twitter.com", Err: x509.UnknownAuthorityError{}}, false},
+		{"wrong host certificate", &url.Error{Op: "Get", URL: "https://api.twitter.com", Err: x509.HostnameError{Host: "api.twitter.com", Certificate: &x509.Certificate{}}}, false},
+		{"other url error", &url.Error{Op: "Get", URL: "https://api.twitter.com", Err: errors.New("unsupported protocol scheme")}, false},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"local error", errors.New("Status and MediaID cannot both be empty"), false},
+		{"canceled", &CanceledError{Err: &url.Error{Op: "Get", Err: io.EOF}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Policy.Retriable(ENDPOINT.HomeTimeline, "GET", tt.err); got != tt.want {
+				t.Errorf("Retriable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+
+	Policy := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+	tests := []struct {
+		name     string
+		attempt  int
+		header   http.Header
+		min, max time.Duration
+	}{
+		{"first attempt", 1, nil, 50 * time.Millisecond, 100 * time.Millisecond},
+		{"doubles", 3, nil, 200 * time.Millisecond, 400 * time.Millisecond},
+		{"capped", 10, nil, time.Second, 2 * time.Second},
+		{"retry after", 1, http.Header{"Retry-After": {"1"}}, time.Second, time.Second},
+		{"retry after capped", 1, http.Header{"Retry-After": {"60"}}, 2 * time.Second, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var resp *http.Response
+
+			if tt.header != nil {
+				resp = &http.Response{StatusCode: 503, Header: tt.header}
+			}
+
+			if got := Policy.Backoff(tt.attempt, resp); got < tt.min || got > tt.max {
+				t.Errorf("Backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SavedSearch is a query saved from the Twitter search page, an account can keep up to 25
+type SavedSearch struct {
+	ID        int64  `json:"id"`
+	IDStr     string `json:"id_str"`
+	Name      string `json:"name"`
+	Query     string `json:"query"`
+	Position  *int   `json:"position"`
+	CreatedAt string `json:"created_at"`
+}
+
+func savedSearchArg(ID string) (string, error) {
+
+	if !numericID.MatchString(ID) {
+		return "", fmt.Errorf("Invalid saved search ID %q", ID)
+	}
+
+	return ID, nil
+}
+
+// SavedSearches lists the account's saved searches
+func (P *Account) SavedSearches() (string, error) {
+
+	var Params = url.Values{}
+
+	return P.DoRequest(ENDPOINT.SavedSearches, Params, "GET")
+}
+
+func (P *Account) SavedSearchShow(ID string) (string, error) {
+
+	ID, err := savedSearchArg(ID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
+
+	return P.DoRequest(strings.Replace(ENDPOINT.SavedSearchShow, ":id", ID, -1), Params, "GET")
+}
+
+func (P *Account) SavedSearchCreate(Query string) (string, error) {
+
+	if strings.TrimSpace(Query) == "" {
+		return "", errors.New("Search query cannot be empty")
+	}
+
+	if err := checkQueryLength(Query); err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
+
+	Params.Add("query", Query)
+
+	return P.DoRequest(ENDPOINT.SavedSearchCreate, Params, "POST")
+}
+
+func (P *Account) SavedSearchDestroy(ID string) (string, error) {
+
+	ID, err := savedSearchArg(ID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
+
+	Params.Add("id", ID)
+
+	return P.DoRequest(strings.Replace(ENDPOINT.SavedSearchDestroy, ":id", ID, -1), Params, "POST")
+}
+
+// ListSavedSearches returns the saved searches decoded
+func (P *Account) ListSavedSearches() ([]SavedSearch, error) {
+
+	resp, err := P.SavedSearches()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var Searches []SavedSearch
+
+	return Searches, Decode(resp, &Searches)
+}
+
+// RunSavedSearches runs every saved search with the other options in O and returns the tweets found,
+// newest first. A tweet matched by several searches is only returned once.
+func (P *Account) RunSavedSearches(O SearchOptions) ([]Tweet, error) {
+
+	Searches, err := P.ListSavedSearches()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var Tweets []Tweet
+	seen := make(map[string]bool)
+
+	for _, S := range Searches {
+
+		O.Query = S.Query
+
+		resp, err := P.SearchTweets(O)
+
+		if err != nil {
+			return Tweets, fmt.Errorf("Saved search %q: %w", S.Name, err)
+		}
+
+		var Result struct {
+			Statuses []Tweet `json:"statuses"`
+		}
+
+		if err := Decode(resp, &Result); err != nil {
+			return Tweets, fmt.Errorf("Saved search %q: %w", S.Name, err)
+		}
+
+		for _, T := range Result.Statuses {
+			if !seen[T.IDStr] {
+				seen[T.IDStr] = true
+				Tweets = append(Tweets, T)
+			}
+		}
+	}
+
+	sort.SliceStable(Tweets, func(i, j int) bool { return Tweets[i].ID > Tweets[j].ID })
+
+	return Tweets, nil
+}
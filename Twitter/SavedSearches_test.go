@@ -0,0 +1,133 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestSavedSearches(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+	Other := login(t, S, "other")
+
+	resp, err := A.SavedSearchCreate("golang")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ID := idOf(t, resp)
+
+	tests := []struct {
+		name  string
+		call  func() (string, error)
+		code  int
+		fails bool
+	}{
+		{"same query again", func() (string, error) { return A.SavedSearchCreate("golang") }, 0, false},
+		{"show", func() (string, error) { return A.SavedSearchShow(ID) }, 0, false},
+		{"someone else's", func() (string, error) { return Other.SavedSearchShow(ID) }, 34, true},
+		{"bad id", func() (string, error) { return A.SavedSearchShow("12a") }, 0, true},
+		{"destroy bad id", func() (string, error) { return A.SavedSearchDestroy("../1") }, 0, true},
+		{"destroy", func() (string, error) { return A.SavedSearchDestroy(ID) }, 0, false},
+		{"destroyed", func() (string, error) { return A.SavedSearchShow(ID) }, 34, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			resp, err := tt.call()
+
+			if (err != nil) != tt.fails {
+				t.Fatalf("got %v, want failure %v", err, tt.fails)
+			}
+
+			var E *APIError
+
+			if tt.code != 0 && (!errors.As(err, &E) || E.Code != tt.code) {
+				t.Errorf("got %v, want code %d", err, tt.code)
+			}
+
+			if err == nil && idOf(t, resp) != ID {
+				t.Errorf("got %s, want saved search %s", resp, ID)
+			}
+		})
+	}
+
+	Before := len(S.Requests())
+
+	for _, Query := range []string{"", " \t\n"} {
+		if _, err := A.SavedSearchCreate(Query); err == nil {
+			t.Errorf("saved the empty query %q", Query)
+		}
+	}
+
+	if n := len(S.Requests()) - Before; n != 0 {
+		t.Errorf("%d requests for empty queries, want them refused before sending", n)
+	}
+
+	for i := 0; i < 25; i++ {
+		if _, err := A.SavedSearchCreate("query " + strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var E *APIError
+
+	if _, err := A.SavedSearchCreate("one too many"); !errors.As(err, &E) || E.Code != 172 {
+		t.Errorf("got %v, want code 172", err)
+	}
+
+	if Searches, err := A.ListSavedSearches(); err != nil || len(Searches) != 25 || Searches[0].Query != "query 0" {
+		t.Errorf("got %d searches, %v", len(Searches), err)
+	}
+}
+
+func TestRunSavedSearches(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+	Bob := login(t, S, "bob")
+
+	for _, Status := range []string{"golang generics", "rust traits", "golang and rust", "nothing"} {
+		if _, err := Bob.Tweet(Status, "", "", false, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, Query := range []string{"golang", "rust"} {
+		if _, err := A.SavedSearchCreate(Query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Tweets, err := A.RunSavedSearches(SearchOptions{ResultType: Recent})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var Texts []string
+
+	for _, T := range Tweets {
+		Texts = append(Texts, T.Text)
+	}
+
+	//the tweet found by both searches comes back once, everything newest first
+	if want := []string{"golang and rust", "rust traits", "golang generics"}; !reflect.DeepEqual(Texts, want) {
+		t.Errorf("got %q, want %q", Texts, want)
+	}
+
+	if _, err := A.RunSavedSearches(SearchOptions{Count: 500}); err == nil {
+		t.Error("RunSavedSearches ran with a bad count")
+	}
+}
@@ -0,0 +1,478 @@
+package TwitterAPI
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antonholmquist/jason"
+)
+
+// QueueState is where a ScheduledTweet is in its life
+type QueueState string
+
+const (
+	Pending QueueState = "pending"
+	// Publishing is persisted right before the tweet is sent, finding it on startup means the
+	// process stopped mid-publish and the timeline has to be checked before sending again
+	Publishing QueueState = "publishing"
+	Published  QueueState = "published"
+	Failed     QueueState = "failed"
+	Canceled   QueueState = "canceled"
+)
+
+// ScheduledTweet is a queued post and, once handled, its outcome
+type ScheduledTweet struct {
+	ID string    `json:"id"`
+	At time.Time `json:"at"`
+
+	Status             string   `json:"status"`
+	MediaPaths         []string `json:"media_paths,omitempty"`
+	ReplyTo            string   `json:"reply_to,omitempty"`
+	PossiblySensitive  bool     `json:"possibly_sensitive,omitempty"`
+	DisplayCoordinates bool     `json:"display_coordinates,omitempty"`
+
+	State    QueueState `json:"state"`
+	Attempts int        `json:"attempts"`
+	// MediaIDs are kept once uploaded so a retry doesn't upload the files again
+	MediaIDs    []string  `json:"media_ids,omitempty"`
+	TweetID     string    `json:"tweet_id,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// QueueStore persists the whole queue, implementations must be safe for concurrent use
+type QueueStore interface {
+	Load() ([]ScheduledTweet, error)
+	Save(Queue []ScheduledTweet) error
+}
+
+// FileQueue is a QueueStore keeping the queue as a JSON file
+type FileQueue struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewFileQueue returns a store at Path, the file is created on the first Save
+func NewFileQueue(Path string) *FileQueue {
+	return &FileQueue{Path: Path}
+}
+
+func (Q *FileQueue) Load() ([]ScheduledTweet, error) {
+
+	Q.mu.Lock()
+	defer Q.mu.Unlock()
+
+	data, err := ioutil.ReadFile(Q.Path)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var Queue []ScheduledTweet
+
+	if err := json.Unmarshal(data, &Queue); err != nil {
+		return nil, fmt.Errorf("Tweet queue %s is corrupt: %v", Q.Path, err)
+	}
+
+	return Queue, nil
+}
+
+func (Q *FileQueue) Save(Queue []ScheduledTweet) error {
+
+	Q.mu.Lock()
+	defer Q.mu.Unlock()
+
+	data, err := json.MarshalIndent(Queue, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(Q.Path, data, 0600)
+}
+
+// Scheduler publishes queued tweets from Account when they are due.
+//
+// Every state change is saved before the next step is taken, so a restarted scheduler picks up
+// where the last one stopped. Tweets are sent through the same duplicate detection as
+// Account.IdempotentTweets, a post that may or may not have gone out is looked up on the
+// timeline instead of being sent twice.
+type Scheduler struct {
+	Account *Account
+	Store   QueueStore
+
+	// PollInterval is how often Run looks for due tweets, 30 seconds when zero
+	PollInterval time.Duration
+
+	// MaxAttempts is how many times a tweet is tried before it is marked Failed, 5 when zero.
+	// Only transient failures (network errors, 5xx, over capacity, rate limits) are retried.
+	MaxAttempts int
+
+	// RetryDelay is doubled after every failed attempt, a minute when zero
+	RetryDelay time.Duration
+
+	// OnResult is called whenever a tweet is published or gives up
+	OnResult func(ScheduledTweet)
+
+	// mu guards the load-modify-save cycle on Store, running keeps publishers from overlapping
+	mu      sync.Mutex
+	running sync.Mutex
+}
+
+// NewScheduler returns a scheduler publishing from A with the queue kept in Store
+func NewScheduler(A *Account, Store QueueStore) *Scheduler {
+	return &Scheduler{Account: A, Store: Store}
+}
+
+// Schedule queues T for T.At, or for now when At is zero, and returns it with its ID filled in
+func (S *Scheduler) Schedule(T ScheduledTweet) (ScheduledTweet, error) {
+
+	if T.Status == "" && len(T.MediaPaths) == 0 {
+		return T, errors.New("Status and MediaPaths cannot both be empty")
+	}
+
+	for _, Path := range T.MediaPaths {
+		if _, err := os.Stat(Path); err != nil {
+			return T, err
+		}
+	}
+
+	id := make([]byte, 8)
+
+	if _, err := rand.Read(id); err != nil {
+		return T, err
+	}
+
+	T.ID = hex.EncodeToString(id)
+	T.State = Pending
+	T.Attempts = 0
+	T.MediaIDs, T.TweetID, T.Error = nil, "", ""
+
+	if T.At.IsZero() {
+		T.At = time.Now()
+	}
+
+	err := S.update(func(Queue []ScheduledTweet) ([]ScheduledTweet, error) {
+		return append(Queue, T), nil
+	})
+
+	return T, err
+}
+
+// Cancel takes a pending tweet off the queue, it stays in the store as Canceled
+func (S *Scheduler) Cancel(ID string) error {
+	return S.update(func(Queue []ScheduledTweet) ([]ScheduledTweet, error) {
+
+		for i := range Queue {
+
+			if Queue[i].ID != ID {
+				continue
+			}
+
+			if Queue[i].State != Pending {
+				return nil, fmt.Errorf("Tweet %s is %s and can't be canceled", ID, Queue[i].State)
+			}
+
+			Queue[i].State = Canceled
+
+			return Queue, nil
+		}
+
+		return nil, fmt.Errorf("No queued tweet with ID %s", ID)
+	})
+}
+
+// Queue returns every stored tweet ordered by time
+func (S *Scheduler) Queue() ([]ScheduledTweet, error) {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	Queue, err := S.Store.Load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(Queue, func(i, j int) bool { return Queue[i].At.Before(Queue[j].At) })
+
+	return Queue, nil
+}
+
+// update runs f on the stored queue and saves what it returns
+func (S *Scheduler) update(f func([]ScheduledTweet) ([]ScheduledTweet, error)) error {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	Queue, err := S.Store.Load()
+
+	if err != nil {
+		return err
+	}
+
+	if Queue, err = f(Queue); err != nil {
+		return err
+	}
+
+	return S.Store.Save(Queue)
+}
+
+// errSuperseded is returned by set when the stored tweet changed under the publisher, like a
+// tweet canceled while its media was uploading
+var errSuperseded = errors.New("Queued tweet changed while it was being published")
+
+// set saves T over the stored tweet with the same ID, as long as that one is still in state From
+func (S *Scheduler) set(T ScheduledTweet, From QueueState) error {
+	return S.update(func(Queue []ScheduledTweet) ([]ScheduledTweet, error) {
+
+		for i := range Queue {
+
+			if Queue[i].ID != T.ID {
+				continue
+			}
+
+			if Queue[i].State != From {
+				return nil, errSuperseded
+			}
+
+			Queue[i] = T
+
+			return Queue, nil
+		}
+
+		return nil, fmt.Errorf("No queued tweet with ID %s", T.ID)
+	})
+}
+
+// Run publishes due tweets until ctx is canceled
+func (S *Scheduler) Run(ctx context.Context) error {
+
+	Interval := S.PollInterval
+
+	if Interval <= 0 {
+		Interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := S.PublishDue(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue publishes every tweet whose time has come, including ones a previous process left
+// in Publishing. The error is only about the store, publishing failures are recorded on the tweets.
+// A tweet canceled after the queue was read is left alone, every state change checks the stored
+// state first.
+func (S *Scheduler) PublishDue() error {
+
+	S.running.Lock()
+	defer S.running.Unlock()
+
+	Queue, err := S.Queue()
+
+	if err != nil {
+		return err
+	}
+
+	Now := time.Now()
+
+	for _, T := range Queue {
+
+		if (T.State == Pending || T.State == Publishing) && !T.At.After(Now) {
+			if err := S.publish(T); err != nil && err != errSuperseded {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (T ScheduledTweet) params() (url.Values, error) {
+	return tweetParams(T.Status, T.ReplyTo, strings.Join(T.MediaIDs, ","), T.PossiblySensitive, T.DisplayCoordinates)
+}
+
+func (S *Scheduler) publish(T ScheduledTweet) error {
+
+	T.Attempts++
+
+	//an earlier attempt got as far as sending, the tweet may already be out
+	if T.State == Publishing {
+
+		if Params, err := T.params(); err == nil {
+
+			found, err := S.Account.findPublished(Params)
+
+			if err != nil {
+				return S.failed(T, err)
+			}
+
+			if found != "" {
+				return S.published(T, found)
+			}
+		}
+	}
+
+	//checked before uploading anything, media attached to a rejected tweet would be wasted
+	if err := S.Account.CheckTweetLength(T.Status); err != nil {
+		return S.failed(T, err)
+	}
+
+	for i := len(T.MediaIDs); i < len(T.MediaPaths); i++ {
+
+		if _, err := os.Stat(T.MediaPaths[i]); err != nil {
+			return S.failed(T, err)
+		}
+
+		ID, err := S.Account.MediaUpload(T.MediaPaths[i], false)
+
+		if err != nil {
+			return S.failed(T, err)
+		}
+
+		T.MediaIDs = append(T.MediaIDs, ID)
+	}
+
+	Params, err := T.params()
+
+	if err != nil {
+		return S.failed(T, err)
+	}
+
+	From := T.State
+	T.State = Publishing
+
+	if err := S.set(T, From); err != nil {
+		return err
+	}
+
+	resp, err := S.Account.tweetIdempotent(Params)
+
+	if err != nil {
+		return S.failed(T, err)
+	}
+
+	return S.published(T, resp)
+}
+
+func (S *Scheduler) published(T ScheduledTweet, Resp string) error {
+
+	From := T.State
+	T.State = Published
+	T.PublishedAt = time.Now()
+	T.Error = ""
+
+	if v, err := jason.NewObjectFromBytes([]byte(Resp)); err == nil {
+		T.TweetID, _ = v.GetString("id_str")
+	}
+
+	if err := S.set(T, From); err != nil {
+		return err
+	}
+
+	if S.OnResult != nil {
+		S.OnResult(T)
+	}
+
+	return nil
+}
+
+// failed reschedules T after a transient error, or gives up on it
+func (S *Scheduler) failed(T ScheduledTweet, Err error) error {
+
+	MaxAttempts := S.MaxAttempts
+
+	if MaxAttempts <= 0 {
+		MaxAttempts = 5
+	}
+
+	From := T.State
+	T.Error = Err.Error()
+
+	if transient(Err) && T.Attempts < MaxAttempts {
+
+		Delay := S.RetryDelay
+
+		if Delay <= 0 {
+			Delay = time.Minute
+		}
+
+		for i := 1; i < T.Attempts; i++ {
+			Delay *= 2
+		}
+
+		var Held *RateLimitError
+
+		if errors.As(Err, &Held) && time.Until(Held.Reset) > Delay {
+			Delay = time.Until(Held.Reset)
+		}
+
+		//a tweet that may have been sent stays Publishing so the next attempt checks the timeline first
+		if T.State != Publishing {
+			T.State = Pending
+		}
+
+		T.At = time.Now().Add(Delay)
+
+		return S.set(T, From)
+	}
+
+	T.State = Failed
+
+	if err := S.set(T, From); err != nil {
+		return err
+	}
+
+	if S.OnResult != nil {
+		S.OnResult(T)
+	}
+
+	return nil
+}
+
+// transient reports whether trying again later may work: network errors, 5xx, over capacity
+// and rate limits. Anything else, like a tweet that is too long or an image that isn't one, fails
+// the same way every time.
+func transient(err error) bool {
+
+	var Held *RateLimitError
+
+	if errors.As(err, &Held) {
+		return true
+	}
+
+	E, ok := err.(*APIError)
+
+	if !ok {
+		return networkError(err)
+	}
+
+	return E.StatusCode >= 500 || E.StatusCode == 429 || IsErrorCode(err, 88, 130, 131)
+}
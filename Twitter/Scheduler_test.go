@@ -0,0 +1,194 @@
+package TwitterAPI
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"image"
+	"image/png"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestSchedulerStates(t *testing.T) {
+
+	defer func(Lag time.Duration) { timelineLag = Lag }(timelineLag)
+	timelineLag = time.Millisecond
+
+	tests := []struct {
+		name     string
+		status   string
+		at       time.Duration
+		fault    *TwitterTest.Fault
+		state    QueueState
+		tweets   int
+		retrying bool
+	}{
+		{"published", "hello", 0, nil, Published, 1, false},
+		{"not due", "hello", time.Hour, nil, Pending, 0, false},
+		{"published before the error", "hello", 0, &TwitterTest.Fault{Status: 503, AfterHandler: true}, Published, 1, false},
+		{"server error", "hello", 0, &TwitterTest.Fault{Status: 503}, Publishing, 0, true},
+		{"rate limited", "hello", 0, &TwitterTest.Fault{Status: 429, Code: 88}, Publishing, 0, true},
+		{"rejected", "hello", 0, &TwitterTest.Fault{Status: 403, Code: 326}, Failed, 0, false},
+		{"too long", strings.Repeat("a", 281), 0, nil, Failed, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			S := TwitterTest.NewServer("ck", "cs")
+			defer S.Close()
+
+			Sched := NewScheduler(login(t, S, "gopher"), NewFileQueue(filepath.Join(t.TempDir(), "queue.json")))
+
+			var Results []ScheduledTweet
+			Sched.OnResult = func(T ScheduledTweet) { Results = append(Results, T) }
+
+			if tt.fault != nil {
+				S.InjectFault("statuses/update.json", *tt.fault, 1)
+			}
+
+			Queued, err := Sched.Schedule(ScheduledTweet{Status: tt.status, At: time.Now().Add(tt.at)})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := Sched.PublishDue(); err != nil {
+				t.Fatal(err)
+			}
+
+			Queue, err := Sched.Queue()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			T := Queue[0]
+
+			if T.State != tt.state {
+				t.Errorf("state %s (%s), want %s", T.State, T.Error, tt.state)
+			}
+
+			if n := len(S.Tweets()); n != tt.tweets {
+				t.Errorf("server has %d tweets, want %d", n, tt.tweets)
+			}
+
+			if Retrying := T.At.After(Queued.At); Retrying != tt.retrying {
+				t.Errorf("rescheduled %v, want %v", Retrying, tt.retrying)
+			}
+
+			if tt.state == Published && (len(Results) != 1 || T.TweetID != S.Tweets()[0].IDStr) {
+				t.Errorf("got %+v, results %v", T, Results)
+			}
+		})
+	}
+}
+
+func TestSchedulerResumesPublishing(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	//a previous process sent the tweet and stopped before saving the outcome
+	if _, err := A.Tweet("sent once", "", "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	Store := NewFileQueue(filepath.Join(t.TempDir(), "queue.json"))
+	Store.Save([]ScheduledTweet{{ID: "1", Status: "sent once", At: time.Now(), State: Publishing, Attempts: 1}})
+
+	if err := NewScheduler(A, Store).PublishDue(); err != nil {
+		t.Fatal(err)
+	}
+
+	Queue, _ := Store.Load()
+
+	if Queue[0].State != Published || Queue[0].TweetID != S.Tweets()[0].IDStr || len(S.Tweets()) != 1 {
+		t.Errorf("got %+v with %d tweets, want the existing tweet recorded", Queue[0], len(S.Tweets()))
+	}
+}
+
+func TestSchedulerCancelWhileUploading(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	Image := filepath.Join(t.TempDir(), "a.png")
+
+	f, err := os.Create(Image)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	f.Close()
+
+	A := login(t, S, "gopher")
+	Sched := NewScheduler(A, NewFileQueue(filepath.Join(t.TempDir(), "queue.json")))
+
+	Queued, err := Sched.Schedule(ScheduledTweet{Status: "with media", MediaPaths: []string{Image}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	A.Use(Middleware{BeforeRequest: func(R *Request) error {
+		if R.Endpoint == ENDPOINT.MediaUpload {
+			if err := Sched.Cancel(Queued.ID); err != nil {
+				t.Errorf("Cancel: %v", err)
+			}
+		}
+		return nil
+	}})
+
+	if err := Sched.PublishDue(); err != nil {
+		t.Fatal(err)
+	}
+
+	Queue, _ := Sched.Queue()
+
+	if Queue[0].State != Canceled || len(S.Tweets()) != 0 {
+		t.Errorf("state %s with %d tweets, want it left canceled and unsent", Queue[0].State, len(S.Tweets()))
+	}
+}
+
+func TestTransient(t *testing.T) {
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", &APIError{StatusCode: 502}, true},
+		{"rate limited", &APIError{StatusCode: 429, Code: 88}, true},
+		{"over capacity", &APIError{StatusCode: 403, Code: 130}, true},
+		{"held by a pool", &CanceledError{Err: &RateLimitError{Reset: time.Now()}}, true},
+		{"network", &url.Error{Op: "Post", URL: ENDPOINT.Tweet, Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}, true},
+		{"context canceled", &url.Error{Op: "Post", URL: ENDPOINT.Tweet, Err: context.Canceled}, false},
+		{"bad certificate", &url.Error{Op: "Post", URL: ENDPOINT.Tweet, Err: x509.UnknownAuthorityError{}}, false},
+		{"duplicate", &APIError{StatusCode: 403, Code: 187}, false},
+		{"too long", errors.New("Tweet is 300 characters long, the limit is 280"), false},
+		{"missing file", &os.PathError{Op: "stat", Path: "a.png", Err: os.ErrNotExist}, false},
+		{"canceled", &CanceledError{Err: errors.New("Quota used up")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transient(tt.err); got != tt.want {
+				t.Errorf("transient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxQueryLength is the longest query search/tweets accepts, operators included
+const MaxQueryLength = 500
+
+// ErrQueryTooLong is wrapped by the errors returned for queries over MaxQueryLength
+var ErrQueryTooLong = errors.New("Search query is too long")
+
+// ResultType picks between recent and popular tweets, the API mixes both when it's empty
+type ResultType string
+
+const (
+	Mixed   ResultType = "mixed"
+	Recent  ResultType = "recent"
+	Popular ResultType = "popular"
+)
+
+// SearchOptions are the parameters of search/tweets, everything but Query is optional
+type SearchOptions struct {
+	// Query is the search, use NewQuery to build one with operators
+	Query   string
+	GeoCode string
+	// Lang restricts results to an ISO 639-1 language, Locale is the language of the query itself
+	Lang       string
+	Locale     string
+	ResultType ResultType
+	// Count is the number of tweets per page, up to 100
+	Count int
+	// Until returns tweets created before the given date, the search index only goes back 7 days
+	Until time.Time
+	// SinceID and MaxID take tweet IDs or links
+	SinceID string
+	MaxID   string
+	// ExcludeEntities sends include_entities=false
+	ExcludeEntities bool
+}
+
+// checkQueryLength returns an error wrapping ErrQueryTooLong when Query is over MaxQueryLength
+func checkQueryLength(Query string) error {
+
+	if n := utf8.RuneCountInString(Query); n > MaxQueryLength {
+		return fmt.Errorf("%w, it is %d characters and the limit is %d", ErrQueryTooLong, n, MaxQueryLength)
+	}
+
+	return nil
+}
+
+// Values validates the options and returns them as request parameters
+func (O SearchOptions) Values() (url.Values, error) {
+
+	var Params = url.Values{}
+
+	if strings.TrimSpace(O.Query) == "" {
+		return nil, errors.New("Search query cannot be empty")
+	}
+
+	if err := checkQueryLength(O.Query); err != nil {
+		return nil, err
+	}
+
+	Params.Set("q", O.Query)
+
+	if O.GeoCode != "" {
+		Params.Set("geocode", O.GeoCode)
+	}
+
+	if O.Lang != "" {
+		if !langCode.MatchString(O.Lang) {
+			return nil, fmt.Errorf("Invalid language code %q", O.Lang)
+		}
+		Params.Set("lang", O.Lang)
+	}
+
+	if O.Locale != "" {
+		Params.Set("locale", O.Locale)
+	}
+
+	switch O.ResultType {
+	case "":
+	case Mixed, Recent, Popular:
+		Params.Set("result_type", string(O.ResultType))
+	default:
+		return nil, fmt.Errorf("Invalid result type %q, expected mixed, recent or popular", O.ResultType)
+	}
+
+	if O.Count != 0 {
+		if O.Count < 1 || O.Count > 100 {
+			return nil, fmt.Errorf("Count must be between 1 and 100, got %d", O.Count)
+		}
+		Params.Set("count", strconv.Itoa(O.Count))
+	}
+
+	if !O.Until.IsZero() {
+		Params.Set("until", O.Until.Format("2006-01-02"))
+	}
+
+	SinceID, err := tweetIDArg(O.SinceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	MaxID, err := tweetIDArg(O.MaxID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if SinceID != "" {
+		Params.Set("since_id", SinceID)
+	}
+
+	if MaxID != "" {
+		Params.Set("max_id", MaxID)
+	}
+
+	if O.ExcludeEntities {
+		Params.Set("include_entities", "false")
+	}
+
+	return Params, nil
+}
+
+// SearchTweets runs a search with every search/tweets parameter available
+func (P *Account) SearchTweets(O SearchOptions) (string, error) {
+
+	Params, err := O.Values()
+
+	if err != nil {
+		return "", err
+	}
+
+	return P.DoRequest(ENDPOINT.Search, Params, "GET")
+}
+
+var (
+	langCode   = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z]+)?$`)
+	filterName = regexp.MustCompile(`^[a-z_]+$`)
+
+	// bareWord is a term that can go in a query as is, anything else is quoted
+	bareWord = regexp.MustCompile(`^[#$@]?[\pL\pN_][\pL\pN_'.&+]*$`)
+)
+
+// Query builds a search query out of terms and operators, every method adds to the query and
+// returns it so calls can be chained. Terms are ANDed like the search box does.
+//
+//	q, err := TwitterAPI.NewQuery("golang").
+//		Any("generics", "iterators").
+//		From("golang").
+//		Exclude("retweets").
+//		Lang("en").
+//		Build()
+//
+// gives `golang (generics OR iterators) from:golang -filter:retweets lang:en`.
+// Mistakes like an invalid screen name are kept and returned by Build.
+type Query struct {
+	terms []string
+	err   error
+}
+
+// NewQuery starts a query matching all of Words
+func NewQuery(Words ...string) *Query {
+	return new(Query).Words(Words...)
+}
+
+func (Q *Query) add(Term string) *Query {
+	Q.terms = append(Q.terms, Term)
+	return Q
+}
+
+func (Q *Query) fail(err error) *Query {
+
+	if Q.err == nil {
+		Q.err = err
+	}
+
+	return Q
+}
+
+// quoteTerm returns Word as a single search term, quoting it when it has spaces, operator
+// characters or is one of the OR/AND keywords
+func quoteTerm(Word string) (string, error) {
+
+	if strings.Contains(Word, `"`) {
+		return "", fmt.Errorf("Search terms cannot contain double quotes: %q", Word)
+	}
+
+	if bareWord.MatchString(Word) && Word != "OR" && Word != "AND" {
+		return Word, nil
+	}
+
+	return `"` + Word + `"`, nil
+}
+
+// Words matches tweets containing every word, a word with spaces or punctuation is matched as a phrase
+func (Q *Query) Words(Words ...string) *Query {
+
+	for _, Word := range Words {
+
+		Word = strings.TrimSpace(Word)
+
+		if Word == "" {
+			continue
+		}
+
+		Term, err := quoteTerm(Word)
+
+		if err != nil {
+			return Q.fail(err)
+		}
+
+		Q.add(Term)
+	}
+
+	return Q
+}
+
+// Phrase matches the exact phrase
+func (Q *Query) Phrase(Phrase string) *Query {
+
+	Phrase = strings.Join(strings.Fields(Phrase), " ")
+
+	if Phrase == "" {
+		return Q
+	}
+
+	if strings.Contains(Phrase, `"`) {
+		return Q.fail(fmt.Errorf("Search phrases cannot contain double quotes: %q", Phrase))
+	}
+
+	return Q.add(`"` + Phrase + `"`)
+}
+
+// Not leaves out tweets containing any of Words
+func (Q *Query) Not(Words ...string) *Query {
+
+	for _, Word := range Words {
+
+		Word = strings.TrimSpace(Word)
+
+		if Word == "" {
+			continue
+		}
+
+		Term, err := quoteTerm(Word)
+
+		if err != nil {
+			return Q.fail(err)
+		}
+
+		Q.add("-" + Term)
+	}
+
+	return Q
+}
+
+// Hashtag matches tweets with the hashtag, with or without the #
+func (Q *Query) Hashtag(Tag string) *Query {
+	return Q.Words("#" + strings.TrimPrefix(strings.TrimSpace(Tag), "#"))
+}
+
+// Any matches tweets containing at least one of Words
+func (Q *Query) Any(Words ...string) *Query {
+
+	var Terms []string
+
+	for _, Word := range Words {
+
+		Word = strings.TrimSpace(Word)
+
+		if Word == "" {
+			continue
+		}
+
+		Term, err := quoteTerm(Word)
+
+		if err != nil {
+			return Q.fail(err)
+		}
+
+		Terms = append(Terms, Term)
+	}
+
+	return Q.group(Terms)
+}
+
+// AnyOf matches tweets matching at least one of Queries, for alternatives made of several terms
+//
+//	NewQuery().AnyOf(NewQuery().From("alice").Words("release"), NewQuery().From("bob"))
+func (Q *Query) AnyOf(Queries ...*Query) *Query {
+
+	var Terms []string
+
+	for _, Sub := range Queries {
+
+		if Sub.err != nil {
+			return Q.fail(Sub.err)
+		}
+
+		switch len(Sub.terms) {
+		case 0:
+		case 1:
+			Terms = append(Terms, Sub.terms[0])
+		default:
+			Terms = append(Terms, "("+Sub.String()+")")
+		}
+	}
+
+	return Q.group(Terms)
+}
+
+func (Q *Query) group(Terms []string) *Query {
+
+	switch len(Terms) {
+	case 0:
+		return Q
+	case 1:
+		return Q.add(Terms[0])
+	}
+
+	return Q.add("(" + strings.Join(Terms, " OR ") + ")")
+}
+
+func (Q *Query) user(Operator, ScreenName string) *Query {
+
+	Name, err := ParseScreenName(ScreenName)
+
+	if err != nil {
+		return Q.fail(fmt.Errorf("Invalid screen name %q", ScreenName))
+	}
+
+	return Q.add(Operator + Name)
+}
+
+// From matches tweets sent by ScreenName
+func (Q *Query) From(ScreenName string) *Query {
+	return Q.user("from:", ScreenName)
+}
+
+// To matches replies to ScreenName
+func (Q *Query) To(ScreenName string) *Query {
+	return Q.user("to:", ScreenName)
+}
+
+// Mentioning matches tweets mentioning ScreenName
+func (Q *Query) Mentioning(ScreenName string) *Query {
+	return Q.user("@", ScreenName)
+}
+
+func (Q *Query) filter(Prefix, Name string) *Query {
+
+	if !filterName.MatchString(Name) {
+		return Q.fail(fmt.Errorf("Invalid search filter %q", Name))
+	}
+
+	return Q.add(Prefix + Name)
+}
+
+// Filter keeps only tweets of a kind, like "media", "links", "replies", "retweets" or "verified"
+func (Q *Query) Filter(Name string) *Query {
+	return Q.filter("filter:", Name)
+}
+
+// Exclude leaves out tweets of a kind, Exclude("retweets") gives -filter:retweets
+func (Q *Query) Exclude(Name string) *Query {
+	return Q.filter("-filter:", Name)
+}
+
+// Since matches tweets sent on or after the day of T
+func (Q *Query) Since(T time.Time) *Query {
+	return Q.add("since:" + T.Format("2006-01-02"))
+}
+
+// Until matches tweets sent before the day of T
+func (Q *Query) Until(T time.Time) *Query {
+	return Q.add("until:" + T.Format("2006-01-02"))
+}
+
+// Lang matches tweets Twitter detected as written in Code
+func (Q *Query) Lang(Code string) *Query {
+
+	if !langCode.MatchString(Code) {
+		return Q.fail(fmt.Errorf("Invalid language code %q", Code))
+	}
+
+	return Q.add("lang:" + Code)
+}
+
+// Raw adds Term unchanged, for operators without a method of their own
+func (Q *Query) Raw(Term string) *Query {
+
+	if Term = strings.TrimSpace(Term); Term == "" {
+		return Q
+	}
+
+	return Q.add(Term)
+}
+
+// String is the query as built so far, without validation
+func (Q *Query) String() string {
+	return strings.Join(Q.terms, " ")
+}
+
+// Build returns the query or the first mistake made building it
+func (Q *Query) Build() (string, error) {
+
+	if Q.err != nil {
+		return "", Q.err
+	}
+
+	Query := Q.String()
+
+	if Query == "" {
+		return "", errors.New("Search query cannot be empty")
+	}
+
+	if err := checkQueryLength(Query); err != nil {
+		return "", err
+	}
+
+	return Query, nil
+}
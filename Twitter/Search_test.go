@@ -0,0 +1,148 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestQuery(t *testing.T) {
+
+	Day := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  string
+		fails bool
+	}{
+		{"readme example", NewQuery("golang").Any("generics", "iterators").From("golang").Exclude("retweets").Lang("en"), "golang (generics OR iterators) from:golang -filter:retweets lang:en", false},
+		{"phrases are quoted", NewQuery("go 1.18", "OR").Phrase("  type   parameters "), `"go 1.18" "OR" "type parameters"`, false},
+		{"negation and hashtags", NewQuery().Hashtag("golang").Not("rust", "zig lang"), `#golang -rust -"zig lang"`, false},
+		{"grouped alternatives", NewQuery().AnyOf(NewQuery().From("alice").Words("release"), NewQuery().From("@bob")), "((from:alice release) OR from:bob)", false},
+		{"dates and mentions", NewQuery().Mentioning("https://x.com/gopher").Since(Day).Until(Day.AddDate(0, 0, 1)), "@gopher since:2021-03-04 until:2021-03-05", false},
+		{"raw", NewQuery("a").Raw(" min_faves:10 "), "a min_faves:10", false},
+		{"quotes", NewQuery(`say "hi"`), "", true},
+		{"bad screen name", NewQuery("a").From("not a name"), "", true},
+		{"bad filter", NewQuery("a").Filter("Media!"), "", true},
+		{"bad language", NewQuery("a").Lang("english"), "", true},
+		{"empty", NewQuery(" "), "", true},
+		{"too long", NewQuery(strings.Repeat("a", MaxQueryLength+1)), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got, err := tt.query.Build()
+
+			if got != tt.want || (err != nil) != tt.fails {
+				t.Errorf("got %q, %v, want %q, failure %v", got, err, tt.want, tt.fails)
+			}
+		})
+	}
+
+	if _, err := NewQuery(strings.Repeat("a", MaxQueryLength+1)).Build(); !errors.Is(err, ErrQueryTooLong) {
+		t.Errorf("got %v, want ErrQueryTooLong", err)
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		options SearchOptions
+		want    map[string][]string
+		fails   bool
+	}{
+		{"query only", SearchOptions{Query: "go"}, map[string][]string{"q": {"go"}}, false},
+		{"everything", SearchOptions{
+			Query: "go", GeoCode: "1,2,5km", Lang: "en", Locale: "ja", ResultType: Recent, Count: 100,
+			Until: time.Date(2021, 3, 4, 15, 0, 0, 0, time.UTC), SinceID: "https://twitter.com/a/status/10", MaxID: "20", ExcludeEntities: true,
+		}, map[string][]string{
+			"q": {"go"}, "geocode": {"1,2,5km"}, "lang": {"en"}, "locale": {"ja"}, "result_type": {"recent"}, "count": {"100"},
+			"until": {"2021-03-04"}, "since_id": {"10"}, "max_id": {"20"}, "include_entities": {"false"},
+		}, false},
+		{"empty query", SearchOptions{}, nil, true},
+		{"bad result type", SearchOptions{Query: "go", ResultType: "newest"}, nil, true},
+		{"count too high", SearchOptions{Query: "go", Count: 101}, nil, true},
+		{"bad language", SearchOptions{Query: "go", Lang: "e"}, nil, true},
+		{"bad since id", SearchOptions{Query: "go", SinceID: "https://example.com/1"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got, err := tt.options.Values()
+
+			if (err != nil) != tt.fails || (err == nil && !reflect.DeepEqual(map[string][]string(got), tt.want)) {
+				t.Errorf("got %v, %v, want %v, failure %v", got, err, tt.want, tt.fails)
+			}
+		})
+	}
+}
+
+func TestSearchTweets(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	Alice := login(t, S, "alice")
+	Bob := login(t, S, "bob")
+
+	Alice.Tweet("golang release notes", "", "", false, false)
+	Bob.Tweet("golang meetup tonight", "", "", false, false)
+
+	resp, _ := Bob.Tweet("no match here", "", "", false, false)
+	Alice.Retweet(idOf(t, resp))
+	Alice.Retweet(idOf(t, resp))
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  []string
+	}{
+		{"word", NewQuery("golang"), []string{"golang meetup tonight", "golang release notes"}},
+		{"from", NewQuery("golang").From("alice"), []string{"golang release notes"}},
+		{"only retweets", NewQuery().From("alice").Filter("retweets"), []string{"RT @bob: no match here"}},
+		{"no retweets", NewQuery().From("alice").Exclude("retweets"), []string{"golang release notes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Query, err := tt.query.Build()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := Alice.SearchTweets(SearchOptions{Query: Query, ResultType: Recent})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var Result struct {
+				Statuses []Tweet `json:"statuses"`
+			}
+
+			if err := Decode(resp, &Result); err != nil {
+				t.Fatal(err)
+			}
+
+			var Texts []string
+
+			for _, T := range Result.Statuses {
+				Texts = append(Texts, T.Text)
+			}
+
+			if !reflect.DeepEqual(Texts, tt.want) {
+				t.Errorf("got %q, want %q", Texts, tt.want)
+			}
+		})
+	}
+}
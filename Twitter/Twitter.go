@@ -4,14 +4,13 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"github.com/antonholmquist/jason"
 	"github.com/garyburd/go-oauth/oauth"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -22,9 +21,25 @@ const BASEURL = "https://api.twitter.com/1.1/"
 type Account struct {
 	ConsumerKey    string
 	ConsumerSecret string
-}
 
-var params = url.Values{}
+	//Retry decides which failed requests are sent again, DefaultRetryPolicy is used when nil
+	Retry *RetryPolicy
+
+	//IdempotentTweets makes Tweet look for an already published copy before failing or posting twice
+	IdempotentTweets bool
+
+	//Middleware runs around every request the account makes, see Use
+	Middleware []Middleware
+
+	//Cache serves repeated GETs of read endpoints without spending rate limit, see NewCachePolicy
+	Cache *CachePolicy
+
+	//ScreenName, AccessToken and AccessSecret identify the user the account acts as. Auth, SetAccessToken
+	//and LoadAccount fill them in, while the token is empty the one from the last Auth call is used.
+	ScreenName   string
+	AccessToken  string
+	AccessSecret string
+}
 
 type EndPoints struct {
 	GetAccountSettings    string
@@ -36,6 +51,7 @@ type EndPoints struct {
 	ShowTweet             string
 	Tweet                 string
 	Retweet               string
+	Unretweet             string
 	Oembed                string
 	Favourite             string
 	LookUp                string
@@ -81,12 +97,29 @@ type EndPoints struct {
 	MuteUserList          string
 	FavoriteList          string
 	UnFavorite            string
+	SavedSearches         string
+	SavedSearchShow       string
+	SavedSearchCreate     string
+	SavedSearchDestroy    string
+	CollectionShow        string
+	CollectionCreate      string
+	CollectionUpdate      string
+	CollectionDestroy     string
+	CollectionEntries     string
+	CollectionAdd         string
+	CollectionRemove      string
+	CollectionMove        string
+	CollectionCurate      string
+	HelpConfiguration     string
+	HelpLanguages         string
+	HelpPrivacy           string
+	HelpTOS               string
 }
 
 //Twitter Endpoints
 var ENDPOINT = EndPoints{
-	UnFavorite:            fmt.Sprintf("%favorites/destroy.json", BASEURL),
-	FavoriteList:          fmt.Sprintf("%favorites/list.json", BASEURL),
+	UnFavorite:            fmt.Sprintf("%sfavorites/destroy.json", BASEURL),
+	FavoriteList:          fmt.Sprintf("%sfavorites/list.json", BASEURL),
 	MuteUser:              fmt.Sprintf("%smutes/users/create.json", BASEURL),
 	UnmuteUser:            fmt.Sprintf("%smutes/users/destroy.json", BASEURL),
 	GetUserBanner:         fmt.Sprintf("%susers/profile_banner.json", BASEURL),
@@ -132,11 +165,29 @@ var ENDPOINT = EndPoints{
 	ShowTweet:             fmt.Sprintf("%sstatuses/show.json", BASEURL),
 	Tweet:                 fmt.Sprintf("%sstatuses/update.json", BASEURL),
 	Retweet:               fmt.Sprintf("%sstatuses/retweet/:id.json", BASEURL),
+	Unretweet:             fmt.Sprintf("%sstatuses/unretweet/:id.json", BASEURL),
 	Oembed:                fmt.Sprintf("%sstatuses/oembed.json", BASEURL),
 	Retweeters:            fmt.Sprintf("%sstatuses/retweeters/ids.json", BASEURL),
 	LookUp:                fmt.Sprintf("%sstatuses/lookup.json", BASEURL),
-	MediaUpload:           fmt.Sprintf("%smedia/upload.json", BASEURL),
+	MediaUpload:           "https://upload.twitter.com/1.1/media/upload.json",
 	Search:                fmt.Sprintf("%ssearch/tweets.json", BASEURL),
+	SavedSearches:         fmt.Sprintf("%ssaved_searches/list.json", BASEURL),
+	SavedSearchShow:       fmt.Sprintf("%ssaved_searches/show/:id.json", BASEURL),
+	SavedSearchCreate:     fmt.Sprintf("%ssaved_searches/create.json", BASEURL),
+	SavedSearchDestroy:    fmt.Sprintf("%ssaved_searches/destroy/:id.json", BASEURL),
+	CollectionShow:        fmt.Sprintf("%scollections/show.json", BASEURL),
+	CollectionCreate:      fmt.Sprintf("%scollections/create.json", BASEURL),
+	CollectionUpdate:      fmt.Sprintf("%scollections/update.json", BASEURL),
+	CollectionDestroy:     fmt.Sprintf("%scollections/destroy.json", BASEURL),
+	CollectionEntries:     fmt.Sprintf("%scollections/entries.json", BASEURL),
+	CollectionAdd:         fmt.Sprintf("%scollections/entries/add.json", BASEURL),
+	CollectionRemove:      fmt.Sprintf("%scollections/entries/remove.json", BASEURL),
+	CollectionMove:        fmt.Sprintf("%scollections/entries/move.json", BASEURL),
+	CollectionCurate:      fmt.Sprintf("%scollections/entries/curate.json", BASEURL),
+	HelpConfiguration:     fmt.Sprintf("%shelp/configuration.json", BASEURL),
+	HelpLanguages:         fmt.Sprintf("%shelp/languages.json", BASEURL),
+	HelpPrivacy:           fmt.Sprintf("%shelp/privacy.json", BASEURL),
+	HelpTOS:               fmt.Sprintf("%shelp/tos.json", BASEURL),
 }
 
 var oauthClient = oauth.Client{
@@ -147,13 +198,22 @@ var oauthClient = oauth.Client{
 
 var token = &oauthClient.Credentials
 
+//HTTPClient is used for every request made by the library, swap it out to route requests elsewhere (e.g. a TwitterTest server)
+var HTTPClient = http.DefaultClient
+
 func (P *Account) UnFavorite(ID string) (string, error) {
 
-	var Params = params
+	ID, err := tweetIDArg(ID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	Params.Add("id", ID)
 
-	resp, err := DoRequest(ENDPOINT.UnFavorite, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.UnFavorite, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -164,18 +224,26 @@ func (P *Account) UnFavorite(ID string) (string, error) {
 
 func (P *Account) FavoritesList(ScreenName, UserId, Count string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ScreenName != "":
 		Params.Add("screen_name", ScreenName)
-	case Count != "":
-		Params.Add("count", Count)
 	case UserId != "":
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.FavoriteList, Params, "GET")
+	if Count != "" {
+		Params.Add("count", Count)
+	}
+
+	resp, err := P.DoRequest(ENDPOINT.FavoriteList, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -187,7 +255,13 @@ func (P *Account) FavoritesList(ScreenName, UserId, Count string) (string, error
 
 func (P *Account) UnMuteUser(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ScreenName != "":
@@ -196,7 +270,7 @@ func (P *Account) UnMuteUser(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.UnmuteUser, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.UnmuteUser, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -208,7 +282,13 @@ func (P *Account) UnMuteUser(ScreenName, UserId string) (string, error) {
 
 func (P *Account) MuteUser(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ScreenName != "":
@@ -217,7 +297,7 @@ func (P *Account) MuteUser(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.MuteUser, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.MuteUser, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -229,7 +309,13 @@ func (P *Account) MuteUser(ScreenName, UserId string) (string, error) {
 
 func (P *Account) GetUserBanner(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ScreenName != "":
@@ -238,7 +324,7 @@ func (P *Account) GetUserBanner(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.GetUserBanner, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.GetUserBanner, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -249,7 +335,7 @@ func (P *Account) GetUserBanner(ScreenName, UserId string) (string, error) {
 
 func (P *Account) RemoveBanner() (string, error) {
 
-	resp, err := DoRequest(ENDPOINT.RemoveBanner, nil, "POST")
+	resp, err := P.DoRequest(ENDPOINT.RemoveBanner, nil, "POST")
 
 	if err != nil {
 		return "", err
@@ -260,43 +346,19 @@ func (P *Account) RemoveBanner() (string, error) {
 
 func (P *Account) UpdateBanner(Image, Width, Height, Offset_Left, Offset_Top string) (string, error) {
 
-	var Params = params
-
-	Image, _, err := ImageToBase64(Image)
-
-	if err != nil {
-		return "", err
-	}
-
-	switch {
-	case Image == "":
+	if Image == "" {
 		return "", errors.New("Image cannot be empty")
-	case Width != "":
-		Params.Add("width", Width)
-	case Height != "":
-		Params.Add("height", Height)
-	case Offset_Left != "":
-		Params.Add("offset_left", Offset_Left)
-	case Offset_Top != "":
-		Params.Add("offset_top", Offset_Top)
-	}
-
-	resp, err := DoRequest(ENDPOINT.UpdateBanner, Params, "POST")
-
-	if err != nil {
-		return "", err
 	}
 
-	return resp, nil
-
+	return P.UpdateBannerFrom(FileSource(Image), Width, Height, Offset_Left, Offset_Top)
 }
 
 func (P *Account) UsersSearch(Q, Page string) (string, error) {
-	var Params = params
+	var Params = url.Values{}
 	Params.Add("q", Q)
 	Params.Add("page", Page)
 
-	resp, err := DoRequest(ENDPOINT.UsersSearch, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.UsersSearch, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -307,7 +369,13 @@ func (P *Account) UsersSearch(Q, Page string) (string, error) {
 
 func (P *Account) UsersShow(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ScreenName == "" && UserId == "":
@@ -318,7 +386,7 @@ func (P *Account) UsersShow(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.UsersShow, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.UsersShow, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -329,7 +397,13 @@ func (P *Account) UsersShow(ScreenName, UserId string) (string, error) {
 
 func (P *Account) UserLookUp(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ScreenName == "" && UserId == "":
@@ -340,7 +414,7 @@ func (P *Account) UserLookUp(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.UsersLookup, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.UsersLookup, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -351,7 +425,13 @@ func (P *Account) UserLookUp(ScreenName, UserId string) (string, error) {
 
 func (P *Account) UnBlockUser(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ScreenName == "" && UserId == "":
@@ -362,7 +442,7 @@ func (P *Account) UnBlockUser(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.UnBlockUser, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.UnBlockUser, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -373,7 +453,13 @@ func (P *Account) UnBlockUser(ScreenName, UserId string) (string, error) {
 
 func (P *Account) BlockUser(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ScreenName == "" && UserId == "":
@@ -384,7 +470,7 @@ func (P *Account) BlockUser(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.BlockUser, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.BlockUser, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -401,7 +487,7 @@ func (P *Account) BlockUser(ScreenName, UserId string) (string, error) {
 //Only supports 5000 user objects currently (Need to sort out cursors)
 func (P *Account) BlockList() (string, error) {
 
-	resp, err := DoRequest(ENDPOINT.BlockList, nil, "GET")
+	resp, err := P.DoRequest(ENDPOINT.BlockList, nil, "GET")
 
 	if err != nil {
 		return "", err
@@ -411,32 +497,15 @@ func (P *Account) BlockList() (string, error) {
 }
 
 func (P *Account) ChangeProfilePicture(FileName string) (string, error) {
-
-	var Params = params
-
-	F, _, err := ImageToBase64(FileName)
-
-	if err != nil {
-		return "", err
-	}
-
-	Params.Add("image", F)
-
-	resp, err := DoRequest(ENDPOINT.UpdatePicture, Params, "POST")
-
-	if err != nil {
-		return "", err
-	}
-
-	return resp, nil
+	return P.ChangeProfilePictureFrom(FileSource(FileName))
 }
 
 func (P *Account) RemoveBackgroundPicture() (string, error) {
-	var Params = params
+	var Params = url.Values{}
 
 	Params.Add("use", "false")
 
-	resp, err := DoRequest(ENDPOINT.UpdateBackgroundPic, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.UpdateBackgroundPic, Params, "POST")
 
 	if err != nil {
 		return "", nil
@@ -446,56 +515,12 @@ func (P *Account) RemoveBackgroundPicture() (string, error) {
 }
 
 func (P *Account) UpdateBackgroundPicture(FilePath string, Tile bool) (string, error) {
-
-	var Params = params
-
-	f, i, err := ImageToBase64(FilePath)
-
-	if err != nil {
-		return "", err
-	}
-	//if file size is greater than 800kb we return an error because twitter will not accept anything > 800kbs.
-	if i > 800 {
-		return "", errors.New("Images may not be largers than 800kbs")
-	}
-
-	fmt.Println(f)
-
-	Params.Add("image", f)
-	Params.Add("use", "1")
-
-	if Tile == true {
-		Params.Add("tile", "true")
-	}
-
-	resp, err := DoRequest(ENDPOINT.UpdateBackgroundPic, Params, "POST")
-
-	if err != nil {
-		return "", nil
-	}
-
-	return resp, nil
+	return P.UpdateBackgroundPictureFrom(FileSource(FilePath), Tile)
 }
-func (P *Account) UpdateProfile(Options map[string]string) (string, error) {
 
-	Par := []string{"name", "url", "location", "description", "profile_link_color"}
-
-	var Params = params
-
-	Op := make(map[string]string)
+func (P *Account) VerifyCredential() (string, error) {
 
-	for key, value := range Options {
-		for _, ele := range Par {
-			if strings.Contains(key, ele) {
-				Op[key] = value
-			}
-		}
-	}
-
-	for k, v := range Op {
-		Params.Add(k, v)
-	}
-	resp, err := DoRequest(ENDPOINT.UpdatePicture, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.VerifyCredentials, nil, "GET")
 
 	if err != nil {
 		return "", err
@@ -504,55 +529,27 @@ func (P *Account) UpdateProfile(Options map[string]string) (string, error) {
 	return resp, nil
 }
 
-func (P *Account) ChangeAccountSettings(Options map[string]string) (string, error) {
-
-	var Params = params
-
-	Pref := []string{"sleep_time_enabled", "trend_location_woeid", "start_sleep_time", "end_sleep_time", "time_zone", "lang"}
-
-	Op := make(map[string]string)
-
-	for key, value := range Options {
-		for _, ele := range Pref {
-			if strings.Contains(key, ele) {
-				Op[key] = value
-			}
-		}
-	}
-
-	for k, v := range Op {
-		Params.Add(k, v)
-	}
+func (P *Account) FriendshipShow(ScreenName, TargetScreenName string) (string, error) {
 
-	resp, err := DoRequest(ENDPOINT.ChangeAccountSettings, Params, "POST")
+	ScreenName, err := screenNameArg(ScreenName)
 
 	if err != nil {
 		return "", err
 	}
 
-	return resp, nil
-}
-
-func (P *Account) VerifyCredential() (string, error) {
-
-	resp, err := DoRequest(ENDPOINT.VerifyCredentials, nil, "GET")
+	TargetScreenName, err = screenNameArg(TargetScreenName)
 
 	if err != nil {
 		return "", err
 	}
 
-	return resp, nil
-}
-
-func (P *Account) FriendshipShow(ScreenName, TargetScreenName string) (string, error) {
-
-	var Params = params
+	var Params = url.Values{}
 
 	Params.Add("source_screen_name", ScreenName)
 
 	Params.Add("target_screen_name", TargetScreenName)
 
-	resp, err := DoRequest(ENDPOINT.FriendshipShow, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.FriendshipShow, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -563,7 +560,13 @@ func (P *Account) FriendshipShow(ScreenName, TargetScreenName string) (string, e
 
 func (P *Account) UnFollowUser(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 
@@ -575,7 +578,7 @@ func (P *Account) UnFollowUser(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.UnFollowUser, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.UnFollowUser, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -586,7 +589,13 @@ func (P *Account) UnFollowUser(ScreenName, UserId string) (string, error) {
 
 func (P *Account) FollowUser(ScreenName, UserId string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case UserId == "" && ScreenName == "":
@@ -597,7 +606,7 @@ func (P *Account) FollowUser(ScreenName, UserId string) (string, error) {
 		Params.Add("user_id", UserId)
 	}
 
-	resp, err := DoRequest(ENDPOINT.FollowUser, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.FollowUser, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -609,13 +618,13 @@ func (P *Account) FollowUser(ScreenName, UserId string) (string, error) {
 
 func (P *Account) PendingFollowersOutgoing(Cursor string) (string, error) {
 
-	var Params = params
+	var Params = url.Values{}
 
 	if Cursor != "" {
 		Params.Add("cursor", Cursor)
 	}
 
-	resp, err := DoRequest(ENDPOINT.PendingFollowersO, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.PendingFollowersO, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -625,14 +634,14 @@ func (P *Account) PendingFollowersOutgoing(Cursor string) (string, error) {
 }
 func (P *Account) PendingFollowersIncoming(Cursor string) (string, error) {
 
-	var Params = params
+	var Params = url.Values{}
 
 	if Cursor != "" {
 
 		Params.Add("cursor", Cursor)
 	}
 
-	resp, err := DoRequest(ENDPOINT.PendingFollowersI, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.PendingFollowersI, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -642,7 +651,13 @@ func (P *Account) PendingFollowersIncoming(Cursor string) (string, error) {
 }
 func (P *Account) FollowersList(UserID, ScreenName, Cursor, Count string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case UserID == "" && ScreenName == "":
@@ -651,13 +666,17 @@ func (P *Account) FollowersList(UserID, ScreenName, Cursor, Count string) (strin
 		Params.Add("user_id", UserID)
 	case ScreenName != "":
 		Params.Add("screen_name", ScreenName)
-	case Cursor != "":
+	}
+
+	if Cursor != "" {
 		Params.Add("cursor", Cursor)
-	case Count != "":
+	}
+
+	if Count != "" {
 		Params.Add("count", Count)
 	}
 
-	resp, err := DoRequest(ENDPOINT.Followers, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.Followers, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -669,7 +688,13 @@ func (P *Account) FollowersList(UserID, ScreenName, Cursor, Count string) (strin
 
 func (P *Account) FollowingList(UserID, ScreenName, Cursor, Count string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case UserID == "" && ScreenName == "":
@@ -678,13 +703,17 @@ func (P *Account) FollowingList(UserID, ScreenName, Cursor, Count string) (strin
 		Params.Add("user_id", UserID)
 	case ScreenName != "":
 		Params.Add("screen_name", ScreenName)
-	case Cursor != "":
+	}
+
+	if Cursor != "" {
 		Params.Add("cursor", Cursor)
-	case Count != "":
+	}
+
+	if Count != "" {
 		Params.Add("count", Count)
 	}
 
-	resp, err := DoRequest(ENDPOINT.Following, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.Following, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -695,11 +724,11 @@ func (P *Account) FollowingList(UserID, ScreenName, Cursor, Count string) (strin
 
 func (P *Account) DMDelete(ID string) (string, error) {
 
-	var Params = params
+	var Params = url.Values{}
 
 	Params.Add("id", ID)
 
-	resp, err := DoRequest(ENDPOINT.DMDelete, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.DMDelete, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -710,7 +739,13 @@ func (P *Account) DMDelete(ID string) (string, error) {
 
 func (P *Account) DMCreate(UserID, ScreenName, Text string) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	Params.Add("text", Text)
 
@@ -723,7 +758,7 @@ func (P *Account) DMCreate(UserID, ScreenName, Text string) (string, error) {
 		Params.Add("screen_name", ScreenName)
 	}
 
-	resp, err := DoRequest(ENDPOINT.DMCreate, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.DMCreate, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -734,13 +769,13 @@ func (P *Account) DMCreate(UserID, ScreenName, Text string) (string, error) {
 }
 
 func (P *Account) DirectMessages(Count, SkipStatus string) (string, error) {
-	var Params = params
+	var Params = url.Values{}
 
 	if Count != "" {
 		Params.Add("count", Count)
 	}
 
-	resp, err := DoRequest(ENDPOINT.DirectMessages, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.DirectMessages, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -749,31 +784,19 @@ func (P *Account) DirectMessages(Count, SkipStatus string) (string, error) {
 	return resp, nil
 }
 
+//Search is SearchTweets with only a query and geocode
 func (P *Account) Search(Query, GeoCode string) (string, error) {
-	var Params = params
-
-	Params.Add("q", Query)
 
-	if GeoCode != "" {
-		Params.Add("geocode", GeoCode)
-	}
-
-	resp, err := DoRequest(ENDPOINT.Search, Params, "GET")
-
-	if err != nil {
-		return "", err
-	}
-
-	return resp, nil
+	return P.SearchTweets(SearchOptions{Query: Query, GeoCode: GeoCode})
 
 }
 
 //UNTESTED
 func (P *Account) DirectMessageShow(ID string) (string, error) {
-	var Params = params
+	var Params = url.Values{}
 	Params.Add("id", ID)
 
-	resp, err := DoRequest(ENDPOINT.DMShow, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.DMShow, Params, "GET")
 
 	if err != nil {
 		return "", nil
@@ -785,7 +808,7 @@ func (P *Account) DirectMessageShow(ID string) (string, error) {
 //UNTESTED
 func (P *Account) DirectMessageSent(Page, Count string) (string, error) {
 
-	var Params = params
+	var Params = url.Values{}
 
 	switch {
 	case Page != "":
@@ -794,7 +817,7 @@ func (P *Account) DirectMessageSent(Page, Count string) (string, error) {
 		Params.Add("id", Count)
 	}
 
-	resp, err := DoRequest(ENDPOINT.DMSent, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.DMSent, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -807,11 +830,11 @@ func (P *Account) DirectMessageSent(Page, Count string) (string, error) {
 //UNTESTED
 func (P *Account) ReportForSpam(ID string) (string, error) {
 
-	var Params = params
+	var Params = url.Values{}
 
 	Params.Add("id", ID)
 
-	resp, err := DoRequest(ENDPOINT.ReportSpam, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.ReportSpam, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -823,11 +846,17 @@ func (P *Account) ReportForSpam(ID string) (string, error) {
 
 func (P *Account) DeleteTweet(ID string) (string, error) {
 
-	var Params = params
+	ID, err := tweetIDArg(ID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	Params.Add("id", ID)
 
-	resp, err := DoRequest(strings.Replace(ENDPOINT.DeleteTweet, ":id", ID, -1), Params, "POST")
+	resp, err := P.DoRequest(strings.Replace(ENDPOINT.DeleteTweet, ":id", ID, -1), Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -838,12 +867,19 @@ func (P *Account) DeleteTweet(ID string) (string, error) {
 }
 
 func (P *Account) Retweeters(ID string) (string, error) {
+
+	ID, err := tweetIDArg(ID)
+
+	if err != nil {
+		return "", err
+	}
+
 	//Cursor doesn't work?
-	var Params = params
+	var Params = url.Values{}
 
 	Params.Add("id", ID)
 
-	resp, err := DoRequest(ENDPOINT.Retweeters, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.Retweeters, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -853,14 +889,21 @@ func (P *Account) Retweeters(ID string) (string, error) {
 }
 
 func (P *Account) RetweetsByID(ID, Count string) (string, error) {
-	var Params = params
+
+	ID, err := tweetIDArg(ID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 	Params.Add("id", ID)
 
 	if Count != "" {
 		Params.Add("count", Count)
 	}
 
-	resp, err := DoRequest(strings.Replace(ENDPOINT.RetweetsByID, ":id", ID, -1), Params, "GET")
+	resp, err := P.DoRequest(strings.Replace(ENDPOINT.RetweetsByID, ":id", ID, -1), Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -868,13 +911,13 @@ func (P *Account) RetweetsByID(ID, Count string) (string, error) {
 	return resp, nil
 }
 func (P *Account) RetweetsOfMe(Count string) (string, error) {
-	var Params = params
+	var Params = url.Values{}
 
 	if Count != "" {
 		Params.Add("count", Count)
 	}
 
-	resp, err := DoRequest(ENDPOINT.RetweetsOfMe, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.RetweetsOfMe, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -884,18 +927,25 @@ func (P *Account) RetweetsOfMe(Count string) (string, error) {
 }
 
 func (P *Account) Oembed(ID, URL string) (string, error) {
-	var Params = params
+
+	ID, err := tweetIDArg(ID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	switch {
 	case ID == "" && URL == "":
-		log.Fatal("RIP")
+		return "", errors.New("ID and URL cannot both be empty")
 	case ID != "":
 		Params.Add("id", ID)
 	case URL != "":
 		Params.Add("url", URL)
 	}
 
-	resp, err := DoRequest(ENDPOINT.Oembed, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.Oembed, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -906,7 +956,7 @@ func (P *Account) Oembed(ID, URL string) (string, error) {
 
 func (P *Account) GetAccountSettings() (string, error) {
 
-	resp, err := DoRequest(ENDPOINT.GetAccountSettings, nil, "GET")
+	resp, err := P.DoRequest(ENDPOINT.GetAccountSettings, nil, "GET")
 
 	if err != nil {
 		return "", err
@@ -916,11 +966,18 @@ func (P *Account) GetAccountSettings() (string, error) {
 
 }
 func (P *Account) ShowTweet(ID string) (string, error) {
-	var Params = params
+
+	ID, err := tweetIDArg(ID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	Params.Add("id", ID)
 
-	resp, err := DoRequest(ENDPOINT.ShowTweet, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.ShowTweet, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -929,13 +986,26 @@ func (P *Account) ShowTweet(ID string) (string, error) {
 	return resp, nil
 }
 func (P *Account) LookUp(IDS []string) (string, error) {
-	var Params = params
+	var Params = url.Values{}
 
-	ids := strings.Join(IDS, ",")
+	var Parsed []string
+
+	for _, ID := range IDS {
+
+		ID, err := ParseTweetID(ID)
+
+		if err != nil {
+			return "", err
+		}
+
+		Parsed = append(Parsed, ID)
+	}
+
+	ids := strings.Join(Parsed, ",")
 
 	Params.Add("id", ids)
 
-	resp, err := DoRequest(ENDPOINT.LookUp, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.LookUp, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -958,47 +1028,21 @@ func ImageToBase64(FilePath string) (string, int, error) {
 }
 func (P *Account) MediaUpload(FilePath string, tweet bool) (string, error) {
 
-	encoded, _, _ := ImageToBase64(FilePath)
-	var Params = params
-
-	Params.Add("media", encoded)
-
-	resp, _ := oauthClient.Post(http.DefaultClient, token, "https://upload.twitter.com/1.1/media/upload.json", Params)
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	j, err := jason.NewObjectFromBytes([]byte(string(body)))
-
-	if err != nil {
-		return "", err
-	}
-
-	m, err := j.GetString("media_id_string")
-
-	if err != nil {
-		return "", err
-	}
+	m, err := P.MediaUploadFrom(FileSource(FilePath))
 
-	if tweet {
-		P.Tweet("", "", m, false, false)
-	} else {
-		return m, nil
+	if err != nil || !tweet {
+		return m, err
 	}
 
-	return string(body), nil
-
+	return P.Tweet("", "", m, false, false)
 }
 func (P *Account) GetHomeTimeline(Count string) (string, error) {
 
-	var Paramas = params
+	var Paramas = url.Values{}
 
 	if Count != "" {
 		Paramas.Add("count", Count)
-		resp, err := DoRequest(ENDPOINT.MentionsTimeline, Paramas, "GET")
+		resp, err := P.DoRequest(ENDPOINT.HomeTimeline, Paramas, "GET")
 
 		if err != nil {
 			return "", err
@@ -1007,7 +1051,7 @@ func (P *Account) GetHomeTimeline(Count string) (string, error) {
 		return resp, nil
 	}
 
-	resp, err := DoRequest(ENDPOINT.MentionsTimeline, nil, "GET")
+	resp, err := P.DoRequest(ENDPOINT.HomeTimeline, nil, "GET")
 
 	if err != nil {
 		return "", err
@@ -1019,13 +1063,13 @@ func (P *Account) GetHomeTimeline(Count string) (string, error) {
 
 func (P *Account) GetMentionsTimeline(Count string) (string, error) {
 
-	var Params = params
+	var Params = url.Values{}
 
 	if Count != "" {
 		Params.Add("count", Count)
 	}
 
-	resp, err := DoRequest(ENDPOINT.MentionsTimeline, Params, "GET")
+	resp, err := P.DoRequest(ENDPOINT.MentionsTimeline, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -1035,7 +1079,13 @@ func (P *Account) GetMentionsTimeline(Count string) (string, error) {
 }
 func (P *Account) GetUserTimeline(ScreenName string, UserID string, Count string, IncludeRetweets bool) (string, error) {
 
-	var Params = params
+	ScreenName, err := screenNameArg(ScreenName)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	if ScreenName == "" && UserID == "" {
 		return "", errors.New("Screenname and UserID can't be both empty")
@@ -1045,10 +1095,16 @@ func (P *Account) GetUserTimeline(ScreenName string, UserID string, Count string
 	case ScreenName != "":
 		Params.Add("screen_name", ScreenName)
 	case UserID != "":
-		Params.Add("user_Id", UserID)
+		Params.Add("user_id", UserID)
 	}
 
-	resp, err := DoRequest(ENDPOINT.UserTimeline, Params, "GET")
+	if Count != "" {
+		Params.Add("count", Count)
+	}
+
+	Params.Add("include_rts", strconv.FormatBool(IncludeRetweets))
+
+	resp, err := P.DoRequest(ENDPOINT.UserTimeline, Params, "GET")
 
 	if err != nil {
 		return "", err
@@ -1059,11 +1115,17 @@ func (P *Account) GetUserTimeline(ScreenName string, UserID string, Count string
 }
 func (P *Account) FavouriteTweet(TweetID string) (string, error) {
 
-	var Params = params
+	TweetID, err := tweetIDArg(TweetID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	Params.Add("id", TweetID)
 
-	resp, err := DoRequest(ENDPOINT.Favourite, Params, "POST")
+	resp, err := P.DoRequest(ENDPOINT.Favourite, Params, "POST")
 
 	if err != nil {
 		return "", err
@@ -1072,65 +1134,170 @@ func (P *Account) FavouriteTweet(TweetID string) (string, error) {
 
 }
 
+//SetAccessToken skips the PIN flow in Auth for when the access token is already known
+func (P *Account) SetAccessToken(Token, Secret string) {
+
+	oauthClient.Credentials.Token = P.ConsumerKey
+	oauthClient.Credentials.Secret = P.ConsumerSecret
+
+	P.AccessToken = Token
+	P.AccessSecret = Secret
+
+	token = &oauth.Credentials{Token: Token, Secret: Secret}
+}
+
 func (P *Account) UnAuth() {
+	P.AccessToken = ""
+	P.AccessSecret = ""
 	token = nil
 }
 
 func (P *Account) Retweet(TweetID string) (string, error) {
 
-	var Params = params
+	TweetID, err := tweetIDArg(TweetID)
+
+	if err != nil {
+		return "", err
+	}
+
+	var Params = url.Values{}
 
 	Params.Add("id", TweetID)
 
-	resp, err := DoRequest(strings.Replace(ENDPOINT.Retweet, ":id", TweetID, -1), Params, "POST")
+	resp, err := P.DoRequest(strings.Replace(ENDPOINT.Retweet, ":id", TweetID, -1), Params, "POST")
+
+	if IsErrorCode(err, 327) {
+		return "", errors.New("You can not retweet a tweet that is already retweeted")
+	}
 
 	if err != nil {
 		return "", err
 	}
 
-	if strings.Contains(resp, "You have already retweeted this tweet.") {
-		return "", errors.New("You can not retweet a tweet that is already retweeted")
+	return resp, nil
+
+}
+
+//Unretweet undoes a retweet, TweetID may be the original tweet or the retweet
+func (P *Account) Unretweet(TweetID string) (string, error) {
+
+	TweetID, err := tweetIDArg(TweetID)
+
+	if err != nil {
+		return "", err
 	}
 
-	return resp, nil
+	var Params = url.Values{}
+
+	Params.Add("id", TweetID)
 
+	resp, err := P.DoRequest(strings.Replace(ENDPOINT.Unretweet, ":id", TweetID, -1), Params, "POST")
+
+	if err != nil {
+		return "", err
+	}
+
+	return resp, nil
 }
 
 func (P *Account) Tweet(Status string, ReplyStatusID string, MediaId string, PossiblySenstive bool, DisplayCoordinates bool) (string, error) {
-	var Params = params
 
-	Params.Add("status", Status)
-	switch {
-	case Status == "" && MediaId == "":
-		return "", errors.New("Status and MediaID cannot both be empty")
-	case MediaId != "":
-		Params.Add("media_ids", MediaId)
-	case ReplyStatusID != "":
-		Params.Add("in_reply_to_status_id", ReplyStatusID)
-	case PossiblySenstive:
-		Params.Add("possibly_sensitive", "true")
-	case DisplayCoordinates:
-		Params.Add("display_coordinates", "true")
+	Params, err := tweetParams(Status, ReplyStatusID, MediaId, PossiblySenstive, DisplayCoordinates)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := P.CheckTweetLength(Status); err != nil {
+		return "", err
+	}
+
+	if P.IdempotentTweets {
+		return P.tweetIdempotent(Params)
+	}
+
+	resp, err := P.DoRequest(ENDPOINT.Tweet, Params, "POST")
+
+	if err != nil {
+		return "", err
+	}
+
+	return resp, nil
+}
+
+//Reply answers TweetID in its thread, Twitter adds the @mentions of the conversation in front of Status
+func (P *Account) Reply(TweetID string, Status string, MediaId string) (string, error) {
+
+	if TweetID == "" {
+		return "", errors.New("TweetID cannot be empty")
 	}
 
-	resp, err := DoRequest(ENDPOINT.Tweet, Params, "POST")
+	Params, err := tweetParams(Status, TweetID, MediaId, false, false)
 
 	if err != nil {
 		return "", err
 	}
 
-	if strings.Contains(resp, "errors") {
-		return "", errors.New(resp)
+	if err := P.CheckTweetLength(Status); err != nil {
+		return "", err
+	}
+
+	Params.Add("auto_populate_reply_metadata", "true")
+
+	if P.IdempotentTweets {
+		return P.tweetIdempotent(Params)
 	}
+
+	resp, err := P.DoRequest(ENDPOINT.Tweet, Params, "POST")
+
+	if err != nil {
+		return "", err
+	}
+
 	return resp, nil
 }
 
+func tweetParams(Status string, ReplyStatusID string, MediaId string, PossiblySenstive bool, DisplayCoordinates bool) (url.Values, error) {
+
+	var Params = url.Values{}
+
+	if Status == "" && MediaId == "" {
+		return nil, errors.New("Status and MediaID cannot both be empty")
+	}
+
+	ReplyStatusID, err := tweetIDArg(ReplyStatusID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	Params.Add("status", Status)
+
+	if MediaId != "" {
+		Params.Add("media_ids", MediaId)
+	}
+
+	if ReplyStatusID != "" {
+		Params.Add("in_reply_to_status_id", ReplyStatusID)
+	}
+
+	if PossiblySenstive {
+		Params.Add("possibly_sensitive", "true")
+	}
+
+	if DisplayCoordinates {
+		Params.Add("display_coordinates", "true")
+	}
+
+	return Params, nil
+}
+
 func (P *Account) Auth() (string, error) {
 
 	oauthClient.Credentials.Token = P.ConsumerKey
 	oauthClient.Credentials.Secret = P.ConsumerSecret
 
-	tempcred, errors := oauthClient.RequestTemporaryCredentials(http.DefaultClient, "oob", nil)
+	tempcred, errors := oauthClient.RequestTemporaryCredentials(HTTPClient, "oob", nil)
 
 	if errors != nil {
 		return "", errors
@@ -1154,7 +1321,7 @@ func (P *Account) Auth() (string, error) {
 	var code string
 	fmt.Scanln(&code)
 
-	tokenCred, _, err := oauthClient.RequestToken(http.DefaultClient, tempcred, code)
+	tokenCred, values, err := oauthClient.RequestToken(HTTPClient, tempcred, code)
 
 	if err != nil {
 		return "", err
@@ -1162,51 +1329,28 @@ func (P *Account) Auth() (string, error) {
 
 	token = tokenCred
 
-	return "", nil
+	P.AccessToken = tokenCred.Token
+	P.AccessSecret = tokenCred.Secret
+	P.ScreenName = values.Get("screen_name")
+
+	return P.ScreenName, nil
 }
+//DoRequest signs and sends a request with the token from Auth, it is the same as calling DoRequest on an empty Account
 func DoRequest(Endpoint string, Params url.Values, Method string) (string, error) {
 
-	switch Method {
-	case "POST":
-		resp, err := oauthClient.Post(http.DefaultClient, token, Endpoint, Params)
+	var P Account
 
-		if err != nil {
-			return "", err
-		}
-
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
-
-		if err != nil {
-			return "", err
-		}
-
-		return string(body), nil
-	case "GET":
-		resp, err := oauthClient.Get(http.DefaultClient, token, Endpoint, Params)
-
-		if err != nil {
-			return "", err
-		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
-
-		if err != nil {
-			return "", err
-		}
-
-		return string(body), nil
-	}
-
-	return "", errors.New("You must supply either a GET or POST method.")
+	return P.DoRequest(Endpoint, Params, Method)
 }
 
-func (P *Account) TweetURLtoID(link string) string {
+//TweetURLtoID returns the ID of the tweet a link points at, see ParseTweetURL for the forms understood
+func (P *Account) TweetURLtoID(link string) (string, error) {
 
-	a := strings.Split(link, "/")[5]
+	T, err := ParseTweetURL(link)
 
-	return a
+	if err != nil {
+		return "", err
+	}
 
+	return T.ID, nil
 }
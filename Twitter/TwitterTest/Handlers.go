@@ -0,0 +1,1609 @@
+package TwitterTest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type call struct {
+	S    *Server
+	W    http.ResponseWriter
+	R    *http.Request
+	User *User
+	ID   string
+}
+
+func (S *Server) handle(Method, Pattern string, Handler func(*call)) {
+	S.routes = append(S.routes, route{
+		Method:  Method,
+		Pattern: regexp.MustCompile("^" + Pattern + "$"),
+		Handler: Handler,
+	})
+}
+
+func (S *Server) registerRoutes() {
+
+	S.handle("GET", `account/verify_credentials\.json`, verifyCredentials)
+	S.handle("GET", `account/settings\.json`, getSettings)
+	S.handle("POST", `account/settings\.json`, postSettings)
+	S.handle("POST", `account/update_profile\.json`, updateProfile)
+	S.handle("POST", `account/update_profile_image\.json`, updateImage("image", 700*1024, func(U *User) *[]byte { return &U.ProfileImage }))
+	S.handle("POST", `account/update_profile_banner\.json`, updateImage("banner", 5*1024*1024, func(U *User) *[]byte { return &U.ProfileBanner }))
+	S.handle("POST", `account/update_profile_background_image\.json`, updateImage("image", 800*1024, func(U *User) *[]byte { return &U.ProfileBackground }))
+	S.handle("POST", `account/remove_profile_banner\.json`, removeBanner)
+
+	S.handle("POST", `statuses/update\.json`, createTweet)
+	S.handle("POST", `statuses/destroy/(\d+)\.json`, destroyTweet)
+	S.handle("POST", `statuses/retweet/(\d+)\.json`, retweet)
+	S.handle("POST", `statuses/unretweet/(\d+)\.json`, unretweet)
+	S.handle("GET", `statuses/show\.json`, showTweet)
+	S.handle("GET", `statuses/lookup\.json`, lookupTweets)
+	S.handle("GET", `statuses/user_timeline\.json`, userTimeline)
+	S.handle("GET", `statuses/home_timeline\.json`, homeTimeline)
+	S.handle("GET", `statuses/mentions_timeline\.json`, mentionsTimeline)
+	S.handle("GET", `statuses/retweets_of_me\.json`, retweetsOfMe)
+	S.handle("GET", `statuses/retweets/(\d+)\.json`, retweetsByID)
+	S.handle("GET", `statuses/retweeters/ids\.json`, retweeters)
+
+	S.handle("POST", `favorites/create\.json`, favorite)
+	S.handle("POST", `favorites/destroy\.json`, unfavorite)
+	S.handle("GET", `favorites/list\.json`, favoritesList)
+
+	S.handle("POST", `friendships/create\.json`, follow)
+	S.handle("POST", `friendships/destroy\.json`, unfollow)
+	S.handle("GET", `friendships/show\.json`, friendshipShow)
+	S.handle("GET", `friendships/(?:incoming|outgoing)\.json`, pending)
+	S.handle("GET", `friends/ids\.json`, edgeIDs(func(S *Server) map[edge]bool { return S.follows }, false))
+	S.handle("GET", `followers/ids\.json`, edgeIDs(func(S *Server) map[edge]bool { return S.follows }, true))
+	S.handle("GET", `friends/list\.json`, edgeList(func(S *Server) map[edge]bool { return S.follows }, false))
+	S.handle("GET", `followers/list\.json`, edgeList(func(S *Server) map[edge]bool { return S.follows }, true))
+
+	S.handle("POST", `blocks/create\.json`, block)
+	S.handle("POST", `blocks/destroy\.json`, toggle(func(S *Server) map[edge]bool { return S.blocks }, false))
+	S.handle("GET", `blocks/ids\.json`, ownIDs(func(S *Server) map[edge]bool { return S.blocks }))
+	S.handle("GET", `blocks/list\.json`, ownList(func(S *Server) map[edge]bool { return S.blocks }))
+	S.handle("POST", `users/report_spam\.json`, block)
+
+	S.handle("POST", `mutes/users/create\.json`, toggle(func(S *Server) map[edge]bool { return S.mutes }, true))
+	S.handle("POST", `mutes/users/destroy\.json`, toggle(func(S *Server) map[edge]bool { return S.mutes }, false))
+	S.handle("GET", `mutes/users/ids\.json`, ownIDs(func(S *Server) map[edge]bool { return S.mutes }))
+	S.handle("GET", `mutes/users/list\.json`, ownList(func(S *Server) map[edge]bool { return S.mutes }))
+
+	S.handle("GET", `direct_messages\.json`, dmList(false))
+	S.handle("GET", `direct_messages/sent\.json`, dmList(true))
+	S.handle("GET", `direct_messages/show\.json`, dmShow)
+	S.handle("POST", `direct_messages/new\.json`, dmCreate)
+	S.handle("POST", `direct_messages/destroy\.json`, dmDestroy)
+
+	S.handle("POST", `media/upload\.json`, mediaUpload)
+
+	S.handle("GET", `search/tweets\.json`, search)
+
+	S.handle("GET", `saved_searches/list\.json`, savedSearchList)
+	S.handle("GET", `saved_searches/show/(\d+)\.json`, savedSearchShow)
+	S.handle("POST", `saved_searches/create\.json`, savedSearchCreate)
+	S.handle("POST", `saved_searches/destroy/(\d+)\.json`, savedSearchDestroy)
+
+	S.handle("GET", `collections/show\.json`, collectionShow)
+	S.handle("POST", `collections/create\.json`, collectionCreate)
+	S.handle("POST", `collections/update\.json`, collectionUpdate)
+	S.handle("POST", `collections/destroy\.json`, collectionDestroy)
+	S.handle("GET", `collections/entries\.json`, collectionEntries)
+	S.handle("POST", `collections/entries/(add|remove|move)\.json`, collectionChange)
+	S.handle("POST", `collections/entries/curate\.json`, collectionCurate)
+
+	S.handle("GET", `help/configuration\.json`, helpConfiguration)
+	S.handle("GET", `help/languages\.json`, helpLanguages)
+	S.handle("GET", `help/privacy\.json`, helpPrivacy)
+	S.handle("GET", `help/tos\.json`, helpTOS)
+
+	S.handle("GET", `users/show\.json`, usersShow)
+	S.handle("GET", `users/lookup\.json`, usersLookup)
+	S.handle("GET", `users/search\.json`, usersSearch)
+}
+
+func (C *call) param(Name string) string {
+	return C.R.Form.Get(Name)
+}
+
+func (C *call) json(v interface{}) {
+	writeJSON(C.W, http.StatusOK, v)
+}
+
+func (C *call) error(Status, Code int, Message string) {
+	writeError(C.W, Status, Code, Message)
+}
+
+// view renders T for the caller, tweet_mode=extended moves the text to full_text
+// while the compatibility mode cuts it to 140 characters like the API does
+func (C *call) view(T *Tweet) Tweet {
+
+	V := C.S.view(T, C.User)
+	C.mode(&V)
+
+	return V
+}
+
+func (C *call) views(T []*Tweet) []Tweet {
+
+	V := C.S.views(T, C.User)
+
+	for i := range V {
+		C.mode(&V[i])
+	}
+
+	return V
+}
+
+func (C *call) mode(V *Tweet) {
+
+	if V.RetweetedStatus != nil {
+		R := *V.RetweetedStatus
+		C.mode(&R)
+		V.RetweetedStatus = &R
+	}
+
+	if C.param("tweet_mode") == "extended" {
+		V.FullText, V.Text = V.Text, ""
+		return
+	}
+
+	if Runes := []rune(V.Text); len(Runes) > 140 {
+		V.Text = string(Runes[:139]) + "…"
+		V.Truncated = true
+	}
+}
+
+func (C *call) count(Default, Max int) int {
+
+	n, err := strconv.Atoi(C.param("count"))
+
+	if err != nil || n <= 0 {
+		return Default
+	}
+
+	if n > Max {
+		return Max
+	}
+
+	return n
+}
+
+// target finds the user named by screen_name/user_id, writing a 404 when there isn't one
+func (C *call) target() *User {
+
+	var id string
+
+	switch {
+	case C.param("user_id") != "":
+		id = C.param("user_id")
+	case C.param("screen_name") != "":
+		id = C.S.names[strings.ToLower(C.param("screen_name"))]
+	default:
+		C.error(http.StatusBadRequest, 38, "screen_name or user_id parameter is missing.")
+		return nil
+	}
+
+	U, ok := C.S.users[id]
+
+	if !ok {
+		C.error(http.StatusNotFound, 50, "User not found.")
+		return nil
+	}
+
+	return U
+}
+
+// tweet finds the tweet named by the path or the id parameter, writing a 404 when there isn't one
+func (C *call) tweet() *Tweet {
+
+	id := C.ID
+
+	if id == "" {
+		id = C.param("id")
+	}
+
+	T, ok := C.S.tweets[id]
+
+	if !ok {
+		C.error(http.StatusNotFound, 144, "No status found with that ID.")
+		return nil
+	}
+
+	return T
+}
+
+// timeline returns the stored tweets matching Keep, newest first, honouring count/since_id/max_id
+func (C *call) timeline(Keep func(*Tweet) bool, Default, Max int) []*Tweet {
+
+	since, _ := strconv.ParseInt(C.param("since_id"), 10, 64)
+	max, _ := strconv.ParseInt(C.param("max_id"), 10, 64)
+	count := C.count(Default, Max)
+
+	var T []*Tweet
+
+	for i := len(C.S.order) - 1; i >= 0 && len(T) < count; i-- {
+
+		t, ok := C.S.tweets[C.S.order[i]]
+
+		switch {
+		case !ok:
+		case since != 0 && t.ID <= since:
+		case max != 0 && t.ID > max:
+		case C.param("include_rts") == "false" && t.RetweetedStatus != nil:
+		case Keep(t):
+			T = append(T, t)
+		}
+	}
+
+	return T
+}
+
+// older reports whether a stored tweet before ID and after since_id matches Keep
+func (C *call) older(Keep func(*Tweet) bool, ID int64) bool {
+
+	since, _ := strconv.ParseInt(C.param("since_id"), 10, 64)
+
+	for _, t := range C.S.tweets {
+		if t.ID < ID && t.ID > since && Keep(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func verifyCredentials(C *call) {
+	C.json(C.S.viewUser(C.User, C.User))
+}
+
+func getSettings(C *call) {
+	C.json(C.S.settings[C.User.IDStr])
+}
+
+func postSettings(C *call) {
+
+	Settings := *C.S.settings[C.User.IDStr]
+
+	if v := C.param("sleep_time_enabled"); v != "" {
+		Settings.SleepTime.Enabled = v == "true"
+	}
+
+	for k, f := range map[string]**int{"start_sleep_time": &Settings.SleepTime.StartTime, "end_sleep_time": &Settings.SleepTime.EndTime} {
+
+		v := C.param(k)
+
+		if v == "" {
+			continue
+		}
+
+		Hour, err := strconv.Atoi(v)
+
+		if err != nil || Hour < 0 || Hour > 23 {
+			C.error(http.StatusBadRequest, 44, k+" parameter is invalid.")
+			return
+		}
+
+		*f = &Hour
+	}
+
+	if v := C.param("time_zone"); v != "" {
+
+		Location, err := time.LoadLocation(v)
+
+		if err != nil {
+			C.error(http.StatusBadRequest, 44, "time_zone parameter is invalid.")
+			return
+		}
+
+		_, Offset := C.S.Now().In(Location).Zone()
+		Settings.TimeZone.Name, Settings.TimeZone.TZInfoName, Settings.TimeZone.UTCOffset = v, Location.String(), Offset
+	}
+
+	if v := C.param("lang"); v != "" {
+		Settings.Language = v
+	}
+
+	if v := C.param("trend_location_woeid"); v != "" {
+		Settings.TrendLocation = []map[string]interface{}{{"name": "Worldwide", "woeid": mustInt(v)}}
+	}
+
+	*C.S.settings[C.User.IDStr] = Settings
+
+	C.json(Settings)
+}
+
+func updateProfile(C *call) {
+
+	U := C.User
+
+	for k, Max := range map[string]int{"name": 50, "url": 100, "location": 30, "description": 160} {
+		if utf8.RuneCountInString(C.param(k)) > Max {
+			C.error(http.StatusForbidden, 120, "Account update failed: "+k+" is too long (maximum is "+strconv.Itoa(Max)+" characters).")
+			return
+		}
+	}
+
+	for k, f := range map[string]*string{
+		"name":               &U.Name,
+		"url":                &U.URL,
+		"location":           &U.Location,
+		"description":        &U.Description,
+		"profile_link_color": &U.ProfileLinkColor,
+	} {
+		if v, ok := C.R.Form[k]; ok {
+			*f = v[0]
+		}
+	}
+
+	C.json(C.S.viewUser(U, U))
+}
+
+// file returns the upload in Name, sent either as a multipart file or base64 in a form field
+func (C *call) file(Name string) ([]byte, bool, error) {
+
+	if C.R.MultipartForm != nil && len(C.R.MultipartForm.File[Name]) > 0 {
+
+		f, err := C.R.MultipartForm.File[Name][0].Open()
+
+		if err != nil {
+			return nil, true, err
+		}
+
+		defer f.Close()
+
+		Data, err := ioutil.ReadAll(f)
+
+		return Data, true, err
+	}
+
+	if C.param(Name) == "" {
+		return nil, false, nil
+	}
+
+	Data, err := base64.StdEncoding.DecodeString(C.param(Name))
+
+	return Data, true, err
+}
+
+// updateImage stores the image in Param as the field of the user Field returns, refusing
+// anything that isn't a JPEG, PNG or GIF of at most MaxBytes
+func updateImage(Param string, MaxBytes int, Field func(*User) *[]byte) func(*call) {
+	return func(C *call) {
+
+		Data, Sent, err := C.file(Param)
+
+		switch {
+		case !Sent:
+			C.error(http.StatusBadRequest, 38, Param+" parameter is missing.")
+			return
+		case err != nil:
+			C.error(http.StatusBadRequest, 44, Param+" parameter is invalid.")
+			return
+		case len(Data) > MaxBytes:
+			C.error(http.StatusRequestEntityTooLarge, 44, "Image is too large.")
+			return
+		}
+
+		if _, _, err := image.DecodeConfig(bytes.NewReader(Data)); err != nil {
+			C.error(http.StatusUnprocessableEntity, 44, "Image could not be processed.")
+			return
+		}
+
+		*Field(C.User) = Data
+
+		C.json(C.S.viewUser(C.User, C.User))
+	}
+}
+
+func removeBanner(C *call) {
+
+	C.User.ProfileBanner = nil
+
+	C.json(C.S.viewUser(C.User, C.User))
+}
+
+func createTweet(C *call) {
+
+	Status := C.param("status")
+	MediaIDs := C.param("media_ids")
+
+	switch {
+	case Status == "" && MediaIDs == "":
+		C.error(http.StatusBadRequest, 170, "Missing required parameter: status.")
+		return
+	case utf8.RuneCountInString(Status) > 280:
+		C.error(http.StatusForbidden, 186, "Tweet needs to be a bit shorter.")
+		return
+	}
+
+	//auto populated replies get the author of the replied tweet in front
+	if R, ok := C.S.tweets[C.param("in_reply_to_status_id")]; ok && C.param("auto_populate_reply_metadata") == "true" && R.User != C.User {
+		Status = "@" + R.User.ScreenName + " " + Status
+	}
+
+	for _, id := range C.S.order {
+		if t, ok := C.S.tweets[id]; ok && Status != "" && t.User == C.User && t.Text == Status && t.RetweetedStatus == nil {
+			C.error(http.StatusForbidden, 187, "Status is a duplicate.")
+			return
+		}
+	}
+
+	id := C.S.newID()
+
+	T := &Tweet{
+		ID:                mustInt(id),
+		IDStr:             id,
+		Text:              Status,
+		CreatedAt:         C.S.Now().Format(time.RubyDate),
+		User:              C.User,
+		PossiblySensitive: C.param("possibly_sensitive") == "true",
+		Lang:              "en",
+		Entities:          C.S.entities(Status),
+	}
+
+	if ReplyID := C.param("in_reply_to_status_id"); ReplyID != "" {
+		if R, ok := C.S.tweets[ReplyID]; ok {
+			T.InReplyToStatusID = &R.ID
+			T.InReplyToStatusIDStr = &R.IDStr
+			T.InReplyToScreenName = &R.User.ScreenName
+		}
+	}
+
+	for _, m := range strings.Split(MediaIDs, ",") {
+
+		if m == "" {
+			continue
+		}
+
+		M, ok := C.S.media[m]
+
+		if !ok || M.Owner != C.User.IDStr {
+			C.error(http.StatusBadRequest, 324, "Invalid media id "+m)
+			return
+		}
+
+		T.Entities.Media = append(T.Entities.Media, *M)
+	}
+
+	C.S.tweets[id] = T
+	C.S.order = append(C.S.order, id)
+	C.User.StatusesCount++
+
+	C.json(C.view(T))
+}
+
+func destroyTweet(C *call) {
+
+	T := C.tweet()
+
+	if T == nil {
+		return
+	}
+
+	if T.User != C.User {
+		C.error(http.StatusForbidden, 183, "You may not delete another user's status.")
+		return
+	}
+
+	V := C.view(T)
+
+	if T.RetweetedStatus != nil {
+		T.RetweetedStatus.RetweetCount--
+	}
+
+	delete(C.S.tweets, T.IDStr)
+	C.User.StatusesCount--
+
+	C.json(V)
+}
+
+func retweet(C *call) {
+
+	T := C.tweet()
+
+	if T == nil {
+		return
+	}
+
+	if T.RetweetedStatus != nil {
+		T = T.RetweetedStatus
+	}
+
+	if C.S.retweetOf(C.User.IDStr, T.IDStr) != nil {
+		C.error(http.StatusForbidden, 327, "You have already retweeted this Tweet.")
+		return
+	}
+
+	id := C.S.newID()
+
+	R := &Tweet{
+		ID:              mustInt(id),
+		IDStr:           id,
+		Text:            "RT @" + T.User.ScreenName + ": " + T.Text,
+		CreatedAt:       C.S.Now().Format(time.RubyDate),
+		User:            C.User,
+		Lang:            T.Lang,
+		RetweetedStatus: T,
+		Entities:        T.Entities,
+	}
+
+	T.RetweetCount++
+	C.S.tweets[id] = R
+	C.S.order = append(C.S.order, id)
+	C.User.StatusesCount++
+
+	C.json(C.view(R))
+}
+
+func unretweet(C *call) {
+
+	T := C.tweet()
+
+	if T == nil {
+		return
+	}
+
+	if T.RetweetedStatus != nil {
+		T = T.RetweetedStatus
+	}
+
+	if R := C.S.retweetOf(C.User.IDStr, T.IDStr); R != nil {
+		delete(C.S.tweets, R.IDStr)
+		T.RetweetCount--
+		C.User.StatusesCount--
+	}
+
+	C.json(C.view(T))
+}
+
+func showTweet(C *call) {
+
+	if T := C.tweet(); T != nil {
+		C.json(C.view(T))
+	}
+}
+
+func lookupTweets(C *call) {
+
+	var T []*Tweet
+
+	for _, id := range strings.Split(C.param("id"), ",") {
+		if t, ok := C.S.tweets[id]; ok {
+			T = append(T, t)
+		}
+	}
+
+	C.json(C.views(T))
+}
+
+func userTimeline(C *call) {
+
+	U := C.User
+
+	if C.param("screen_name") != "" || C.param("user_id") != "" {
+		if U = C.target(); U == nil {
+			return
+		}
+	}
+
+	C.json(C.views(C.timeline(func(t *Tweet) bool { return t.User == U }, 20, 200)))
+}
+
+func homeTimeline(C *call) {
+
+	C.json(C.views(C.timeline(func(t *Tweet) bool {
+		return t.User == C.User || C.S.follows[edge{C.User.IDStr, t.User.IDStr}] && !C.S.mutes[edge{C.User.IDStr, t.User.IDStr}]
+	}, 20, 200)))
+}
+
+func mentionsTimeline(C *call) {
+
+	C.json(C.views(C.timeline(func(t *Tweet) bool {
+
+		if t.RetweetedStatus != nil || C.S.blocks[edge{C.User.IDStr, t.User.IDStr}] {
+			return false
+		}
+
+		for _, m := range t.Entities.UserMentions {
+			if m.IDStr == C.User.IDStr {
+				return true
+			}
+		}
+
+		return false
+	}, 20, 200)))
+}
+
+func retweetsOfMe(C *call) {
+	C.json(C.views(C.timeline(func(t *Tweet) bool {
+		return t.User == C.User && t.RetweetCount > 0
+	}, 20, 100)))
+}
+
+func retweetsByID(C *call) {
+
+	T := C.tweet()
+
+	if T == nil {
+		return
+	}
+
+	C.json(C.views(C.timeline(func(t *Tweet) bool {
+		return t.RetweetedStatus == T
+	}, 100, 100)))
+}
+
+func retweeters(C *call) {
+
+	T := C.tweet()
+
+	if T == nil {
+		return
+	}
+
+	var IDs []string
+
+	for _, t := range C.timeline(func(t *Tweet) bool { return t.RetweetedStatus == T }, 100, 100) {
+		IDs = append(IDs, t.User.IDStr)
+	}
+
+	C.json(cursored(C, "ids", IDs, func(id string) interface{} { return mustInt(id) }))
+}
+
+func favorite(C *call) {
+
+	T := C.tweet()
+
+	if T == nil {
+		return
+	}
+
+	k := edge{C.User.IDStr, T.IDStr}
+
+	if C.S.favs[k] {
+		C.error(http.StatusForbidden, 139, "You have already favorited this status.")
+		return
+	}
+
+	C.S.favs[k] = true
+	T.FavoriteCount++
+	C.User.FavouritesCount++
+
+	C.json(C.view(T))
+}
+
+func unfavorite(C *call) {
+
+	T := C.tweet()
+
+	if T == nil {
+		return
+	}
+
+	k := edge{C.User.IDStr, T.IDStr}
+
+	if !C.S.favs[k] {
+		C.error(http.StatusNotFound, 144, "No status found with that ID.")
+		return
+	}
+
+	delete(C.S.favs, k)
+	T.FavoriteCount--
+	C.User.FavouritesCount--
+
+	C.json(C.view(T))
+}
+
+func favoritesList(C *call) {
+
+	U := C.User
+
+	if C.param("screen_name") != "" || C.param("user_id") != "" {
+		if U = C.target(); U == nil {
+			return
+		}
+	}
+
+	C.json(C.views(C.timeline(func(t *Tweet) bool { return C.S.favs[edge{U.IDStr, t.IDStr}] }, 20, 200)))
+}
+
+func follow(C *call) {
+
+	U := C.target()
+
+	switch {
+	case U == nil:
+		return
+	case U == C.User:
+		C.error(http.StatusForbidden, 158, "You can't follow yourself.")
+		return
+	case C.S.blocks[edge{U.IDStr, C.User.IDStr}]:
+		C.error(http.StatusForbidden, 162, "You have been blocked from following this account at the request of the user.")
+		return
+	}
+
+	if k := (edge{C.User.IDStr, U.IDStr}); !C.S.follows[k] {
+		C.S.follows[k] = true
+		C.User.FriendsCount++
+		U.FollowersCount++
+	}
+
+	C.json(C.S.viewUser(U, C.User))
+}
+
+func unfollow(C *call) {
+
+	U := C.target()
+
+	if U == nil {
+		return
+	}
+
+	C.S.unfollow(C.User, U)
+
+	C.json(C.S.viewUser(U, C.User))
+}
+
+func (S *Server) unfollow(From, To *User) {
+
+	if k := (edge{From.IDStr, To.IDStr}); S.follows[k] {
+		delete(S.follows, k)
+		From.FriendsCount--
+		To.FollowersCount--
+	}
+}
+
+func friendshipShow(C *call) {
+
+	lookup := func(id, name string) *User {
+		if id == "" {
+			id = C.S.names[strings.ToLower(name)]
+		}
+		return C.S.users[id]
+	}
+
+	Source := lookup(C.param("source_id"), C.param("source_screen_name"))
+	Target := lookup(C.param("target_id"), C.param("target_screen_name"))
+
+	if Source == nil || Target == nil {
+		C.error(http.StatusNotFound, 50, "User not found.")
+		return
+	}
+
+	side := func(A, B *User) map[string]interface{} {
+		return map[string]interface{}{
+			"id_str":      A.IDStr,
+			"screen_name": A.ScreenName,
+			"following":   C.S.follows[edge{A.IDStr, B.IDStr}],
+			"followed_by": C.S.follows[edge{B.IDStr, A.IDStr}],
+			"blocking":    C.S.blocks[edge{A.IDStr, B.IDStr}],
+			"muting":      C.S.mutes[edge{A.IDStr, B.IDStr}],
+		}
+	}
+
+	C.json(map[string]interface{}{
+		"relationship": map[string]interface{}{
+			"source": side(Source, Target),
+			"target": side(Target, Source),
+		},
+	})
+}
+
+func pending(C *call) {
+	C.json(cursored(C, "ids", nil, nil))
+}
+
+// related lists the users on the other end of U's edges, Reverse lists who points at U instead
+func (S *Server) related(Edges map[edge]bool, U *User, Reverse bool) []string {
+
+	var IDs []string
+
+	for k := range Edges {
+		switch {
+		case !Reverse && k.From == U.IDStr:
+			IDs = append(IDs, k.To)
+		case Reverse && k.To == U.IDStr:
+			IDs = append(IDs, k.From)
+		}
+	}
+
+	sort.Slice(IDs, func(i, j int) bool { return mustInt(IDs[i]) > mustInt(IDs[j]) })
+
+	return IDs
+}
+
+func edgeIDs(Edges func(*Server) map[edge]bool, Reverse bool) func(*call) {
+	return func(C *call) {
+		if U := C.target(); U != nil {
+			C.json(cursored(C, "ids", C.S.related(Edges(C.S), U, Reverse), func(id string) interface{} { return mustInt(id) }))
+		}
+	}
+}
+
+func edgeList(Edges func(*Server) map[edge]bool, Reverse bool) func(*call) {
+	return func(C *call) {
+		if U := C.target(); U != nil {
+			C.json(cursored(C, "users", C.S.related(Edges(C.S), U, Reverse), C.userByID))
+		}
+	}
+}
+
+func ownIDs(Edges func(*Server) map[edge]bool) func(*call) {
+	return func(C *call) {
+		C.json(cursored(C, "ids", C.S.related(Edges(C.S), C.User, false), func(id string) interface{} { return mustInt(id) }))
+	}
+}
+
+func ownList(Edges func(*Server) map[edge]bool) func(*call) {
+	return func(C *call) {
+		C.json(cursored(C, "users", C.S.related(Edges(C.S), C.User, false), C.userByID))
+	}
+}
+
+func (C *call) userByID(id string) interface{} {
+	return C.S.viewUser(C.S.users[id], C.User)
+}
+
+// cursored pages through IDs using the numeric offset as the cursor
+func cursored(C *call, Key string, IDs []string, Render func(string) interface{}) map[string]interface{} {
+
+	start, _ := strconv.Atoi(C.param("cursor"))
+
+	if start < 0 {
+		start = 0
+	}
+
+	if start > len(IDs) {
+		start = len(IDs)
+	}
+
+	end := start + C.count(5000, 5000)
+	next := end
+
+	if end >= len(IDs) {
+		end = len(IDs)
+		next = 0
+	}
+
+	Items := []interface{}{}
+
+	for _, id := range IDs[start:end] {
+		Items = append(Items, Render(id))
+	}
+
+	return map[string]interface{}{
+		Key:                   Items,
+		"next_cursor":         next,
+		"next_cursor_str":     strconv.Itoa(next),
+		"previous_cursor":     0,
+		"previous_cursor_str": "0",
+	}
+}
+
+func toggle(Edges func(*Server) map[edge]bool, On bool) func(*call) {
+	return func(C *call) {
+
+		U := C.target()
+
+		if U == nil {
+			return
+		}
+
+		if On {
+			Edges(C.S)[edge{C.User.IDStr, U.IDStr}] = true
+		} else {
+			delete(Edges(C.S), edge{C.User.IDStr, U.IDStr})
+		}
+
+		C.json(C.S.viewUser(U, C.User))
+	}
+}
+
+func block(C *call) {
+
+	U := C.target()
+
+	if U == nil {
+		return
+	}
+
+	C.S.blocks[edge{C.User.IDStr, U.IDStr}] = true
+	C.S.unfollow(C.User, U)
+	C.S.unfollow(U, C.User)
+
+	C.json(C.S.viewUser(U, C.User))
+}
+
+func dmList(Sent bool) func(*call) {
+	return func(C *call) {
+
+		since, _ := strconv.ParseInt(C.param("since_id"), 10, 64)
+		max, _ := strconv.ParseInt(C.param("max_id"), 10, 64)
+		count := C.count(20, 200)
+
+		D := []DirectMessage{}
+
+		for i := len(C.S.dmOrder) - 1; i >= 0 && len(D) < count; i-- {
+
+			d, ok := C.S.dms[C.S.dmOrder[i]]
+
+			switch {
+			case !ok:
+			case since != 0 && d.ID <= since:
+			case max != 0 && d.ID > max:
+			case Sent && d.SenderID == C.User.IDStr, !Sent && d.RecipientID == C.User.IDStr:
+				D = append(D, *d)
+			}
+		}
+
+		C.json(D)
+	}
+}
+
+func savedSearchList(C *call) {
+
+	L := []SavedSearch{}
+
+	for _, s := range C.S.saved {
+		if s.Owner == C.User.IDStr {
+			L = append(L, *s)
+		}
+	}
+
+	sort.Slice(L, func(i, j int) bool { return L[i].ID < L[j].ID })
+
+	C.json(L)
+}
+
+func (C *call) savedSearch() *SavedSearch {
+
+	s, ok := C.S.saved[C.ID]
+
+	if !ok || s.Owner != C.User.IDStr {
+		C.error(http.StatusNotFound, 34, "Sorry, that page does not exist.")
+		return nil
+	}
+
+	return s
+}
+
+func savedSearchShow(C *call) {
+
+	if s := C.savedSearch(); s != nil {
+		C.json(s)
+	}
+}
+
+func savedSearchCreate(C *call) {
+
+	Query := C.param("query")
+
+	if Query == "" {
+		C.error(http.StatusBadRequest, 25, "Query parameters are missing.")
+		return
+	}
+
+	var n int
+
+	for _, s := range C.S.saved {
+
+		if s.Owner != C.User.IDStr {
+			continue
+		}
+
+		//saving the same query again returns the existing search
+		if s.Query == Query {
+			C.json(s)
+			return
+		}
+
+		n++
+	}
+
+	if n >= 25 {
+		C.error(http.StatusForbidden, 172, "You have reached the maximum number of saved searches.")
+		return
+	}
+
+	id := C.S.newID()
+
+	s := &SavedSearch{ID: mustInt(id), IDStr: id, Name: Query, Query: Query, CreatedAt: C.S.Now().Format(time.RubyDate), Owner: C.User.IDStr}
+	C.S.saved[id] = s
+
+	C.json(s)
+}
+
+func savedSearchDestroy(C *call) {
+
+	if s := C.savedSearch(); s != nil {
+		delete(C.S.saved, s.IDStr)
+		C.json(s)
+	}
+}
+
+func (C *call) dm() *DirectMessage {
+
+	D, ok := C.S.dms[C.param("id")]
+
+	if !ok || D.SenderID != C.User.IDStr && D.RecipientID != C.User.IDStr {
+		C.error(http.StatusNotFound, 34, "Sorry, that page does not exist.")
+		return nil
+	}
+
+	return D
+}
+
+func dmShow(C *call) {
+
+	if D := C.dm(); D != nil {
+		C.json(D)
+	}
+}
+
+func dmDestroy(C *call) {
+
+	if D := C.dm(); D != nil {
+		delete(C.S.dms, D.IDStr)
+		C.json(D)
+	}
+}
+
+func dmCreate(C *call) {
+
+	U := C.target()
+
+	switch {
+	case U == nil:
+		return
+	case C.param("text") == "":
+		C.error(http.StatusBadRequest, 170, "Missing required parameter: text.")
+		return
+	case !C.S.follows[edge{U.IDStr, C.User.IDStr}] || C.S.blocks[edge{U.IDStr, C.User.IDStr}]:
+		C.error(http.StatusForbidden, 150, "You cannot send messages to users who are not following you.")
+		return
+	}
+
+	id := C.S.newID()
+
+	D := &DirectMessage{
+		ID:                  mustInt(id),
+		IDStr:               id,
+		Text:                C.param("text"),
+		CreatedAt:           C.S.Now().Format(time.RubyDate),
+		SenderID:            C.User.IDStr,
+		RecipientID:         U.IDStr,
+		SenderScreenName:    C.User.ScreenName,
+		RecipientScreenName: U.ScreenName,
+		Sender:              C.User,
+		Recipient:           U,
+	}
+
+	C.S.dms[id] = D
+	C.S.dmOrder = append(C.S.dmOrder, id)
+
+	C.json(D)
+}
+
+func mediaUpload(C *call) {
+
+	var Data []byte
+
+	switch {
+	case C.R.MultipartForm != nil && len(C.R.MultipartForm.File["media"]) > 0:
+
+		f, err := C.R.MultipartForm.File["media"][0].Open()
+
+		if err != nil {
+			C.error(http.StatusBadRequest, 324, err.Error())
+			return
+		}
+
+		defer f.Close()
+
+		Data, _ = ioutil.ReadAll(f)
+
+	case C.param("media_data") != "" || C.param("media") != "":
+
+		raw := C.param("media_data")
+
+		if raw == "" {
+			raw = C.param("media")
+		}
+
+		var err error
+
+		if Data, err = base64.StdEncoding.DecodeString(raw); err != nil {
+			Data = []byte(raw)
+		}
+
+	default:
+		C.error(http.StatusBadRequest, 38, "media parameter is missing.")
+		return
+	}
+
+	id := C.S.newID()
+
+	M := &Media{
+		ID:            mustInt(id),
+		IDStr:         id,
+		MediaID:       mustInt(id),
+		MediaIDString: id,
+		Size:          len(Data),
+		Type:          "photo",
+		MediaURLHTTPS: "https://pbs.twimg.com/media/" + id + ".jpg",
+		Data:          Data,
+		Owner:         C.User.IDStr,
+	}
+
+	C.S.media[id] = M
+
+	C.json(map[string]interface{}{
+		"media_id":           M.MediaID,
+		"media_id_string":    M.MediaIDString,
+		"size":               M.Size,
+		"expires_after_secs": 86400,
+		"image":              map[string]string{"image_type": http.DetectContentType(Data)},
+	})
+}
+
+var termRE = regexp.MustCompile(`-?"[^"]*"|\S+`)
+
+func search(C *call) {
+
+	Query := C.param("q")
+
+	if Query == "" {
+		C.error(http.StatusBadRequest, 25, "Query parameters are missing.")
+		return
+	}
+
+	Terms := termRE.FindAllString(Query, -1)
+
+	Match := func(t *Tweet) bool {
+
+		for _, term := range Terms {
+
+			negate := strings.HasPrefix(term, "-")
+			term = strings.Trim(strings.TrimPrefix(term, "-"), `"`)
+
+			var hit bool
+
+			switch {
+			case strings.HasPrefix(term, "from:"):
+				hit = strings.EqualFold(t.User.ScreenName, strings.TrimPrefix(term, "from:"))
+			case strings.HasPrefix(term, "to:"):
+				hit = t.InReplyToScreenName != nil && strings.EqualFold(*t.InReplyToScreenName, strings.TrimPrefix(term, "to:"))
+			case term == "filter:media":
+				hit = len(t.Entities.Media) > 0
+			case term == "filter:retweets":
+				hit = t.RetweetedStatus != nil
+			case term == "filter:replies":
+				hit = t.InReplyToStatusID != nil
+			default:
+				hit = strings.Contains(strings.ToLower(t.Text), strings.ToLower(term))
+			}
+
+			if hit == negate {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	T := C.timeline(Match, 15, 100)
+
+	Meta := map[string]interface{}{
+		"query":        Query,
+		"count":        len(T),
+		"since_id_str": C.param("since_id"),
+		"max_id_str":   "0",
+	}
+
+	if len(T) > 0 {
+		Meta["max_id_str"] = T[0].IDStr
+	}
+
+	//like the API the last page has no next_results, even when it is full
+	if len(T) > 0 && C.older(Match, T[len(T)-1].ID) {
+		Meta["next_results"] = "?max_id=" + strconv.FormatInt(T[len(T)-1].ID-1, 10) + "&q=" + url.QueryEscape(Query)
+	}
+
+	C.json(map[string]interface{}{
+		"statuses":        C.views(T),
+		"search_metadata": Meta,
+	})
+}
+
+func usersShow(C *call) {
+
+	if U := C.target(); U != nil {
+		C.json(C.S.viewUser(U, C.User))
+	}
+}
+
+func usersLookup(C *call) {
+
+	U := []User{}
+
+	for _, id := range strings.Split(C.param("user_id"), ",") {
+		if u, ok := C.S.users[id]; ok {
+			U = append(U, C.S.viewUser(u, C.User))
+		}
+	}
+
+	for _, name := range strings.Split(C.param("screen_name"), ",") {
+		if u, ok := C.S.users[C.S.names[strings.ToLower(name)]]; ok {
+			U = append(U, C.S.viewUser(u, C.User))
+		}
+	}
+
+	if len(U) == 0 {
+		C.error(http.StatusNotFound, 17, "No user matches for specified terms.")
+		return
+	}
+
+	C.json(U)
+}
+
+func usersSearch(C *call) {
+
+	q := strings.ToLower(C.param("q"))
+	U := []User{}
+
+	for _, u := range C.S.users {
+		if strings.Contains(strings.ToLower(u.ScreenName), q) || strings.Contains(strings.ToLower(u.Name), q) {
+			U = append(U, C.S.viewUser(u, C.User))
+		}
+	}
+
+	sort.Slice(U, func(i, j int) bool { return U[i].ID < U[j].ID })
+
+	C.json(U)
+}
+
+// collection finds the collection named by the id parameter, writing a 404 when there isn't one
+func (C *call) collection(id string) *Collection {
+
+	K, ok := C.S.colls[id]
+
+	if !ok {
+		C.error(http.StatusNotFound, 34, "Sorry, that page does not exist.")
+		return nil
+	}
+
+	return K
+}
+
+// own is collection for the endpoints only the owner may use
+func (C *call) own(id string) *Collection {
+
+	K := C.collection(id)
+
+	if K != nil && K.UserID != C.User.IDStr {
+		C.error(http.StatusForbidden, 200, "Forbidden.")
+		return nil
+	}
+
+	return K
+}
+
+func (C *call) collectionJSON(K *Collection, Response map[string]interface{}, Tweets []*Tweet) {
+
+	Response["timeline_id"] = K.ID
+
+	T := make(map[string]interface{})
+	U := make(map[string]interface{})
+
+	for _, t := range Tweets {
+		v := C.view(t)
+		U[t.User.IDStr] = v.User
+		v.User = &User{ID: t.User.ID, IDStr: t.User.IDStr}
+		T[t.IDStr] = v
+	}
+
+	C.json(map[string]interface{}{
+		"objects": map[string]interface{}{
+			"tweets":    T,
+			"users":     U,
+			"timelines": map[string]interface{}{K.ID: K},
+		},
+		"response": Response,
+	})
+}
+
+var timelineOrders = map[string]bool{"curation_reverse_chron": true, "tweet_chron": true, "tweet_reverse_chron": true}
+
+func collectionShow(C *call) {
+
+	if K := C.collection(C.param("id")); K != nil {
+		C.collectionJSON(K, map[string]interface{}{}, nil)
+	}
+}
+
+func collectionCreate(C *call) {
+
+	Order := C.param("timeline_order")
+
+	switch {
+	case C.param("name") == "":
+		C.error(http.StatusBadRequest, 38, "name parameter is missing.")
+		return
+	case Order == "":
+		Order = "curation_reverse_chron"
+	case !timelineOrders[Order]:
+		C.error(http.StatusBadRequest, 44, "timeline_order parameter is invalid.")
+		return
+	}
+
+	id := C.S.newID()
+
+	K := &Collection{
+		ID:             "custom-" + id,
+		Name:           C.param("name"),
+		Description:    C.param("description"),
+		URL:            C.param("url"),
+		CollectionURL:  "https://twitter.com/" + C.User.ScreenName + "/timelines/" + id,
+		UserID:         C.User.IDStr,
+		Visibility:     "public",
+		TimelineOrder:  Order,
+		CollectionType: "user",
+	}
+
+	C.S.colls[K.ID] = K
+
+	C.collectionJSON(K, map[string]interface{}{}, nil)
+}
+
+func collectionUpdate(C *call) {
+
+	K := C.own(C.param("id"))
+
+	if K == nil {
+		return
+	}
+
+	for k, f := range map[string]*string{"name": &K.Name, "description": &K.Description, "url": &K.URL} {
+		if v, ok := C.R.Form[k]; ok {
+			*f = v[0]
+		}
+	}
+
+	C.collectionJSON(K, map[string]interface{}{}, nil)
+}
+
+func collectionDestroy(C *call) {
+
+	if K := C.own(C.param("id")); K != nil {
+		delete(C.S.colls, K.ID)
+		C.json(map[string]interface{}{"destroyed": true})
+	}
+}
+
+func collectionEntries(C *call) {
+
+	K := C.collection(C.param("id"))
+
+	if K == nil {
+		return
+	}
+
+	var Tweets []*Tweet
+
+	for _, id := range K.Entries {
+		if t, ok := C.S.tweets[id]; ok {
+			Tweets = append(Tweets, t)
+		}
+	}
+
+	switch K.TimelineOrder {
+	case "tweet_chron":
+		sort.Slice(Tweets, func(i, j int) bool { return Tweets[i].ID < Tweets[j].ID })
+	case "tweet_reverse_chron":
+		sort.Slice(Tweets, func(i, j int) bool { return Tweets[i].ID > Tweets[j].ID })
+	}
+
+	//positions count down from the top so later pages have lower ones
+	Max, _ := strconv.Atoi(C.param("max_position"))
+	Min, _ := strconv.Atoi(C.param("min_position"))
+	Count := C.count(200, 200)
+
+	var Page []*Tweet
+	var Timeline []interface{}
+	var Truncated bool
+	var Positions []int
+
+	for i, t := range Tweets {
+
+		Position := len(Tweets) - i
+
+		if Max > 0 && Position >= Max || Position <= Min {
+			continue
+		}
+
+		if len(Page) == Count {
+			Truncated = true
+			break
+		}
+
+		Page = append(Page, t)
+		Positions = append(Positions, Position)
+		Timeline = append(Timeline, map[string]interface{}{"tweet": map[string]string{"id": t.IDStr, "sort_index": strconv.Itoa(Position)}})
+	}
+
+	Position := map[string]interface{}{"was_truncated": Truncated}
+
+	if len(Positions) > 0 {
+		Position["max_position"] = strconv.Itoa(Positions[0])
+		Position["min_position"] = strconv.Itoa(Positions[len(Positions)-1])
+	}
+
+	C.collectionJSON(K, map[string]interface{}{"position": Position, "timeline": Timeline}, Page)
+}
+
+// change applies one entry operation to K, returning why it was refused or ""
+func (S *Server) change(K *Collection, Op, TweetID, RelativeTo string, Above bool) string {
+
+	at := -1
+
+	for i, id := range K.Entries {
+		if id == TweetID {
+			at = i
+		}
+	}
+
+	switch Op {
+
+	case "add":
+		if _, ok := S.tweets[TweetID]; !ok {
+			return "not_found"
+		}
+		if at >= 0 {
+			return "duplicate"
+		}
+
+	case "remove", "move":
+		if at < 0 {
+			return "not_found"
+		}
+		K.Entries = append(K.Entries[:at:at], K.Entries[at+1:]...)
+
+		if Op == "remove" {
+			return ""
+		}
+	}
+
+	//new entries go on top unless placed relative to another one
+	Insert := 0
+
+	if RelativeTo != "" {
+
+		Insert = -1
+
+		for i, id := range K.Entries {
+			if id == RelativeTo {
+				Insert = i
+			}
+		}
+
+		if Insert < 0 {
+			return "not_found"
+		}
+
+		if !Above {
+			Insert++
+		}
+	}
+
+	K.Entries = append(K.Entries[:Insert:Insert], append([]string{TweetID}, K.Entries[Insert:]...)...)
+
+	return ""
+}
+
+func collectionChange(C *call) {
+
+	K := C.own(C.param("id"))
+
+	if K == nil {
+		return
+	}
+
+	var Errors []interface{}
+
+	if Reason := C.S.change(K, C.ID, C.param("tweet_id"), C.param("relative_to"), C.param("above") == "true"); Reason != "" {
+		Errors = append(Errors, map[string]interface{}{"change": map[string]string{"op": C.ID, "tweet_id": C.param("tweet_id")}, "reason": Reason})
+	}
+
+	C.json(map[string]interface{}{"objects": map[string]interface{}{}, "response": map[string]interface{}{"errors": Errors}})
+}
+
+func collectionCurate(C *call) {
+
+	var Body struct {
+		ID      string `json:"id"`
+		Changes []struct {
+			Op      string `json:"op"`
+			TweetID string `json:"tweet_id"`
+		} `json:"changes"`
+	}
+
+	if err := json.NewDecoder(C.R.Body).Decode(&Body); err != nil {
+		C.error(http.StatusBadRequest, 44, "Invalid JSON body.")
+		return
+	}
+
+	K := C.own(Body.ID)
+
+	if K == nil {
+		return
+	}
+
+	if len(Body.Changes) > 100 {
+		C.error(http.StatusBadRequest, 44, "changes parameter is invalid.")
+		return
+	}
+
+	var Errors []interface{}
+
+	for _, c := range Body.Changes {
+		if Reason := C.S.change(K, c.Op, c.TweetID, "", false); Reason != "" {
+			Errors = append(Errors, map[string]interface{}{"change": c, "reason": Reason})
+		}
+	}
+
+	C.json(map[string]interface{}{"objects": map[string]interface{}{}, "response": map[string]interface{}{"errors": Errors}})
+}
+
+// defaultConfiguration is the help/configuration a new server returns
+func defaultConfiguration() map[string]interface{} {
+	return map[string]interface{}{
+		"characters_reserved_per_media": 24,
+		"dm_text_character_limit":       10000,
+		"max_media_per_upload":          1,
+		"non_username_paths":            []string{"about", "account", "search", "settings"},
+		"photo_size_limit":              3145728,
+		"photo_sizes": map[string]interface{}{
+			"thumb":  map[string]interface{}{"w": 150, "h": 150, "resize": "crop"},
+			"small":  map[string]interface{}{"w": 680, "h": 680, "resize": "fit"},
+			"medium": map[string]interface{}{"w": 1200, "h": 1200, "resize": "fit"},
+			"large":  map[string]interface{}{"w": 2048, "h": 2048, "resize": "fit"},
+		},
+		"short_url_length":       23,
+		"short_url_length_https": 23,
+	}
+}
+
+func helpConfiguration(C *call) {
+	C.json(C.S.Configuration)
+}
+
+func helpLanguages(C *call) {
+	C.json([]map[string]interface{}{
+		{"code": "en", "name": "English", "local_name": "English", "status": "production", "debug": false},
+		{"code": "fr", "name": "French", "local_name": "français", "status": "production", "debug": false},
+		{"code": "ja", "name": "Japanese", "local_name": "日本語", "status": "production", "debug": false},
+	})
+}
+
+func helpPrivacy(C *call) {
+	C.json(map[string]string{"privacy": "Fake server privacy policy."})
+}
+
+func helpTOS(C *call) {
+	C.json(map[string]string{"tos": "Fake server terms of service."})
+}
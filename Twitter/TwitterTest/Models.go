@@ -0,0 +1,231 @@
+package TwitterTest
+
+import (
+	"regexp"
+	"strings"
+)
+
+// User is the fake's view of a Twitter user, it renders the same JSON fields as the real API
+type User struct {
+	ID               int64  `json:"id"`
+	IDStr            string `json:"id_str"`
+	ScreenName       string `json:"screen_name"`
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	Location         string `json:"location"`
+	URL              string `json:"url"`
+	ProfileLinkColor string `json:"profile_link_color"`
+	CreatedAt        string `json:"created_at"`
+	FollowersCount   int    `json:"followers_count"`
+	FriendsCount     int    `json:"friends_count"`
+	StatusesCount    int    `json:"statuses_count"`
+	FavouritesCount  int    `json:"favourites_count"`
+	Protected        bool   `json:"protected"`
+	Following        bool   `json:"following"`
+	Muting           bool   `json:"muting"`
+	Blocking         bool   `json:"blocking"`
+
+	//ProfileImage, ProfileBanner and ProfileBackground are the last images uploaded
+	ProfileImage      []byte `json:"-"`
+	ProfileBanner     []byte `json:"-"`
+	ProfileBackground []byte `json:"-"`
+}
+
+// Tweet is a stored status
+type Tweet struct {
+	ID                   int64    `json:"id"`
+	IDStr                string   `json:"id_str"`
+	Text                 string   `json:"text,omitempty"`
+	FullText             string   `json:"full_text,omitempty"`
+	Truncated            bool     `json:"truncated"`
+	CreatedAt            string   `json:"created_at"`
+	User                 *User    `json:"user"`
+	InReplyToStatusID    *int64   `json:"in_reply_to_status_id"`
+	InReplyToStatusIDStr *string  `json:"in_reply_to_status_id_str"`
+	InReplyToScreenName  *string  `json:"in_reply_to_screen_name"`
+	RetweetCount         int      `json:"retweet_count"`
+	FavoriteCount        int      `json:"favorite_count"`
+	Favorited            bool     `json:"favorited"`
+	Retweeted            bool     `json:"retweeted"`
+	PossiblySensitive    bool     `json:"possibly_sensitive"`
+	Lang                 string   `json:"lang"`
+	RetweetedStatus      *Tweet   `json:"retweeted_status,omitempty"`
+	Entities             Entities `json:"entities"`
+}
+
+// Entities are the hashtags, mentions, urls and media parsed out of a tweet
+type Entities struct {
+	Hashtags     []Hashtag `json:"hashtags"`
+	UserMentions []Mention `json:"user_mentions"`
+	URLs         []Link    `json:"urls"`
+	Media        []Media   `json:"media,omitempty"`
+}
+
+type Hashtag struct {
+	Text    string `json:"text"`
+	Indices [2]int `json:"indices"`
+}
+
+type Mention struct {
+	ScreenName string `json:"screen_name"`
+	IDStr      string `json:"id_str"`
+	Indices    [2]int `json:"indices"`
+}
+
+type Link struct {
+	URL         string `json:"url"`
+	ExpandedURL string `json:"expanded_url"`
+	Indices     [2]int `json:"indices"`
+}
+
+// Media is an uploaded file, the same struct is used for the upload response and tweet entities
+type Media struct {
+	ID            int64  `json:"id"`
+	IDStr         string `json:"id_str"`
+	MediaID       int64  `json:"media_id"`
+	MediaIDString string `json:"media_id_string"`
+	Size          int    `json:"size"`
+	Type          string `json:"type"`
+	MediaURLHTTPS string `json:"media_url_https"`
+	Data          []byte `json:"-"`
+	Owner         string `json:"-"`
+}
+
+// DirectMessage is a stored direct message
+type DirectMessage struct {
+	ID                  int64  `json:"id"`
+	IDStr               string `json:"id_str"`
+	Text                string `json:"text"`
+	CreatedAt           string `json:"created_at"`
+	SenderID            string `json:"sender_id_str"`
+	RecipientID         string `json:"recipient_id_str"`
+	SenderScreenName    string `json:"sender_screen_name"`
+	RecipientScreenName string `json:"recipient_screen_name"`
+	Sender              *User  `json:"sender"`
+	Recipient           *User  `json:"recipient"`
+}
+
+// SavedSearch is a stored saved search
+type SavedSearch struct {
+	ID        int64  `json:"id"`
+	IDStr     string `json:"id_str"`
+	Name      string `json:"name"`
+	Query     string `json:"query"`
+	Position  *int   `json:"position"`
+	CreatedAt string `json:"created_at"`
+	Owner     string `json:"-"`
+}
+
+// Settings are a user's account settings, rendered like account/settings
+type Settings struct {
+	ScreenName string `json:"screen_name"`
+	Language   string `json:"language"`
+	Protected  bool   `json:"protected"`
+	TimeZone   struct {
+		Name       string `json:"name"`
+		UTCOffset  int    `json:"utc_offset"`
+		TZInfoName string `json:"tzinfo_name"`
+	} `json:"time_zone"`
+	SleepTime struct {
+		Enabled   bool `json:"enabled"`
+		StartTime *int `json:"start_time"`
+		EndTime   *int `json:"end_time"`
+	} `json:"sleep_time"`
+	TrendLocation []map[string]interface{} `json:"trend_location"`
+}
+
+// Collection is a stored collection, Entries are its tweet IDs in curation order, top first
+type Collection struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	URL            string   `json:"url"`
+	CollectionURL  string   `json:"collection_url"`
+	UserID         string   `json:"user_id"`
+	Visibility     string   `json:"visibility"`
+	TimelineOrder  string   `json:"timeline_order"`
+	CollectionType string   `json:"collection_type"`
+	ID             string   `json:"-"`
+	Entries        []string `json:"-"`
+}
+
+var (
+	hashtagRE = regexp.MustCompile(`#(\w+)`)
+	mentionRE = regexp.MustCompile(`@(\w+)`)
+	linkRE    = regexp.MustCompile(`https?://\S+`)
+)
+
+func (S *Server) entities(Text string) Entities {
+
+	E := Entities{Hashtags: []Hashtag{}, UserMentions: []Mention{}, URLs: []Link{}}
+
+	for _, m := range hashtagRE.FindAllStringSubmatchIndex(Text, -1) {
+		E.Hashtags = append(E.Hashtags, Hashtag{Text: Text[m[2]:m[3]], Indices: [2]int{m[0], m[1]}})
+	}
+
+	for _, m := range mentionRE.FindAllStringSubmatchIndex(Text, -1) {
+
+		M := Mention{ScreenName: Text[m[2]:m[3]], Indices: [2]int{m[0], m[1]}}
+
+		if id, ok := S.names[strings.ToLower(M.ScreenName)]; ok {
+			M.IDStr = id
+		}
+
+		E.UserMentions = append(E.UserMentions, M)
+	}
+
+	for _, m := range linkRE.FindAllStringIndex(Text, -1) {
+		E.URLs = append(E.URLs, Link{URL: Text[m[0]:m[1]], ExpandedURL: Text[m[0]:m[1]], Indices: [2]int{m[0], m[1]}})
+	}
+
+	return E
+}
+
+// view renders a tweet from the point of view of Viewer
+func (S *Server) view(T *Tweet, Viewer *User) Tweet {
+
+	V := *T
+	u := S.viewUser(T.User, Viewer)
+	V.User = &u
+	V.Favorited = S.favs[edge{Viewer.IDStr, T.IDStr}]
+	V.Retweeted = S.retweetOf(Viewer.IDStr, T.IDStr) != nil
+
+	if T.RetweetedStatus != nil {
+		R := S.view(T.RetweetedStatus, Viewer)
+		V.RetweetedStatus = &R
+	}
+
+	return V
+}
+
+func (S *Server) viewUser(U *User, Viewer *User) User {
+
+	V := *U
+	V.Following = S.follows[edge{Viewer.IDStr, U.IDStr}]
+	V.Muting = S.mutes[edge{Viewer.IDStr, U.IDStr}]
+	V.Blocking = S.blocks[edge{Viewer.IDStr, U.IDStr}]
+
+	return V
+}
+
+func (S *Server) views(T []*Tweet, Viewer *User) []Tweet {
+
+	V := make([]Tweet, 0, len(T))
+
+	for _, t := range T {
+		V = append(V, S.view(t, Viewer))
+	}
+
+	return V
+}
+
+// retweetOf finds UserID's retweet of TweetID
+func (S *Server) retweetOf(UserID, TweetID string) *Tweet {
+
+	for _, t := range S.tweets {
+		if t.RetweetedStatus != nil && t.RetweetedStatus.IDStr == TweetID && t.User.IDStr == UserID {
+			return t
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package TwitterTest
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+func (S *Server) authenticate(r *http.Request) (*User, *twitterError) {
+
+	header := r.Header.Get("Authorization")
+
+	if !strings.HasPrefix(header, "OAuth ") {
+		return nil, &twitterError{Code: 215, Message: "Bad Authentication data."}
+	}
+
+	OAuth := parseAuthorization(strings.TrimPrefix(header, "OAuth "))
+
+	if OAuth["oauth_consumer_key"] != S.ConsumerKey {
+		return nil, &twitterError{Code: 32, Message: "Could not authenticate you."}
+	}
+
+	cred, ok := S.tokens[OAuth["oauth_token"]]
+
+	if !ok {
+		return nil, &twitterError{Code: 89, Message: "Invalid or expired token."}
+	}
+
+	if S.VerifySignatures {
+
+		if OAuth["oauth_signature_method"] != "HMAC-SHA1" {
+			return nil, &twitterError{Code: 32, Message: "Could not authenticate you."}
+		}
+
+		expected := signature(r, OAuth, S.ConsumerSecret, cred.Secret)
+
+		if !hmac.Equal([]byte(expected), []byte(OAuth["oauth_signature"])) {
+			return nil, &twitterError{Code: 32, Message: "Could not authenticate you."}
+		}
+	}
+
+	return S.users[cred.UserID], nil
+}
+
+func parseAuthorization(header string) map[string]string {
+
+	P := make(map[string]string)
+
+	for _, part := range strings.Split(header, ",") {
+
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+
+		if len(kv) != 2 {
+			continue
+		}
+
+		v, err := url.QueryUnescape(strings.Trim(kv[1], `"`))
+
+		if err != nil {
+			continue
+		}
+
+		P[kv[0]] = v
+	}
+
+	return P
+}
+
+// signature rebuilds the OAuth 1.0a signature base string (RFC 5849 section 3.4.1) for the request as the client saw it
+func signature(r *http.Request, OAuth map[string]string, ConsumerSecret, TokenSecret string) string {
+
+	scheme := r.Header.Get("X-Forwarded-Proto")
+
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	host := strings.ToLower(r.Host)
+	host = strings.TrimSuffix(host, map[string]string{"http": ":80", "https": ":443"}[scheme])
+
+	var P [][2]string
+
+	add := func(k, v string) {
+		P = append(P, [2]string{encode(k), encode(v)})
+	}
+
+	for k, vs := range r.URL.Query() {
+		for _, v := range vs {
+			add(k, v)
+		}
+	}
+
+	//multipart bodies aren't part of the signature, only url encoded ones are
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		for k, vs := range r.PostForm {
+			for _, v := range vs {
+				add(k, v)
+			}
+		}
+	}
+
+	for k, v := range OAuth {
+		if k != "oauth_signature" {
+			add(k, v)
+		}
+	}
+
+	sort.Slice(P, func(i, j int) bool {
+		if P[i][0] != P[j][0] {
+			return P[i][0] < P[j][0]
+		}
+		return P[i][1] < P[j][1]
+	})
+
+	pairs := make([]string, len(P))
+
+	for i, kv := range P {
+		pairs[i] = kv[0] + "=" + kv[1]
+	}
+
+	base := strings.Join([]string{
+		encode(strings.ToUpper(r.Method)),
+		encode(scheme + "://" + host + r.URL.EscapedPath()),
+		encode(strings.Join(pairs, "&")),
+	}, "&")
+
+	h := hmac.New(sha1.New, []byte(encode(ConsumerSecret)+"&"+encode(TokenSecret)))
+	h.Write([]byte(base))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// encode percent encodes everything but the RFC 3986 unreserved characters
+func encode(s string) string {
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+
+		c := s[i]
+
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		default:
+			b.WriteString("%" + strings.ToUpper(hex(c)))
+		}
+	}
+
+	return b.String()
+}
+
+func hex(c byte) string {
+	const digits = "0123456789abcdef"
+	return string([]byte{digits[c>>4], digits[c&15]})
+}
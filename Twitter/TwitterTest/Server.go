@@ -0,0 +1,497 @@
+// Package TwitterTest is an in-memory fake of the Twitter REST API for running
+// the library (and anything built on it) end to end without touching the network.
+//
+//	srv := TwitterTest.NewServer("KEY", "SECRET")
+//	defer srv.Close()
+//
+//	srv.AddUser("gopher")
+//	tok, sec := srv.Login("gopher")
+//
+//	TwitterAPI.HTTPClient = srv.Client()
+//	T := TwitterAPI.Account{ConsumerKey: "KEY", ConsumerSecret: "SECRET"}
+//	T.SetAccessToken(tok, sec)
+package TwitterTest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault is an error the server returns instead of handling a request
+type Fault struct {
+	Status  int
+	Code    int
+	Message string
+	//Drop closes the connection without writing a response, simulating a network failure
+	Drop bool
+	//Delay is slept before the fault (or the real handler when Status and Drop are unset) is served
+	Delay time.Duration
+	//AfterHandler lets the real handler run (and mutate state) before the fault is returned
+	AfterHandler bool
+}
+
+// Request is a record of a call made against the server
+type Request struct {
+	Method string
+	Path   string
+	Params url.Values
+	User   string
+}
+
+type limit struct {
+	Limit  int
+	Window time.Duration
+	used   map[string]int
+	reset  map[string]time.Time
+}
+
+type fault struct {
+	Fault
+	times int
+}
+
+// Server is a fake api.twitter.com / upload.twitter.com
+type Server struct {
+	*httptest.Server
+
+	ConsumerKey    string
+	ConsumerSecret string
+
+	//VerifySignatures rejects requests whose OAuth signature doesn't match, on by default
+	VerifySignatures bool
+
+	//Now is the server's clock, override it to control created_at and rate limit resets
+	Now func() time.Time
+
+	//Configuration is what help/configuration returns, change it to test limits
+	Configuration map[string]interface{}
+
+	mu       sync.Mutex
+	nextID   int64
+	users    map[string]*User
+	names    map[string]string
+	tokens   map[string]credential
+	tweets   map[string]*Tweet
+	order    []string
+	follows  map[edge]bool
+	blocks   map[edge]bool
+	mutes    map[edge]bool
+	favs     map[edge]bool
+	dms      map[string]*DirectMessage
+	dmOrder  []string
+	saved    map[string]*SavedSearch
+	colls    map[string]*Collection
+	media    map[string]*Media
+	settings map[string]*Settings
+	limits   map[string]*limit
+	faults   map[string][]*fault
+	requests []Request
+	routes   []route
+}
+
+type credential struct {
+	Secret string
+	UserID string
+}
+
+type edge struct {
+	From string
+	To   string
+}
+
+type route struct {
+	Method  string
+	Pattern *regexp.Regexp
+	Handler func(*call)
+}
+
+// NewServer starts a fake API that accepts requests signed with the given consumer key and secret
+func NewServer(ConsumerKey, ConsumerSecret string) *Server {
+
+	S := &Server{
+		ConsumerKey:      ConsumerKey,
+		ConsumerSecret:   ConsumerSecret,
+		VerifySignatures: true,
+		Now:              time.Now,
+		Configuration:    defaultConfiguration(),
+		nextID:           1000,
+		users:            make(map[string]*User),
+		names:            make(map[string]string),
+		tokens:           make(map[string]credential),
+		tweets:           make(map[string]*Tweet),
+		follows:          make(map[edge]bool),
+		blocks:           make(map[edge]bool),
+		mutes:            make(map[edge]bool),
+		favs:             make(map[edge]bool),
+		dms:              make(map[string]*DirectMessage),
+		saved:            make(map[string]*SavedSearch),
+		colls:            make(map[string]*Collection),
+		media:            make(map[string]*Media),
+		settings:         make(map[string]*Settings),
+		limits:           make(map[string]*limit),
+		faults:           make(map[string][]*fault),
+	}
+
+	S.registerRoutes()
+
+	S.Server = httptest.NewServer(http.HandlerFunc(S.serve))
+
+	return S
+}
+
+// Client returns an http.Client that sends every request, whatever its host, to the fake server
+func (S *Server) Client() *http.Client {
+
+	target, _ := url.Parse(S.URL)
+
+	return &http.Client{Transport: &rewriter{target: target, next: S.Server.Client().Transport}}
+}
+
+type rewriter struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (R *rewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	r := req.Clone(req.Context())
+
+	//the signature was computed against the real URL, keep it around so the server can check it
+	r.Host = req.URL.Host
+	r.Header.Set("X-Forwarded-Proto", req.URL.Scheme)
+
+	r.URL.Scheme = R.target.Scheme
+	r.URL.Host = R.target.Host
+
+	return R.next.RoundTrip(r)
+}
+
+// AddUser creates a user and returns it, adding an existing screen name returns the existing user
+func (S *Server) AddUser(ScreenName string) *User {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	if id, ok := S.names[strings.ToLower(ScreenName)]; ok {
+		return S.users[id]
+	}
+
+	return S.addUser(ScreenName)
+}
+
+func (S *Server) addUser(ScreenName string) *User {
+
+	id := S.newID()
+
+	U := &User{
+		ID:         mustInt(id),
+		IDStr:      id,
+		ScreenName: ScreenName,
+		Name:       ScreenName,
+		CreatedAt:  S.Now().Format(time.RubyDate),
+	}
+
+	S.users[id] = U
+	S.names[strings.ToLower(ScreenName)] = id
+	Settings := &Settings{ScreenName: ScreenName, Language: "en"}
+	Settings.TimeZone.Name, Settings.TimeZone.TZInfoName = "UTC", "Etc/UTC"
+
+	S.settings[id] = Settings
+
+	return U
+}
+
+// Login issues an access token and secret for ScreenName, creating the user if needed
+func (S *Server) Login(ScreenName string) (string, string) {
+
+	U := S.AddUser(ScreenName)
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	Token := fmt.Sprintf("%s-%s", U.IDStr, S.newID())
+	Secret := fmt.Sprintf("secret-%s", S.newID())
+
+	S.tokens[Token] = credential{Secret: Secret, UserID: U.IDStr}
+
+	return Token, Secret
+}
+
+// SetRateLimit allows Limit calls to Path (e.g. "statuses/update.json") per token every Window
+func (S *Server) SetRateLimit(Path string, Limit int, Window time.Duration) {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	S.limits[strings.TrimPrefix(Path, "/")] = &limit{
+		Limit:  Limit,
+		Window: Window,
+		used:   make(map[string]int),
+		reset:  make(map[string]time.Time),
+	}
+}
+
+// InjectFault makes the next Times calls to Path fail with F
+func (S *Server) InjectFault(Path string, F Fault, Times int) {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	Path = strings.TrimPrefix(Path, "/")
+
+	S.faults[Path] = append(S.faults[Path], &fault{Fault: F, times: Times})
+}
+
+// InjectError makes the next Times calls to Path return a Twitter style error
+func (S *Server) InjectError(Path string, Status, Code int, Message string, Times int) {
+	S.InjectFault(Path, Fault{Status: Status, Code: Code, Message: Message}, Times)
+}
+
+// Requests returns every request the server has handled so far
+func (S *Server) Requests() []Request {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	return append([]Request(nil), S.requests...)
+}
+
+// Tweets returns the tweets currently stored, newest first
+func (S *Server) Tweets() []Tweet {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	var T []Tweet
+
+	for i := len(S.order) - 1; i >= 0; i-- {
+		if t, ok := S.tweets[S.order[i]]; ok {
+			T = append(T, *t)
+		}
+	}
+
+	return T
+}
+
+// DirectMessages returns the direct messages currently stored, newest first
+func (S *Server) DirectMessages() []DirectMessage {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	var D []DirectMessage
+
+	for i := len(S.dmOrder) - 1; i >= 0; i-- {
+		if d, ok := S.dms[S.dmOrder[i]]; ok {
+			D = append(D, *d)
+		}
+	}
+
+	return D
+}
+
+func (S *Server) newID() string {
+	S.nextID++
+	return strconv.FormatInt(S.nextID, 10)
+}
+
+func (S *Server) serve(w http.ResponseWriter, r *http.Request) {
+
+	path := strings.TrimPrefix(r.URL.Path, "/1.1/")
+
+	//like Twitter's upload endpoints, refuse multipart bodies without a length
+	if r.ContentLength < 0 && strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		writeError(w, http.StatusLengthRequired, 0, "Length Required")
+		return
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+
+	if f := S.takeFault(path); f != nil {
+
+		time.Sleep(f.Delay)
+
+		if f.AfterHandler {
+
+			rec := httptest.NewRecorder()
+			S.dispatch(rec, r, path)
+
+			if !f.Drop && f.Status == 0 {
+				copyResponse(w, rec)
+				return
+			}
+		}
+
+		switch {
+		case f.Drop:
+			dropConnection(w)
+			return
+		case f.Status != 0:
+			writeError(w, f.Status, f.Code, f.Message)
+			return
+		}
+	}
+
+	S.dispatch(w, r, path)
+}
+
+func (S *Server) dispatch(w http.ResponseWriter, r *http.Request, path string) {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	User, err := S.authenticate(r)
+
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Code, err.Message)
+		return
+	}
+
+	S.requests = append(S.requests, Request{Method: r.Method, Path: path, Params: r.Form, User: User.IDStr})
+
+	if !S.allow(w, path, User.IDStr) {
+		writeError(w, http.StatusTooManyRequests, 88, "Rate limit exceeded")
+		return
+	}
+
+	found := false
+
+	for _, rt := range S.routes {
+
+		m := rt.Pattern.FindStringSubmatch(path)
+
+		if m == nil {
+			continue
+		}
+
+		found = true
+
+		if rt.Method != r.Method {
+			continue
+		}
+
+		C := &call{S: S, W: w, R: r, User: User}
+
+		if len(m) > 1 {
+			C.ID = m[1]
+		}
+
+		rt.Handler(C)
+
+		return
+	}
+
+	if found {
+		writeError(w, http.StatusMethodNotAllowed, 0, "Method not allowed")
+		return
+	}
+
+	writeError(w, http.StatusNotFound, 34, "Sorry, that page does not exist.")
+}
+
+func (S *Server) takeFault(path string) *Fault {
+
+	S.mu.Lock()
+	defer S.mu.Unlock()
+
+	F := S.faults[path]
+
+	if len(F) == 0 {
+		return nil
+	}
+
+	f := F[0]
+	f.times--
+
+	if f.times <= 0 {
+		S.faults[path] = F[1:]
+	}
+
+	return &f.Fault
+}
+
+func (S *Server) allow(w http.ResponseWriter, path, UserID string) bool {
+
+	L, ok := S.limits[path]
+
+	if !ok {
+		return true
+	}
+
+	now := S.Now()
+
+	if reset, ok := L.reset[UserID]; !ok || !now.Before(reset) {
+		L.reset[UserID] = now.Add(L.Window)
+		L.used[UserID] = 0
+	}
+
+	allowed := L.used[UserID] < L.Limit
+
+	if allowed {
+		L.used[UserID]++
+	}
+
+	w.Header().Set("x-rate-limit-limit", strconv.Itoa(L.Limit))
+	w.Header().Set("x-rate-limit-remaining", strconv.Itoa(L.Limit-L.used[UserID]))
+	w.Header().Set("x-rate-limit-reset", strconv.FormatInt(L.reset[UserID].Unix(), 10))
+
+	return allowed
+}
+
+type twitterError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, Status, Code int, Message string) {
+	writeJSON(w, Status, map[string][]twitterError{"errors": {{Code: Code, Message: Message}}})
+}
+
+func writeJSON(w http.ResponseWriter, Status int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(Status)
+
+	json.NewEncoder(w).Encode(v)
+}
+
+func dropConnection(w http.ResponseWriter) {
+
+	hj, ok := w.(http.Hijacker)
+
+	if !ok {
+		panic("TwitterTest: response writer can't be hijacked")
+	}
+
+	conn, _, err := hj.Hijack()
+
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func copyResponse(w http.ResponseWriter, rec *httptest.ResponseRecorder) {
+
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+}
+
+func mustInt(s string) int64 {
+	i, _ := strconv.ParseInt(s, 10, 64)
+	return i
+}
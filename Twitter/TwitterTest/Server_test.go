@@ -0,0 +1,222 @@
+package TwitterTest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/garyburd/go-oauth/oauth"
+)
+
+const api = "https://api.twitter.com/1.1/"
+
+func get(t *testing.T, S *Server, Consumer, Token oauth.Credentials, Path string, Params url.Values) (*http.Response, twitterError) {
+
+	t.Helper()
+
+	C := oauth.Client{Credentials: Consumer}
+
+	resp, err := C.Get(S.Client(), &Token, api+Path, Params)
+
+	if err != nil {
+		t.Fatalf("GET %s: %v", Path, err)
+	}
+
+	defer resp.Body.Close()
+
+	var body struct {
+		Errors []twitterError `json:"errors"`
+	}
+
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	if len(body.Errors) > 0 {
+		return resp, body.Errors[0]
+	}
+
+	return resp, twitterError{}
+}
+
+func TestAuthenticate(t *testing.T) {
+
+	S := NewServer("ck", "cs")
+	defer S.Close()
+
+	Token, Secret := S.Login("gopher")
+
+	tests := []struct {
+		name     string
+		consumer oauth.Credentials
+		token    oauth.Credentials
+		verify   bool
+		status   int
+		code     int
+	}{
+		{"signed", oauth.Credentials{Token: "ck", Secret: "cs"}, oauth.Credentials{Token: Token, Secret: Secret}, true, 200, 0},
+		{"unknown consumer key", oauth.Credentials{Token: "other", Secret: "cs"}, oauth.Credentials{Token: Token, Secret: Secret}, true, 401, 32},
+		{"unknown token", oauth.Credentials{Token: "ck", Secret: "cs"}, oauth.Credentials{Token: "nope", Secret: Secret}, true, 401, 89},
+		{"wrong consumer secret", oauth.Credentials{Token: "ck", Secret: "wrong"}, oauth.Credentials{Token: Token, Secret: Secret}, true, 401, 32},
+		{"wrong token secret", oauth.Credentials{Token: "ck", Secret: "cs"}, oauth.Credentials{Token: Token, Secret: "wrong"}, true, 401, 32},
+		{"signatures not verified", oauth.Credentials{Token: "ck", Secret: "wrong"}, oauth.Credentials{Token: Token, Secret: "wrong"}, false, 200, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			S.VerifySignatures = tt.verify
+			defer func() { S.VerifySignatures = true }()
+
+			resp, E := get(t, S, tt.consumer, tt.token, "account/verify_credentials.json", url.Values{"skip_status": {"true"}})
+
+			if resp.StatusCode != tt.status || E.Code != tt.code {
+				t.Errorf("got HTTP %d code %d, want HTTP %d code %d", resp.StatusCode, E.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestFaults(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		fault  Fault
+		times  int
+		status []int
+	}{
+		{"error once", Fault{Status: 503, Code: 130, Message: "Over capacity"}, 1, []int{503, 200}},
+		{"error twice", Fault{Status: 500, Code: 131, Message: "Internal error"}, 2, []int{500, 500, 200}},
+		{"delay only", Fault{Delay: 10 * time.Millisecond}, 1, []int{200, 200}},
+		{"status after handler", Fault{Status: 500, AfterHandler: true}, 1, []int{500, 200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			S := NewServer("ck", "cs")
+			defer S.Close()
+
+			Token, Secret := S.Login("gopher")
+			S.InjectFault("statuses/home_timeline.json", tt.fault, tt.times)
+
+			for i, want := range tt.status {
+				if resp, _ := get(t, S, oauth.Credentials{Token: "ck", Secret: "cs"}, oauth.Credentials{Token: Token, Secret: Secret}, "statuses/home_timeline.json", nil); resp.StatusCode != want {
+					t.Errorf("call %d: got HTTP %d, want %d", i+1, resp.StatusCode, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDropAfterHandler(t *testing.T) {
+
+	S := NewServer("ck", "cs")
+	defer S.Close()
+
+	Token, Secret := S.Login("gopher")
+	S.InjectFault("statuses/update.json", Fault{Drop: true, AfterHandler: true}, 1)
+
+	C := oauth.Client{Credentials: oauth.Credentials{Token: "ck", Secret: "cs"}}
+
+	if _, err := C.Post(S.Client(), &oauth.Credentials{Token: Token, Secret: Secret}, api+"statuses/update.json", url.Values{"status": {"hello"}}); err == nil {
+		t.Fatal("expected the dropped connection to fail the request")
+	}
+
+	if T := S.Tweets(); len(T) != 1 || T[0].Text != "hello" {
+		t.Errorf("got tweets %+v, want the one the handler created", T)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+
+	S := NewServer("ck", "cs")
+	defer S.Close()
+
+	Consumer := oauth.Credentials{Token: "ck", Secret: "cs"}
+	Token, Secret := S.Login("gopher")
+	Other, OtherSecret := S.Login("other")
+
+	S.SetRateLimit("statuses/home_timeline.json", 2, time.Minute)
+
+	tests := []struct {
+		token     oauth.Credentials
+		status    int
+		remaining string
+	}{
+		{oauth.Credentials{Token: Token, Secret: Secret}, 200, "1"},
+		{oauth.Credentials{Token: Token, Secret: Secret}, 200, "0"},
+		{oauth.Credentials{Token: Token, Secret: Secret}, 429, "0"},
+		{oauth.Credentials{Token: Other, Secret: OtherSecret}, 200, "1"},
+	}
+
+	for i, tt := range tests {
+
+		resp, E := get(t, S, Consumer, tt.token, "statuses/home_timeline.json", nil)
+
+		if resp.StatusCode != tt.status || resp.Header.Get("x-rate-limit-remaining") != tt.remaining {
+			t.Errorf("call %d: got HTTP %d remaining %q, want HTTP %d remaining %q", i+1, resp.StatusCode, resp.Header.Get("x-rate-limit-remaining"), tt.status, tt.remaining)
+		}
+
+		if tt.status == 429 && E.Code != 88 {
+			t.Errorf("call %d: got code %d, want 88", i+1, E.Code)
+		}
+	}
+
+	//the limit resets with the server's clock
+	S.Now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	if resp, _ := get(t, S, Consumer, tests[0].token, "statuses/home_timeline.json", nil); resp.StatusCode != 200 {
+		t.Errorf("after the window: got HTTP %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+
+	S := NewServer("ck", "cs")
+	defer S.Close()
+
+	Consumer := oauth.Credentials{Token: "ck", Secret: "cs"}
+	Token, Secret := S.Login("gopher")
+
+	C := oauth.Client{Credentials: Consumer}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"known route", "GET", "statuses/home_timeline.json", 200},
+		{"wrong method", "POST", "statuses/home_timeline.json", 405},
+		{"unknown route", "GET", "statuses/nothing.json", 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var resp *http.Response
+			var err error
+
+			if tt.method == "POST" {
+				resp, err = C.Post(S.Client(), &oauth.Credentials{Token: Token, Secret: Secret}, api+tt.path, nil)
+			} else {
+				resp, err = C.Get(S.Client(), &oauth.Credentials{Token: Token, Secret: Secret}, api+tt.path, nil)
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("got HTTP %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+
+	if R := S.Requests(); len(R) != len(tests) || R[0].Path != "statuses/home_timeline.json" || R[0].User == "" {
+		t.Errorf("got requests %+v", R)
+	}
+}
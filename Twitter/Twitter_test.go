@@ -0,0 +1,172 @@
+package TwitterAPI
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+	"github.com/antonholmquist/jason"
+)
+
+// login routes the library to S for the rest of the test and returns an account acting as ScreenName
+func login(t *testing.T, S *TwitterTest.Server, ScreenName string) *Account {
+
+	t.Helper()
+
+	HTTPClient = S.Client()
+	t.Cleanup(func() { HTTPClient = http.DefaultClient })
+
+	Token, Secret := S.Login(ScreenName)
+
+	return &Account{ConsumerKey: S.ConsumerKey, ConsumerSecret: S.ConsumerSecret, ScreenName: ScreenName, AccessToken: Token, AccessSecret: Secret, Retry: &NoRetry}
+}
+
+func idOf(t *testing.T, JSON string) string {
+
+	t.Helper()
+
+	o, err := jason.NewObjectFromBytes([]byte(JSON))
+
+	if err != nil {
+		t.Fatalf("%v in %s", err, JSON)
+	}
+
+	id, _ := o.GetString("id_str")
+
+	return id
+}
+
+func TestTweetLifecycle(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	T := login(t, S, "gopher")
+	Other := login(t, S, "other")
+
+	resp, err := T.Tweet("hello world", "", "", false, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ID := idOf(t, resp)
+
+	steps := []struct {
+		name   string
+		call   func() (string, error)
+		tweets int
+	}{
+		{"show", func() (string, error) { return T.ShowTweet(ID) }, 1},
+		{"favorite", func() (string, error) { return T.FavouriteTweet(ID) }, 1},
+		{"reply", func() (string, error) { return Other.Reply(ID, "answer", "") }, 2},
+		{"delete", func() (string, error) { return T.DeleteTweet(ID) }, 1},
+	}
+
+	for _, s := range steps {
+
+		if _, err := s.call(); err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+
+		if n := len(S.Tweets()); n != s.tweets {
+			t.Errorf("%s: server has %d tweets, want %d", s.name, n, s.tweets)
+		}
+	}
+
+	if Text := S.Tweets()[0].Text; Text != "@gopher answer" {
+		t.Errorf("reply text %q, want the author mentioned", Text)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	T := login(t, S, "gopher")
+
+	tests := []struct {
+		name   string
+		call   func() (string, error)
+		status int
+		code   int
+	}{
+		{"missing tweet", func() (string, error) { return T.ShowTweet("1") }, 404, 144},
+		{"duplicate", func() (string, error) { return T.Tweet("same", "", "", false, false) }, 403, 187},
+		{"unknown user", func() (string, error) { return T.UsersShow("nobody", "") }, 404, 50},
+	}
+
+	if _, err := T.Tweet("same", "", "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, err := tt.call()
+
+			E, ok := err.(*APIError)
+
+			if !ok {
+				t.Fatalf("got %v, want an *APIError", err)
+			}
+
+			if E.StatusCode != tt.status || E.Code != tt.code {
+				t.Errorf("got HTTP %d code %d, want HTTP %d code %d", E.StatusCode, E.Code, tt.status, tt.code)
+			}
+		})
+	}
+
+	T.AccessSecret = "wrong"
+
+	if _, err := T.VerifyCredential(); !IsErrorCode(err, 32) || !strings.Contains(err.Error(), "401") {
+		t.Errorf("bad signature: got %v, want error 32 (HTTP 401)", err)
+	}
+}
+
+func TestUnretweet(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	Author := login(t, S, "author")
+	Fan := login(t, S, "fan")
+
+	resp, err := Author.Tweet("worth sharing", "", "", false, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Original := idOf(t, resp)
+
+	tests := []struct {
+		name string
+		id   func(Retweet string) string
+	}{
+		{"original id", func(string) string { return Original }},
+		{"retweet id", func(Retweet string) string { return Retweet }},
+		{"tweet url", func(string) string { return "https://twitter.com/author/status/" + Original }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			resp, err := Fan.Retweet(Original)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := Fan.Unretweet(tt.id(idOf(t, resp))); err != nil {
+				t.Fatal(err)
+			}
+
+			if n := len(S.Tweets()); n != 1 {
+				t.Errorf("%d tweets left, want only the original", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrNotTwitterURL is returned for links to other sites and text that isn't a link at all
+	ErrNotTwitterURL = errors.New("Not a twitter.com or x.com link")
+	// ErrNoTweetID is returned for Twitter links that don't point at a tweet
+	ErrNoTweetID = errors.New("Link doesn't point at a tweet")
+	// ErrNoScreenName is returned for Twitter links that don't point at a user
+	ErrNoScreenName = errors.New("Link doesn't point at a user")
+)
+
+// URLError says which link could not be parsed, Err is one of the errors above
+type URLError struct {
+	URL string
+	Err error
+}
+
+func (E *URLError) Error() string {
+	return fmt.Sprintf("%s: %q", E.Err, E.URL)
+}
+
+func (E *URLError) Unwrap() error {
+	return E.Err
+}
+
+// TweetLink is what a tweet URL says about the tweet, ScreenName is empty for /i/web/status/ links
+type TweetLink struct {
+	ID         string
+	ScreenName string
+}
+
+var (
+	twitterHosts = map[string]bool{
+		"twitter.com": true, "www.twitter.com": true, "mobile.twitter.com": true, "m.twitter.com": true,
+		"x.com": true, "www.x.com": true, "mobile.x.com": true,
+	}
+
+	numericID      = regexp.MustCompile(`^\d+$`)
+	screenNameRE   = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+	statusSegments = map[string]bool{"status": true, "statuses": true}
+
+	// reservedPaths are first path segments that belong to the site rather than a user
+	reservedPaths = map[string]bool{
+		"i": true, "home": true, "explore": true, "search": true, "settings": true, "notifications": true,
+		"messages": true, "hashtag": true, "intent": true, "share": true, "login": true, "logout": true,
+		"signup": true, "tos": true, "privacy": true, "compose": true, "account": true,
+	}
+)
+
+// twitterPath parses Link and returns its path segments, old #!/ links use the fragment as the path
+func twitterPath(Link string) (*url.URL, []string, error) {
+
+	Full := strings.TrimSpace(Link)
+
+	if !strings.Contains(Full, "://") {
+		Full = "https://" + Full
+	}
+
+	u, err := url.Parse(Full)
+
+	if err != nil || !twitterHosts[strings.ToLower(u.Hostname())] {
+		return nil, nil, &URLError{URL: Link, Err: ErrNotTwitterURL}
+	}
+
+	Path := u.Path
+
+	if strings.HasPrefix(u.Fragment, "!/") {
+		Path = u.Fragment[1:]
+	}
+
+	var Segments []string
+
+	for _, s := range strings.Split(Path, "/") {
+		if s != "" {
+			Segments = append(Segments, s)
+		}
+	}
+
+	return u, Segments, nil
+}
+
+// ParseTweetURL reads the tweet ID out of links like
+//
+//	https://twitter.com/jack/status/20
+//	https://x.com/jack/status/20/photo/1?s=20
+//	mobile.twitter.com/jack/statuses/20#reply
+//	https://twitter.com/i/web/status/20
+//	https://twitter.com/#!/jack/status/20
+func ParseTweetURL(Link string) (TweetLink, error) {
+
+	_, Segments, err := twitterPath(Link)
+
+	if err != nil {
+		return TweetLink{}, err
+	}
+
+	for i := 0; i+1 < len(Segments); i++ {
+
+		if !statusSegments[strings.ToLower(Segments[i])] || !numericID.MatchString(Segments[i+1]) {
+			continue
+		}
+
+		T := TweetLink{ID: Segments[i+1]}
+
+		if i == 1 && !reservedPaths[strings.ToLower(Segments[0])] {
+			T.ScreenName = Segments[0]
+		}
+
+		return T, nil
+	}
+
+	return TweetLink{}, &URLError{URL: Link, Err: ErrNoTweetID}
+}
+
+// ParseTweetID accepts a tweet ID or a tweet URL and returns the ID
+func ParseTweetID(IDOrURL string) (string, error) {
+
+	IDOrURL = strings.TrimSpace(IDOrURL)
+
+	if numericID.MatchString(IDOrURL) {
+		return IDOrURL, nil
+	}
+
+	T, err := ParseTweetURL(IDOrURL)
+
+	return T.ID, err
+}
+
+// ParseUserURL reads the screen name out of links like https://x.com/jack, https://twitter.com/jack/likes,
+// https://twitter.com/intent/user?screen_name=jack or the author part of a tweet link
+func ParseUserURL(Link string) (string, error) {
+
+	u, Segments, err := twitterPath(Link)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(Segments) >= 2 && strings.ToLower(Segments[0]) == "intent" {
+		if Name := u.Query().Get("screen_name"); screenNameRE.MatchString(Name) {
+			return Name, nil
+		}
+	}
+
+	if len(Segments) > 0 && !reservedPaths[strings.ToLower(Segments[0])] && screenNameRE.MatchString(Segments[0]) {
+		return Segments[0], nil
+	}
+
+	return "", &URLError{URL: Link, Err: ErrNoScreenName}
+}
+
+// ParseScreenName accepts a screen name with or without @, or a profile URL, and returns the bare screen name
+func ParseScreenName(NameOrURL string) (string, error) {
+
+	Name := strings.TrimPrefix(strings.TrimSpace(NameOrURL), "@")
+
+	if screenNameRE.MatchString(Name) {
+		return Name, nil
+	}
+
+	return ParseUserURL(NameOrURL)
+}
+
+// tweetIDArg lets every method taking a tweet ID take a URL as well, empty optional IDs pass through
+func tweetIDArg(ID string) (string, error) {
+
+	if ID == "" {
+		return "", nil
+	}
+
+	return ParseTweetID(ID)
+}
+
+// screenNameArg does the same for screen names
+func screenNameArg(ScreenName string) (string, error) {
+
+	if ScreenName == "" {
+		return "", nil
+	}
+
+	return ParseScreenName(ScreenName)
+}
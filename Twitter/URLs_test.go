@@ -0,0 +1,124 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestParseTweetURL(t *testing.T) {
+
+	tests := []struct {
+		link string
+		want TweetLink
+		err  error
+	}{
+		{"https://twitter.com/jack/status/20", TweetLink{ID: "20", ScreenName: "jack"}, nil},
+		{"https://x.com/jack/status/20/photo/1?s=20", TweetLink{ID: "20", ScreenName: "jack"}, nil},
+		{"mobile.twitter.com/jack/statuses/20#reply", TweetLink{ID: "20", ScreenName: "jack"}, nil},
+		{"https://twitter.com/i/web/status/20", TweetLink{ID: "20"}, nil},
+		{"https://twitter.com/#!/jack/status/20", TweetLink{ID: "20", ScreenName: "jack"}, nil},
+		{"  HTTPS://WWW.Twitter.com/jack/Status/20  ", TweetLink{ID: "20", ScreenName: "jack"}, nil},
+		{"https://twitter.com/jack", TweetLink{}, ErrNoTweetID},
+		{"https://twitter.com/jack/status/abc", TweetLink{}, ErrNoTweetID},
+		{"https://example.com/jack/status/20", TweetLink{}, ErrNotTwitterURL},
+		{"https://twitter.com.evil.com/jack/status/20", TweetLink{}, ErrNotTwitterURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+
+			got, err := ParseTweetURL(tt.link)
+
+			if got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("got %+v, %v, want %+v, %v", got, err, tt.want, tt.err)
+			}
+
+			var E *URLError
+
+			if tt.err != nil && (!errors.As(err, &E) || E.URL != tt.link) {
+				t.Errorf("got %v, want a *URLError for the link", err)
+			}
+		})
+	}
+}
+
+func TestParseScreenName(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"jack", "jack", nil},
+		{"@jack", "jack", nil},
+		{"https://x.com/jack", "jack", nil},
+		{"https://twitter.com/jack/likes", "jack", nil},
+		{"https://twitter.com/jack/status/20", "jack", nil},
+		{"https://twitter.com/intent/user?screen_name=jack", "jack", nil},
+		{"https://twitter.com/home", "", ErrNoScreenName},
+		{"https://twitter.com/i/web/status/20", "", ErrNoScreenName},
+		{"a name with spaces", "", ErrNotTwitterURL},
+		{"much_too_long_screen_name", "", ErrNotTwitterURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got, err := ParseScreenName(tt.in); got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("got %q, %v, want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestURLArguments(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	T := login(t, S, "gopher")
+	S.AddUser("jack")
+
+	resp, err := T.Tweet("link me", "", "", false, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Link := "https://x.com/gopher/status/" + idOf(t, resp)
+
+	tests := []struct {
+		name  string
+		call  func() (string, error)
+		path  string
+		param string
+		want  string
+	}{
+		{"show", func() (string, error) { return T.ShowTweet(Link) }, "statuses/show.json", "id", idOf(t, resp)},
+		{"like", func() (string, error) { return T.FavouriteTweet(Link) }, "favorites/create.json", "id", idOf(t, resp)},
+		{"follow", func() (string, error) { return T.FollowUser("https://twitter.com/jack", "") }, "friendships/create.json", "screen_name", "jack"},
+		{"friendship", func() (string, error) { return T.FriendshipShow("@gopher", "https://x.com/jack/likes") }, "friendships/show.json", "target_screen_name", "jack"},
+		{"timeline", func() (string, error) { return T.GetUserTimeline("@jack", "", "", true) }, "statuses/user_timeline.json", "screen_name", "jack"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if _, err := tt.call(); err != nil {
+				t.Fatal(err)
+			}
+
+			R := S.Requests()
+			Last := R[len(R)-1]
+
+			if Last.Path != tt.path || Last.Params.Get(tt.param) != tt.want {
+				t.Errorf("got %s %v, want %s=%s", Last.Path, Last.Params, tt.param, tt.want)
+			}
+		})
+	}
+
+	if _, err := T.ShowTweet("https://example.com/status/1"); !errors.Is(err, ErrNotTwitterURL) {
+		t.Errorf("got %v, want ErrNotTwitterURL before any request", err)
+	}
+}
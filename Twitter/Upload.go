@@ -0,0 +1,178 @@
+package TwitterAPI
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// MediaSource is something to upload: a file, bytes already in memory or a reader
+type MediaSource struct {
+	// Name is the file name sent with the upload, informational only
+	Name string
+
+	path   string
+	data   []byte
+	reader io.Reader
+}
+
+// FileSource uploads the file at Path
+func FileSource(Path string) MediaSource {
+	return MediaSource{Name: filepath.Base(Path), path: Path}
+}
+
+// BytesSource uploads Data
+func BytesSource(Data []byte) MediaSource {
+	return MediaSource{Name: "upload", data: Data}
+}
+
+// ReaderSource uploads what R returns. R is read once and whole, images are decoded and checked
+// before they are sent.
+func ReaderSource(R io.Reader) MediaSource {
+	return MediaSource{Name: "upload", reader: R}
+}
+
+// Bytes returns the whole content of the source. Images are decoded and checked before they are
+// sent, so a source is read into memory once, the multipart form then streams it without a copy.
+func (S MediaSource) Bytes() ([]byte, error) {
+
+	switch {
+	case S.data != nil:
+		return S.data, nil
+	case S.reader != nil:
+		return ioutil.ReadAll(S.reader)
+	case S.path != "":
+		return ioutil.ReadFile(S.path)
+	}
+
+	return nil, errors.New("Media source is empty")
+}
+
+// DetectMIME sniffs the content type of Data, like "image/png" or "video/mp4"
+func DetectMIME(Data []byte) string {
+
+	MIME := http.DetectContentType(Data)
+
+	if i := strings.IndexByte(MIME, ';'); i >= 0 {
+		MIME = MIME[:i]
+	}
+
+	return MIME
+}
+
+// prepareSource reads an image to upload and prepares it for Spec
+func (P *Account) prepareSource(Src MediaSource, Spec ImageSpec) ([]byte, error) {
+
+	data, err := Src.Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	Out, err := PrepareImage(data, Spec)
+
+	if err == ErrUnsupportedImage {
+		return nil, fmt.Errorf("%w, %s is %s", err, Src.Name, DetectMIME(data))
+	}
+
+	return Out, err
+}
+
+// MediaUploadFrom uploads an image for a tweet and returns its media ID
+func (P *Account) MediaUploadFrom(Src MediaSource) (string, error) {
+
+	Spec := MediaImage
+
+	if Spec.MaxBytes <= 0 {
+		Spec.MaxBytes = P.limits().PhotoSizeLimit
+	}
+
+	data, err := P.prepareSource(Src, Spec)
+
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := P.DoMultipart(ENDPOINT.MediaUpload, nil, FormFile{Field: "media", Name: Src.Name, Data: data})
+
+	if err != nil {
+		return "", err
+	}
+
+	var Result struct {
+		MediaIDString string `json:"media_id_string"`
+	}
+
+	if err := Decode(resp, &Result); err != nil {
+		return "", err
+	}
+
+	if Result.MediaIDString == "" {
+		return "", errors.New("Upload response has no media_id_string")
+	}
+
+	return Result.MediaIDString, nil
+}
+
+func (P *Account) ChangeProfilePictureFrom(Src MediaSource) (string, error) {
+
+	data, err := P.prepareSource(Src, AvatarImage)
+
+	if err != nil {
+		return "", err
+	}
+
+	return P.DoMultipart(ENDPOINT.UpdatePicture, nil, FormFile{Field: "image", Name: Src.Name, Data: data})
+}
+
+// UpdateBannerFrom sets the profile banner, the optional Width, Height and offsets pick the part of
+// the image to show
+func (P *Account) UpdateBannerFrom(Src MediaSource, Width, Height, Offset_Left, Offset_Top string) (string, error) {
+
+	var Params = url.Values{}
+
+	//the offsets point into the image as given, so it is only recompressed and Twitter crops it
+	Spec := BannerImage
+
+	if Width != "" || Height != "" || Offset_Left != "" || Offset_Top != "" {
+		Spec = ImageSpec{KeepDimensions: true}
+	}
+
+	data, err := P.prepareSource(Src, Spec)
+
+	if err != nil {
+		return "", err
+	}
+
+	for k, v := range map[string]string{"width": Width, "height": Height, "offset_left": Offset_Left, "offset_top": Offset_Top} {
+		if v != "" {
+			Params.Add(k, v)
+		}
+	}
+
+	return P.DoMultipart(ENDPOINT.UpdateBanner, Params, FormFile{Field: "banner", Name: Src.Name, Data: data})
+}
+
+func (P *Account) UpdateBackgroundPictureFrom(Src MediaSource, Tile bool) (string, error) {
+
+	var Params = url.Values{}
+
+	data, err := P.prepareSource(Src, BackgroundImage)
+
+	if err != nil {
+		return "", err
+	}
+
+	Params.Add("use", "1")
+
+	if Tile {
+		Params.Add("tile", "true")
+	}
+
+	return P.DoMultipart(ENDPOINT.UpdateBackgroundPic, Params, FormFile{Field: "image", Name: Src.Name, Data: data})
+}
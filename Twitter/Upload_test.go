@@ -0,0 +1,134 @@
+package TwitterAPI
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestMediaSource(t *testing.T) {
+
+	Path := filepath.Join(t.TempDir(), "photo.png")
+
+	if err := ioutil.WriteFile(Path, []byte("file data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source MediaSource
+		file   string
+		want   string
+		fails  bool
+	}{
+		{"file", FileSource(Path), "photo.png", "file data", false},
+		{"bytes", BytesSource([]byte("bytes data")), "upload", "bytes data", false},
+		{"reader", ReaderSource(strings.NewReader("reader data")), "upload", "reader data", false},
+		{"missing file", FileSource(Path + ".gone"), "photo.png.gone", "", true},
+		{"empty", MediaSource{}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			Data, err := tt.source.Bytes()
+
+			if tt.source.Name != tt.file || string(Data) != tt.want {
+				t.Errorf("got %s %q, want %s %q", tt.source.Name, Data, tt.file, tt.want)
+			}
+
+			if (err != nil) != tt.fails {
+				t.Errorf("got %v, want failure %v", err, tt.fails)
+			}
+		})
+	}
+}
+
+func TestDetectMIME(t *testing.T) {
+
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{testImage(t, 2, 2, "png", false), "image/png"},
+		{testImage(t, 2, 2, "jpeg", false), "image/jpeg"},
+		{testImage(t, 2, 2, "gif", false), "image/gif"},
+		{[]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), "video/mp4"},
+		{[]byte("just some text"), "text/plain"},
+		{[]byte("<html><body>"), "text/html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := DetectMIME(tt.data); got != tt.want {
+				t.Errorf("DetectMIME = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaUploadFrom(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	A := login(t, S, "gopher")
+
+	Image := testImage(t, 100, 100, "png", false)
+	Path := filepath.Join(t.TempDir(), "photo.png")
+
+	if err := ioutil.WriteFile(Path, Image, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source MediaSource
+		err    error
+		detail string
+	}{
+		{"file", FileSource(Path), nil, ""},
+		{"bytes", BytesSource(Image), nil, ""},
+		{"reader", ReaderSource(bytes.NewReader(Image)), nil, ""},
+		{"text file", ReaderSource(strings.NewReader("not an image")), ErrUnsupportedImage, "upload is text/plain"},
+		{"missing file", FileSource(Path + ".gone"), os.ErrNotExist, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ID, err := A.MediaUploadFrom(tt.source)
+
+			if !errors.Is(err, tt.err) || tt.detail != "" && !strings.Contains(err.Error(), tt.detail) {
+				t.Fatalf("got %v, want %v mentioning %q", err, tt.err, tt.detail)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if _, err := A.Tweet("photo from "+tt.name, "", ID, false, false); err != nil {
+				t.Fatal(err)
+			}
+
+			if Media := S.Tweets()[0].Entities.Media; len(Media) != 1 {
+				t.Errorf("tweet has %d media, want the upload attached", len(Media))
+			}
+		})
+	}
+
+	//MediaUpload is the path based shortcut that tweets the upload on its own
+	if _, err := A.MediaUpload(Path, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if Media := S.Tweets()[0].Entities.Media; len(Media) != 1 {
+		t.Errorf("tweet has %d media, want the upload attached", len(Media))
+	}
+}
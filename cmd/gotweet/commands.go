@@ -0,0 +1,580 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	TwitterAPI "github.com/KingBARD/GoTweet/Twitter"
+)
+
+// flags parses a subcommand's own flags and checks the number of positional arguments left
+func flags(Name string, Args []string, NArgs int, Define func(*flag.FlagSet)) (*flag.FlagSet, error) {
+
+	F := flag.NewFlagSet(Name, flag.ContinueOnError)
+	F.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: gotweet %s\n", commands[Name].Usage)
+		F.PrintDefaults()
+	}
+
+	if Define != nil {
+		Define(F)
+	}
+
+	if err := F.Parse(Args); err != nil {
+		return nil, err
+	}
+
+	if NArgs >= 0 && F.NArg() != NArgs {
+		F.Usage()
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d", Name, NArgs, F.NArg())
+	}
+
+	return F, nil
+}
+
+// message turns a local result into JSON so every command prints through the same formatter
+func message(Fields map[string]interface{}) (string, error) {
+
+	data, err := json.Marshal(Fields)
+
+	return string(data), err
+}
+
+func runAuth(C *cli, Args []string) (string, error) {
+
+	var Key, Secret string
+
+	if _, err := flags("auth", Args, 0, func(F *flag.FlagSet) {
+		F.StringVar(&Key, "key", os.Getenv("GOTWEET_CONSUMER_KEY"), "consumer key")
+		F.StringVar(&Secret, "secret", os.Getenv("GOTWEET_CONSUMER_SECRET"), "consumer secret")
+	}); err != nil {
+		return "", err
+	}
+
+	if Key == "" || Secret == "" {
+		return "", errors.New("auth needs a consumer key and secret, pass -key and -secret or set GOTWEET_CONSUMER_KEY and GOTWEET_CONSUMER_SECRET")
+	}
+
+	S, err := C.openStore()
+
+	if err != nil {
+		return "", err
+	}
+
+	A := &TwitterAPI.Account{ConsumerKey: Key, ConsumerSecret: Secret}
+
+	if _, err := A.Auth(); err != nil {
+		return "", err
+	}
+
+	if err := A.SaveCredentials(S); err != nil {
+		return "", err
+	}
+
+	return message(map[string]interface{}{"screen_name": A.ScreenName, "saved": true})
+}
+
+func runAccounts(C *cli, Args []string) (string, error) {
+
+	if _, err := flags("accounts", Args, 0, nil); err != nil {
+		return "", err
+	}
+
+	S, err := C.openStore()
+
+	if err != nil {
+		return "", err
+	}
+
+	Names, err := S.List()
+
+	if err != nil {
+		return "", err
+	}
+
+	Rows := []map[string]interface{}{}
+
+	for _, Name := range Names {
+		Rows = append(Rows, map[string]interface{}{"screen_name": Name})
+	}
+
+	data, err := json.Marshal(Rows)
+
+	return string(data), err
+}
+
+func runForget(C *cli, Args []string) (string, error) {
+
+	F, err := flags("forget", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	S, err := C.openStore()
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := S.Delete(F.Arg(0)); err != nil {
+		return "", err
+	}
+
+	return message(map[string]interface{}{"screen_name": F.Arg(0), "deleted": true})
+}
+
+func runWhoami(C *cli, Args []string) (string, error) {
+
+	if _, err := flags("whoami", Args, 0, nil); err != nil {
+		return "", err
+	}
+
+	return C.Account.VerifyCredential()
+}
+
+func runTweet(C *cli, Args []string) (string, error) {
+
+	var Reply, Media string
+	var Sensitive bool
+
+	F, err := flags("tweet", Args, 1, func(F *flag.FlagSet) {
+		F.StringVar(&Reply, "reply", "", "ID of the tweet to reply to")
+		F.StringVar(&Media, "media", "", "comma separated files to attach")
+		F.BoolVar(&Sensitive, "sensitive", false, "mark the media as possibly sensitive")
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	//checked before uploading anything, media attached to a rejected tweet would be wasted
+	if err := C.Account.CheckTweetLength(F.Arg(0)); err != nil {
+		return "", err
+	}
+
+	var MediaIDs []string
+
+	for _, Path := range strings.Split(Media, ",") {
+
+		if Path = strings.TrimSpace(Path); Path == "" {
+			continue
+		}
+
+		ID, err := C.Account.MediaUpload(Path, false)
+
+		if err != nil {
+			return "", fmt.Errorf("uploading %s: %v", Path, err)
+		}
+
+		MediaIDs = append(MediaIDs, ID)
+	}
+
+	return C.Account.Tweet(F.Arg(0), Reply, strings.Join(MediaIDs, ","), Sensitive, false)
+}
+
+func runRetweet(C *cli, Args []string) (string, error) {
+
+	F, err := flags("retweet", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.Retweet(F.Arg(0))
+}
+
+func runUnretweet(C *cli, Args []string) (string, error) {
+
+	F, err := flags("unretweet", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.Unretweet(F.Arg(0))
+}
+
+func runDelete(C *cli, Args []string) (string, error) {
+
+	F, err := flags("delete", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.DeleteTweet(F.Arg(0))
+}
+
+func runLike(C *cli, Args []string) (string, error) {
+
+	F, err := flags("like", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.FavouriteTweet(F.Arg(0))
+}
+
+func runUnlike(C *cli, Args []string) (string, error) {
+
+	F, err := flags("unlike", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.UnFavorite(F.Arg(0))
+}
+
+func runShow(C *cli, Args []string) (string, error) {
+
+	F, err := flags("show", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.ShowTweet(F.Arg(0))
+}
+
+func runTimeline(C *cli, Args []string) (string, error) {
+
+	var User string
+	var Count int
+	var Mentions, Retweets bool
+
+	if _, err := flags("timeline", Args, 0, func(F *flag.FlagSet) {
+		F.StringVar(&User, "user", "", "read this user's timeline instead of the home timeline")
+		F.BoolVar(&Mentions, "mentions", false, "read the mentions timeline")
+		F.IntVar(&Count, "count", 20, "number of tweets")
+		F.BoolVar(&Retweets, "rts", true, "include retweets in a user timeline")
+	}); err != nil {
+		return "", err
+	}
+
+	switch {
+	case User != "" && Mentions:
+		return "", errors.New("-user and -mentions can't be combined")
+	case User != "":
+		return C.Account.GetUserTimeline(User, "", strconv.Itoa(Count), Retweets)
+	case Mentions:
+		return C.Account.GetMentionsTimeline(strconv.Itoa(Count))
+	}
+
+	return C.Account.GetHomeTimeline(strconv.Itoa(Count))
+}
+
+func runSearch(C *cli, Args []string) (string, error) {
+
+	var O TwitterAPI.SearchOptions
+	var ResultType, Until string
+
+	F, err := flags("search", Args, -1, func(F *flag.FlagSet) {
+		F.StringVar(&O.GeoCode, "geocode", "", "restrict to latitude,longitude,radius")
+		F.StringVar(&O.Lang, "lang", "", "restrict to a language code")
+		F.StringVar(&ResultType, "type", "", "mixed, recent or popular")
+		F.IntVar(&O.Count, "count", 0, "number of tweets, up to 100")
+		F.StringVar(&Until, "until", "", "only tweets before this date, YYYY-MM-DD")
+		F.StringVar(&O.SinceID, "since-id", "", "only tweets newer than this ID")
+		F.StringVar(&O.MaxID, "max-id", "", "only tweets up to this ID")
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if F.NArg() == 0 {
+		F.Usage()
+		return "", errors.New("search needs a query")
+	}
+
+	if Until != "" {
+		if O.Until, err = time.Parse("2006-01-02", Until); err != nil {
+			return "", fmt.Errorf("-until: %v", err)
+		}
+	}
+
+	O.Query = strings.Join(F.Args(), " ")
+	O.ResultType = TwitterAPI.ResultType(ResultType)
+
+	return C.Account.SearchTweets(O)
+}
+
+func runSaved(C *cli, Args []string) (string, error) {
+
+	var Run bool
+	var Count int
+
+	_, err := flags("saved", Args, 0, func(F *flag.FlagSet) {
+		F.BoolVar(&Run, "run", false, "run every saved search and print the tweets found")
+		F.IntVar(&Count, "count", 0, "number of tweets per search, up to 100")
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if !Run {
+		return C.Account.SavedSearches()
+	}
+
+	Tweets, err := C.Account.RunSavedSearches(TwitterAPI.SearchOptions{Count: Count})
+
+	if err != nil {
+		return "", err
+	}
+
+	return message(map[string]interface{}{"statuses": Tweets})
+}
+
+func runSave(C *cli, Args []string) (string, error) {
+
+	F, err := flags("save", Args, -1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	if F.NArg() == 0 {
+		F.Usage()
+		return "", errors.New("save needs a query")
+	}
+
+	return C.Account.SavedSearchCreate(strings.Join(F.Args(), " "))
+}
+
+func runUnsave(C *cli, Args []string) (string, error) {
+
+	F, err := flags("unsave", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.SavedSearchDestroy(F.Arg(0))
+}
+
+func runConfig(C *cli, Args []string) (string, error) {
+
+	var Languages bool
+
+	_, err := flags("config", Args, 0, func(F *flag.FlagSet) {
+		F.BoolVar(&Languages, "languages", false, "list the supported languages instead")
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if Languages {
+		return C.Account.HelpLanguages()
+	}
+
+	return C.Account.HelpConfiguration()
+}
+
+func runUser(C *cli, Args []string) (string, error) {
+
+	F, err := flags("user", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.UsersShow(strings.TrimPrefix(F.Arg(0), "@"), "")
+}
+
+// userAction adapts the Account methods taking (ScreenName, UserId)
+func userAction(Method func(*TwitterAPI.Account, string, string) (string, error)) func(*cli, []string) (string, error) {
+	return func(C *cli, Args []string) (string, error) {
+
+		F, err := flags(flag.Arg(0), Args, 1, nil)
+
+		if err != nil {
+			return "", err
+		}
+
+		return Method(C.Account, strings.TrimPrefix(F.Arg(0), "@"), "")
+	}
+}
+
+func listUsers(C *cli, Name string, Args []string, List func(*TwitterAPI.Account, string, string, string, string) (string, error)) (string, error) {
+
+	var User, Cursor string
+	var Count int
+
+	if _, err := flags(Name, Args, 0, func(F *flag.FlagSet) {
+		F.StringVar(&User, "user", "", "screen name, defaults to the account itself")
+		F.IntVar(&Count, "count", 20, "number of users")
+		F.StringVar(&Cursor, "cursor", "", "cursor from a previous page")
+	}); err != nil {
+		return "", err
+	}
+
+	if User == "" {
+		User = C.Account.ScreenName
+	}
+
+	return List(C.Account, "", strings.TrimPrefix(User, "@"), Cursor, strconv.Itoa(Count))
+}
+
+func runFollowers(C *cli, Args []string) (string, error) {
+	return listUsers(C, "followers", Args, (*TwitterAPI.Account).FollowersList)
+}
+
+func runFollowing(C *cli, Args []string) (string, error) {
+	return listUsers(C, "following", Args, (*TwitterAPI.Account).FollowingList)
+}
+
+func runDM(C *cli, Args []string) (string, error) {
+
+	F, err := flags("dm", Args, 2, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return C.Account.DMCreate("", strings.TrimPrefix(F.Arg(0), "@"), F.Arg(1))
+}
+
+func runDMs(C *cli, Args []string) (string, error) {
+
+	var Count int
+
+	if _, err := flags("dms", Args, 0, func(F *flag.FlagSet) {
+		F.IntVar(&Count, "count", 20, "number of messages")
+	}); err != nil {
+		return "", err
+	}
+
+	return C.Account.DirectMessages(strconv.Itoa(Count), "true")
+}
+
+func runUpload(C *cli, Args []string) (string, error) {
+
+	F, err := flags("upload", Args, 1, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	Src := TwitterAPI.FileSource(F.Arg(0))
+
+	//"-" uploads whatever is piped in
+	if F.Arg(0) == "-" {
+		Src = TwitterAPI.ReaderSource(os.Stdin)
+	}
+
+	ID, err := C.Account.MediaUploadFrom(Src)
+
+	if err != nil {
+		return "", err
+	}
+
+	return message(map[string]interface{}{"media_id_string": ID, "file": F.Arg(0)})
+}
+
+func runProfile(C *cli, Args []string) (string, error) {
+
+	var O TwitterAPI.ProfileOptions
+
+	//flag name to ProfileOptions field
+	Fields := map[string]**string{"name": &O.Name, "url": &O.URL, "location": &O.Location, "description": &O.Description, "link-color": &O.ProfileLinkColor}
+	Options := map[string]*string{}
+
+	F, err := flags("profile", Args, 0, func(F *flag.FlagSet) {
+		for Name := range Fields {
+			Options[Name] = F.String(Name, "", "new "+strings.Replace(Name, "-", " ", 1))
+		}
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	//only flags given on the command line are sent, so an empty value can clear a field
+	F.Visit(func(f *flag.Flag) {
+		*Fields[f.Name] = Options[f.Name]
+	})
+
+	if F.NFlag() == 0 {
+		F.Usage()
+		return "", errors.New("profile needs at least one field to change")
+	}
+
+	U, err := C.Account.UpdateProfile(O)
+
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(U)
+
+	return string(data), err
+}
+
+func runSettings(C *cli, Args []string) (string, error) {
+
+	var O TwitterAPI.SettingsOptions
+	var Sleep string
+	var Start, End int
+
+	F, err := flags("settings", Args, 0, func(F *flag.FlagSet) {
+		F.StringVar(&O.Lang, "lang", "", "interface language, like en")
+		F.StringVar(&O.TimeZone, "time-zone", "", "time zone, like Europe/Berlin")
+		F.IntVar(&O.TrendLocationWOEID, "trend-woeid", 0, "WOEID of the place to show trends for, 1 for worldwide")
+		F.StringVar(&Sleep, "sleep", "", "on or off, hold back notifications during sleep time")
+		F.IntVar(&Start, "sleep-start", 0, "hour sleep time starts")
+		F.IntVar(&End, "sleep-end", 0, "hour sleep time ends")
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	Changed := false
+
+	F.Visit(func(f *flag.Flag) {
+
+		Changed = true
+
+		switch f.Name {
+		case "sleep-start":
+			O.StartSleepTime = &Start
+		case "sleep-end":
+			O.EndSleepTime = &End
+		}
+	})
+
+	switch Sleep {
+	case "":
+	case "on", "off":
+		O.SleepTimeEnabled = TwitterAPI.Bool(Sleep == "on")
+	default:
+		return "", fmt.Errorf("-sleep must be on or off, got %q", Sleep)
+	}
+
+	var S *TwitterAPI.AccountSettings
+
+	if Changed {
+		S, err = C.Account.ChangeAccountSettings(O)
+	} else {
+		S, err = C.Account.Settings()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(S)
+
+	return string(data), err
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	TwitterAPI "github.com/KingBARD/GoTweet/Twitter"
+	"github.com/KingBARD/GoTweet/Twitter/TwitterTest"
+)
+
+func TestTimeline(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	TwitterAPI.HTTPClient = S.Client()
+	defer func() { TwitterAPI.HTTPClient = http.DefaultClient }()
+
+	Token, Secret := S.Login("gopher")
+	S.AddUser("other")
+
+	C := &cli{Account: &TwitterAPI.Account{ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: Token, AccessSecret: Secret}}
+
+	tests := []struct {
+		args   []string
+		path   string
+		params url.Values
+	}{
+		{nil, "statuses/home_timeline.json", url.Values{"count": {"20"}}},
+		{[]string{"-mentions", "-count", "5"}, "statuses/mentions_timeline.json", url.Values{"count": {"5"}}},
+		{[]string{"-user", "other"}, "statuses/user_timeline.json", url.Values{"screen_name": {"other"}, "count": {"20"}, "include_rts": {"true"}}},
+		{[]string{"-user", "https://twitter.com/other", "-rts=false", "-count", "3"}, "statuses/user_timeline.json", url.Values{"screen_name": {"other"}, "count": {"3"}, "include_rts": {"false"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+
+			if _, err := runTimeline(C, tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			R := S.Requests()
+			Last := R[len(R)-1]
+
+			if Last.Path != tt.path || !reflect.DeepEqual(Last.Params, tt.params) {
+				t.Errorf("got %s %v, want %s %v", Last.Path, Last.Params, tt.path, tt.params)
+			}
+		})
+	}
+
+	if _, err := runTimeline(C, []string{"-user", "other", "-mentions"}); err == nil {
+		t.Error("-user and -mentions were accepted together")
+	}
+}
+
+func TestTweetTooLong(t *testing.T) {
+
+	S := TwitterTest.NewServer("ck", "cs")
+	defer S.Close()
+
+	TwitterAPI.HTTPClient = S.Client()
+	defer func() { TwitterAPI.HTTPClient = http.DefaultClient }()
+
+	Token, Secret := S.Login("gopher")
+
+	C := &cli{Account: &TwitterAPI.Account{ConsumerKey: "ck", ConsumerSecret: "cs", AccessToken: Token, AccessSecret: Secret}}
+
+	//the media would be uploaded first if the length wasn't checked up front, the file doesn't exist
+	if _, err := runTweet(C, []string{"-media", "missing.png", strings.Repeat("a", 281)}); err == nil || strings.Contains(err.Error(), "missing.png") {
+		t.Errorf("got %v, want the length to be rejected before uploading", err)
+	}
+
+	if len(S.Tweets()) != 0 {
+		t.Error("the tweet was posted")
+	}
+}
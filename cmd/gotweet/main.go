@@ -0,0 +1,293 @@
+// Command gotweet exposes the TwitterAPI package on the command line.
+//
+//	gotweet auth -key KEY -secret SECRET
+//	gotweet -account brand tweet "Hello world"
+//	gotweet -format table timeline -count 20
+//
+// Credentials are kept in an encrypted FileStore, the passphrase is read from GOTWEET_PASSPHRASE or
+// asked for on the terminal.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	TwitterAPI "github.com/KingBARD/GoTweet/Twitter"
+	"golang.org/x/term"
+)
+
+// command is one subcommand, Run gets the arguments left after the subcommand name
+type command struct {
+	Usage   string
+	Summary string
+	// NoAccount commands run without loading credentials
+	NoAccount bool
+	Run       func(C *cli, Args []string) (string, error)
+}
+
+var commands map[string]command
+
+// filled in by init, the commands refer back to the table for their usage lines
+func init() {
+	commands = map[string]command{
+		"auth":      {Usage: "auth [-key KEY -secret SECRET]", Summary: "authorize an account with the PIN flow and save it", NoAccount: true, Run: runAuth},
+		"accounts":  {Usage: "accounts", Summary: "list the saved accounts", NoAccount: true, Run: runAccounts},
+		"forget":    {Usage: "forget SCREEN_NAME", Summary: "remove a saved account", NoAccount: true, Run: runForget},
+		"whoami":    {Usage: "whoami", Summary: "show the authenticated user", Run: runWhoami},
+		"tweet":     {Usage: "tweet [-reply ID] [-media FILE,...] [-sensitive] TEXT", Summary: "post a tweet", Run: runTweet},
+		"retweet":   {Usage: "retweet ID|URL", Summary: "retweet a tweet", Run: runRetweet},
+		"unretweet": {Usage: "unretweet ID|URL", Summary: "undo a retweet", Run: runUnretweet},
+		"delete":    {Usage: "delete ID|URL", Summary: "delete one of your tweets", Run: runDelete},
+		"like":      {Usage: "like ID|URL", Summary: "like a tweet", Run: runLike},
+		"unlike":    {Usage: "unlike ID|URL", Summary: "remove a like", Run: runUnlike},
+		"show":      {Usage: "show ID|URL", Summary: "show a tweet", Run: runShow},
+		"timeline":  {Usage: "timeline [-user SCREEN_NAME | -mentions] [-count N] [-rts]", Summary: "read the home, mentions or a user timeline", Run: runTimeline},
+		"search":    {Usage: "search [-lang CODE] [-type recent] [-count N] [-until DATE] [-since-id ID] [-max-id ID] [-geocode LAT,LONG,RADIUS] QUERY", Summary: "search recent tweets", Run: runSearch},
+		"saved":     {Usage: "saved [-run] [-count N]", Summary: "list saved searches, or run them all", Run: runSaved},
+		"save":      {Usage: "save QUERY", Summary: "save a search", Run: runSave},
+		"unsave":    {Usage: "unsave ID", Summary: "delete a saved search", Run: runUnsave},
+		"config":    {Usage: "config [-languages]", Summary: "show Twitter's current limits, or the supported languages", Run: runConfig},
+		"user":      {Usage: "user SCREEN_NAME", Summary: "show a user", Run: runUser},
+		"follow":    {Usage: "follow SCREEN_NAME", Summary: "follow a user", Run: userAction((*TwitterAPI.Account).FollowUser)},
+		"unfollow":  {Usage: "unfollow SCREEN_NAME", Summary: "unfollow a user", Run: userAction((*TwitterAPI.Account).UnFollowUser)},
+		"block":     {Usage: "block SCREEN_NAME", Summary: "block a user", Run: userAction((*TwitterAPI.Account).BlockUser)},
+		"unblock":   {Usage: "unblock SCREEN_NAME", Summary: "unblock a user", Run: userAction((*TwitterAPI.Account).UnBlockUser)},
+		"mute":      {Usage: "mute SCREEN_NAME", Summary: "mute a user", Run: userAction((*TwitterAPI.Account).MuteUser)},
+		"unmute":    {Usage: "unmute SCREEN_NAME", Summary: "unmute a user", Run: userAction((*TwitterAPI.Account).UnMuteUser)},
+		"followers": {Usage: "followers [-user SCREEN_NAME] [-count N] [-cursor C]", Summary: "list followers", Run: runFollowers},
+		"following": {Usage: "following [-user SCREEN_NAME] [-count N] [-cursor C]", Summary: "list followed users", Run: runFollowing},
+		"dm":        {Usage: "dm SCREEN_NAME TEXT", Summary: "send a direct message", Run: runDM},
+		"dms":       {Usage: "dms [-count N]", Summary: "list received direct messages", Run: runDMs},
+		"upload":    {Usage: "upload FILE|-", Summary: "upload media, or stdin, and print its media id", Run: runUpload},
+		"profile":   {Usage: "profile [-name N] [-url U] [-location L] [-description D] [-link-color HEX]", Summary: "update your profile", Run: runProfile},
+		"settings":  {Usage: "settings [-lang CODE] [-time-zone TZ] [-trend-woeid N] [-sleep on|off] [-sleep-start H] [-sleep-end H]", Summary: "show or change your account settings", Run: runSettings},
+	}
+}
+
+// cli holds the global flags and the account commands act as
+type cli struct {
+	StorePath string
+	Name      string
+	Format    string
+
+	store   *TwitterAPI.FileStore
+	Account *TwitterAPI.Account
+}
+
+func main() {
+
+	C := &cli{}
+
+	flag.StringVar(&C.StorePath, "store", defaultStore(), "encrypted credential store")
+	flag.StringVar(&C.Name, "account", os.Getenv("GOTWEET_ACCOUNT"), "screen name of the saved account to use, optional when only one is saved")
+	flag.StringVar(&C.Format, "format", "json", "output format, json or table")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	Cmd, ok := commands[flag.Arg(0)]
+
+	if !ok {
+		fmt.Fprintf(os.Stderr, "gotweet: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	if C.Format != "json" && C.Format != "table" {
+		fatal(fmt.Errorf("unknown format %q, use json or table", C.Format))
+	}
+
+	if !Cmd.NoAccount {
+		if err := C.load(); err != nil {
+			fatal(err)
+		}
+	}
+
+	resp, err := Cmd.Run(C, flag.Args()[1:])
+
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+
+	if err != nil {
+		fatal(err)
+	}
+
+	if err := write(os.Stdout, C.Format, resp); err != nil {
+		fatal(err)
+	}
+}
+
+func usage() {
+
+	fmt.Fprintf(os.Stderr, "usage: gotweet [-store FILE] [-account NAME] [-format json|table] COMMAND [ARGS]\n\ncommands:\n")
+
+	var Names []string
+
+	for Name := range commands {
+		Names = append(Names, Name)
+	}
+
+	sort.Strings(Names)
+
+	for _, Name := range Names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", Name, commands[Name].Summary)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nglobal flags:\n")
+	flag.PrintDefaults()
+}
+
+func fatal(err error) {
+
+	if E, ok := err.(*TwitterAPI.APIError); ok && E.Code != 0 {
+		fmt.Fprintf(os.Stderr, "gotweet: %s (HTTP %d, code %d)\n", E.Message, E.StatusCode, E.Code)
+	} else {
+		fmt.Fprintf(os.Stderr, "gotweet: %v\n", err)
+	}
+
+	os.Exit(1)
+}
+
+func defaultStore() string {
+
+	if Path := os.Getenv("GOTWEET_STORE"); Path != "" {
+		return Path
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "gotweet-credentials.json"
+	}
+
+	return filepath.Join(home, ".gotweet", "credentials.json")
+}
+
+// passphrase comes from GOTWEET_PASSPHRASE so scripts don't need a terminal
+func passphrase() (string, error) {
+
+	if P := os.Getenv("GOTWEET_PASSPHRASE"); P != "" {
+		return P, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Credential store passphrase: ")
+
+	//don't echo it on a terminal
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+
+		line, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(line) == 0 {
+			return "", errors.New("no passphrase given, set GOTWEET_PASSPHRASE")
+		}
+
+		return string(line), nil
+	}
+
+	//piped in, read a byte at a time, auth reads the PIN from stdin right after
+	var line []byte
+	b := make([]byte, 1)
+
+	for {
+		n, err := os.Stdin.Read(b)
+
+		if n == 1 && b[0] != '\n' {
+			line = append(line, b[0])
+			continue
+		}
+
+		if n == 0 && err == nil {
+			continue
+		}
+
+		break
+	}
+
+	if len(line) == 0 {
+		return "", errors.New("no passphrase given, set GOTWEET_PASSPHRASE")
+	}
+
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
+func (C *cli) openStore() (*TwitterAPI.FileStore, error) {
+
+	if C.store != nil {
+		return C.store, nil
+	}
+
+	P, err := passphrase()
+
+	if err != nil {
+		return nil, err
+	}
+
+	S, err := TwitterAPI.NewFileStore(C.StorePath, P)
+
+	if err != nil {
+		return nil, err
+	}
+
+	C.store = S
+
+	return S, nil
+}
+
+// load picks the account named by -account, or the only saved one
+func (C *cli) load() error {
+
+	S, err := C.openStore()
+
+	if err != nil {
+		return err
+	}
+
+	Name := C.Name
+
+	if Name == "" {
+
+		Names, err := S.List()
+
+		if err != nil {
+			return err
+		}
+
+		switch len(Names) {
+		case 0:
+			return errors.New("no saved accounts, run gotweet auth first")
+		case 1:
+			Name = Names[0]
+		default:
+			return fmt.Errorf("several accounts are saved (%s), pick one with -account", strings.Join(Names, ", "))
+		}
+	}
+
+	A, err := TwitterAPI.LoadAccount(S, Name)
+
+	if err == TwitterAPI.ErrNoCredentials {
+		return fmt.Errorf("no saved account named %s, run gotweet auth first", Name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	C.Account = A
+
+	return nil
+}
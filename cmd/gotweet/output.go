@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// columns per kind of object, nested fields are written with dots
+var (
+	tweetColumns = []string{"id_str", "user.screen_name", "created_at", "text"}
+	userColumns  = []string{"id_str", "screen_name", "name", "followers_count", "friends_count"}
+	dmColumns    = []string{"id_str", "sender_screen_name", "created_at", "text"}
+)
+
+// write prints an API response as indented JSON or as a table
+func write(w io.Writer, Format, Resp string) error {
+
+	if Format == "table" {
+		var v interface{}
+
+		if err := json.Unmarshal([]byte(Resp), &v); err == nil {
+			return table(w, v)
+		}
+	}
+
+	var B bytes.Buffer
+
+	if err := json.Indent(&B, []byte(Resp), "", "  "); err != nil {
+		_, err := fmt.Fprintln(w, Resp)
+		return err
+	}
+
+	B.WriteByte('\n')
+
+	_, err := B.WriteTo(w)
+
+	return err
+}
+
+func table(w io.Writer, v interface{}) error {
+
+	T := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	//search results and cursored lists wrap the interesting array
+	if O, ok := v.(map[string]interface{}); ok {
+		for _, key := range []string{"statuses", "users", "ids"} {
+			if list, ok := O[key].([]interface{}); ok {
+				v = list
+				break
+			}
+		}
+	}
+
+	switch V := v.(type) {
+
+	case []interface{}:
+
+		var Rows []map[string]interface{}
+
+		for _, e := range V {
+			if O, ok := e.(map[string]interface{}); ok {
+				Rows = append(Rows, O)
+			} else {
+				fmt.Fprintln(T, cell(e))
+			}
+		}
+
+		if len(Rows) > 0 {
+			Columns := columns(Rows)
+
+			fmt.Fprintln(T, strings.ToUpper(strings.Join(Columns, "\t")))
+
+			for _, O := range Rows {
+
+				var Cells []string
+
+				for _, c := range Columns {
+					Cells = append(Cells, cell(field(O, c)))
+				}
+
+				fmt.Fprintln(T, strings.Join(Cells, "\t"))
+			}
+		}
+
+	case map[string]interface{}:
+
+		Columns := columns([]map[string]interface{}{V})
+
+		for _, c := range Columns {
+			fmt.Fprintf(T, "%s\t%s\n", c, cell(field(V, c)))
+		}
+
+	default:
+		fmt.Fprintln(T, cell(V))
+	}
+
+	return T.Flush()
+}
+
+// columns picks a known layout from the first row, or every scalar field for anything else
+func columns(Rows []map[string]interface{}) []string {
+
+	First := Rows[0]
+
+	switch {
+	case First["sender_screen_name"] != nil:
+		return dmColumns
+	case First["text"] != nil && First["user"] != nil:
+		return tweetColumns
+	case First["screen_name"] != nil && len(Rows) > 1:
+		return userColumns
+	}
+
+	seen := map[string]bool{}
+	var Columns []string
+
+	for _, O := range Rows {
+		for k, e := range O {
+			switch e.(type) {
+			case map[string]interface{}, []interface{}:
+				continue
+			}
+
+			if !seen[k] {
+				seen[k] = true
+				Columns = append(Columns, k)
+			}
+		}
+	}
+
+	sort.Strings(Columns)
+
+	return Columns
+}
+
+// field follows a dotted path like user.screen_name
+func field(O map[string]interface{}, Path string) interface{} {
+
+	var v interface{} = O
+
+	for _, key := range strings.Split(Path, ".") {
+
+		M, ok := v.(map[string]interface{})
+
+		if !ok {
+			return nil
+		}
+
+		v = M[key]
+	}
+
+	return v
+}
+
+// cell renders a value on one line, long text is cut so rows stay readable
+func cell(v interface{}) string {
+
+	var s string
+
+	switch V := v.(type) {
+	case nil:
+		return ""
+	case string:
+		s = V
+	case float64:
+		s = fmt.Sprintf("%.0f", V)
+		if float64(int64(V)) != V {
+			s = fmt.Sprint(V)
+		}
+	default:
+		s = fmt.Sprint(V)
+	}
+
+	s = strings.Join(strings.Fields(s), " ")
+
+	if r := []rune(s); len(r) > 80 {
+		s = string(r[:79]) + "…"
+	}
+
+	return s
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		format string
+		resp   string
+		want   string
+	}{
+		{"json is indented", "json", `{"id_str":"1"}`, "{\n  \"id_str\": \"1\"\n}\n"},
+		{"not json", "json", "plain", "plain\n"},
+		{"tweets", "table", `[{"id_str":"1","text":"hi  there","created_at":"now","user":{"screen_name":"gopher"}}]`,
+			"ID_STR  USER.SCREEN_NAME  CREATED_AT  TEXT\n1       gopher            now         hi there\n"},
+		{"search results", "table", `{"statuses":[{"id_str":"2","text":"found","created_at":"then","user":{"screen_name":"a"}}]}`,
+			"ID_STR  USER.SCREEN_NAME  CREATED_AT  TEXT\n2       a                 then        found\n"},
+		{"single object", "table", `{"screen_name":"gopher","followers_count":12,"status":{"text":"nested"}}`,
+			"followers_count  12\nscreen_name      gopher\n"},
+		{"ids", "table", `{"ids":[1,2]}`, "1\n2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var b strings.Builder
+
+			if err := write(&b, tt.format, tt.resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if b.String() != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCell(t *testing.T) {
+
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, ""},
+		{float64(1234567890123), "1234567890123"},
+		{1.5, "1.5"},
+		{"line\nbreak", "line break"},
+		{strings.Repeat("x", 100), strings.Repeat("x", 79) + "…"},
+	}
+
+	for _, tt := range tests {
+		if got := cell(tt.v); got != tt.want {
+			t.Errorf("cell(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}